@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+	"e-commerce/internal/fixture"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testConfig() *config.Config {
+	cfg := config.Defaults(defaultRateLimits)
+	return &cfg
+}
+
+// testAPI adalah router dengan data demo dan token untuk setiap user,
+// dipakai test yang memanggil endpoint lewat HTTP
+type testAPI struct {
+	t      *testing.T
+	router *gin.Engine
+	tokens map[string]string
+}
+
+// newTestAPI memuat fixture demo, membuat token untuk users (dicari lewat
+// Username) lalu menyiapkan router
+func newTestAPI(t *testing.T, users ...auth.User) *testAPI {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if err := loadFixtures(config.Fixtures{Set: fixture.SetDemo}); err != nil {
+		t.Fatal(err)
+	}
+	if err := initializeAuth(testConfig()); err != nil {
+		t.Fatal(err)
+	}
+	tokens := map[string]string{}
+	for _, user := range users {
+		token, _, err := tokenIssuer.Issue(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[user.Username] = token
+	}
+	return &testAPI{t: t, router: setupRouter(testConfig()), tokens: tokens}
+}
+
+// do mengirim request sebagai user, kosong berarti tanpa login. Data dari
+// response di-decode ke data kalau tidak nil.
+func (api *testAPI) do(user, method, path, body string, data interface{}) int {
+	api.t.Helper()
+	return api.send(user, method, path, body, nil, data)
+}
+
+// send sama dengan do dengan header tambahan
+func (api *testAPI) send(user, method, path, body string, header http.Header, data interface{}) int {
+	api.t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	for key := range header {
+		req.Header.Set(key, header.Get(key))
+	}
+	if user != "" {
+		req.Header.Set("Authorization", "Bearer "+api.tokens[user])
+	}
+	w := httptest.NewRecorder()
+	api.router.ServeHTTP(w, req)
+	if data != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{data}); err != nil {
+			api.t.Fatalf("%s %s: invalid response %q: %v", method, path, w.Body.String(), err)
+		}
+	}
+	return w.Code
+}
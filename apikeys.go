@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateAPIKeyRequest struct {
+	Name   string       `json:"name" validate:"required,max=100"`
+	Scopes []auth.Scope `json:"scopes" validate:"dive,scope"`
+}
+
+type RotateAPIKeyRequest struct {
+	// Durasi key lama masih berlaku, contoh "1h". Kosong berarti langsung dicabut.
+	GracePeriod string `json:"grace_period" validate:"duration"`
+}
+
+// Key asli hanya dikembalikan sekali saat dibuat atau dirotasi
+type IssuedAPIKey struct {
+	Key    string      `json:"key"`
+	APIKey auth.APIKey `json:"api_key"`
+}
+
+type StockUpdateRequest struct {
+	Stock *int `json:"stock" validate:"required,gte=0"`
+}
+
+// Domain event API key. Data-nya auth.StoredAPIKey yang berisi hash key,
+// jadi tidak diteruskan ke webhook.
+const (
+	EventAPIKeyCreated = "api_key.created"
+	EventAPIKeyUpdated = "api_key.updated"
+)
+
+var apiKeys = auth.NewAPIKeyStore()
+
+// API key handlers
+func createAPIKey(c *gin.Context) {
+	sourceID := c.Param("id")
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	// Validasi
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	if len(req.Scopes) == 0 {
+		req.Scopes = []auth.Scope{auth.ScopeStockWrite}
+	}
+
+	// Source bisa dihapus bersamaan, jadi dicek di commit yang sama dengan
+	// pembuatan key-nya
+	var plaintext string
+	var key auth.APIKey
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if !sourceExists(sourceID) {
+			return nil, nil, reject(http.StatusNotFound, "Source not found", apierror.NotFound("Source", sourceID))
+		}
+		var err error
+		plaintext, key, err = apiKeys.NewKey(sourceID, req.Name, req.Scopes)
+		if err != nil {
+			return nil, nil, reject(http.StatusInternalServerError, "Could not create API key", apierror.New(apierror.CodeInternal, err.Error()))
+		}
+		return func() { apiKeys.Put(key) }, []events.Message{{Type: EventAPIKeyCreated, Data: key.Stored()}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("API key created", "key_id", key.ID, "source_id", sourceID)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "API key created successfully",
+		Data:    IssuedAPIKey{Key: plaintext, APIKey: key},
+		Error:   nil,
+	})
+}
+
+func getAPIKeys(c *gin.Context) {
+	sourceID := c.Param("id")
+
+	if !sourceExists(sourceID) {
+		respondError(c, http.StatusNotFound, "Source not found", apierror.NotFound("Source", sourceID))
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "API keys retrieved successfully",
+		Data:    apiKeys.List(sourceID),
+		Error:   nil,
+	})
+}
+
+func rotateAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	var req RotateAPIKeyRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+			return
+		}
+	}
+
+	// Validasi
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	var grace time.Duration
+	if req.GracePeriod != "" {
+		grace, _ = time.ParseDuration(req.GracePeriod)
+	}
+
+	var plaintext string
+	var key auth.APIKey
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		var old auth.APIKey
+		var err error
+		plaintext, old, key, err = apiKeys.Rotation(id, grace)
+		if err != nil {
+			return nil, nil, rejectAPIKey(id, err)
+		}
+		return func() { apiKeys.Put(old, key) }, []events.Message{
+			{Type: EventAPIKeyUpdated, Data: old.Stored()},
+			{Type: EventAPIKeyCreated, Data: key.Stored()},
+		}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("API key rotated", "key_id", id, "new_key_id", key.ID, "grace_period", grace.String())
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "API key rotated successfully",
+		Data:    IssuedAPIKey{Key: plaintext, APIKey: key},
+		Error:   nil,
+	})
+}
+
+func revokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+
+	var key auth.APIKey
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		var err error
+		if key, err = apiKeys.Revocation(id); err != nil {
+			return nil, nil, rejectAPIKey(id, err)
+		}
+		return func() { apiKeys.Put(key) }, []events.Message{{Type: EventAPIKeyUpdated, Data: key.Stored()}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("API key revoked", "key_id", id, "source_id", key.SourceID)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "API key revoked successfully",
+		Data:    key,
+		Error:   nil,
+	})
+}
+
+func rejectAPIKey(id string, err error) error {
+	if errors.Is(err, auth.ErrAPIKeyNotFound) {
+		return reject(http.StatusNotFound, "API key not found", apierror.NotFound("API key", id))
+	}
+	return reject(http.StatusConflict, "API key is not active", apierror.New(apierror.CodeConflict, err.Error()))
+}
+
+// revokeSourceKeys mencabut API key milik source yang dihapus. Dipanggil
+// di dalam prepare; key yang dicabut disimpan lewat apply dan dicatat
+// sebagai api_key.updated.
+func revokeSourceKeys(sourceID string) (func(), []events.Message) {
+	revoked := apiKeys.SourceRevocations(sourceID)
+	messages := make([]events.Message, len(revoked))
+	for i, key := range revoked {
+		messages[i] = events.Message{Type: EventAPIKeyUpdated, Data: key.Stored()}
+	}
+	return func() { apiKeys.Put(revoked...) }, messages
+}
+
+// Update stock oleh staff atau supplier. API key supplier hanya boleh
+// mengubah produk dengan SourceID yang sama dengan source pemilik key.
+func updateProductStock(c *gin.Context) {
+	id := c.Param("id")
+
+	var req StockUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	// Validasi
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	principal, _ := auth.FromContext(c)
+	var updatedProduct Product
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		index, err := productIndex(id)
+		if err != nil {
+			return nil, nil, reject(http.StatusNotFound, "Product not found", apierror.NotFound("Product", id))
+		}
+		product := products[index]
+		if principal.Role == auth.RoleSupplier && product.SourceID != principal.SourceID {
+			return nil, nil, reject(http.StatusForbidden, "Forbidden", apierror.New(apierror.CodeForbidden, "API key can only update products of source "+principal.SourceID))
+		}
+		if apiErr := checkStockFloor(id, *req.Stock); apiErr != nil {
+			return nil, nil, reject(http.StatusConflict, "Stock held in other warehouses", apiErr)
+		}
+
+		updatedProduct = product
+		updatedProduct.Stock = *req.Stock
+		return func() { products[index] = updatedProduct }, productUpdated(product, updatedProduct, StockReasonAdjustment), nil
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Product stock updated successfully",
+		Data:    updatedProduct,
+		Error:   nil,
+	})
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"e-commerce/internal/auth"
+)
+
+func TestSupplierKeyUpdatesOnlyOwnProducts(t *testing.T) {
+	api := newTestAPI(t, auth.User{ID: "u1", Username: "admin", Role: auth.RoleAdmin})
+
+	// Laptop demo dari source 1, Mouse dari source 2
+	var issued IssuedAPIKey
+	if code := api.do("admin", http.MethodPost, "/sources/1/api-keys", `{"name":"erp"}`, &issued); code != http.StatusCreated || issued.Key == "" {
+		t.Fatalf("create key status %d: %+v", code, issued)
+	}
+	header := http.Header{"X-Api-Key": {issued.Key}}
+
+	if code := api.send("", http.MethodPatch, "/products/2/stock", `{"stock":1}`, header, nil); code != http.StatusForbidden {
+		t.Errorf("other source's product status %d", code)
+	}
+	if product, _ := findProduct("2"); product.Stock != 50 {
+		t.Errorf("other source's product stock changed to %d", product.Stock)
+	}
+	var product Product
+	if code := api.send("", http.MethodPatch, "/products/1/stock", `{"stock":7}`, header, &product); code != http.StatusOK || product.Stock != 7 {
+		t.Errorf("own product status %d: %+v", code, product)
+	}
+
+	// Key dengan token JWT sekaligus ditolak, key yang dicabut tidak berlaku
+	if code := api.send("admin", http.MethodPatch, "/products/1/stock", `{"stock":7}`, header, nil); code != http.StatusBadRequest {
+		t.Errorf("key with bearer token status %d", code)
+	}
+	if code := api.do("admin", http.MethodDelete, "/api-keys/"+issued.APIKey.ID, "", nil); code != http.StatusOK {
+		t.Fatalf("revoke status %d", code)
+	}
+	if code := api.send("", http.MethodPatch, "/products/1/stock", `{"stock":8}`, header, nil); code != http.StatusUnauthorized {
+		t.Errorf("revoked key status %d", code)
+	}
+}
+
+func TestRotateGracePeriod(t *testing.T) {
+	api := newTestAPI(t, auth.User{ID: "u1", Username: "admin", Role: auth.RoleAdmin})
+
+	var issued IssuedAPIKey
+	if code := api.do("admin", http.MethodPost, "/sources/1/api-keys", `{"name":"erp"}`, &issued); code != http.StatusCreated {
+		t.Fatalf("create key status %d", code)
+	}
+
+	// Grace 0 sama dengan tanpa grace, yang negatif ditolak
+	path := "/api-keys/" + issued.APIKey.ID + "/rotate"
+	if code := api.do("admin", http.MethodPost, path, `{"grace_period":"-1h"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("negative grace period status %d", code)
+	}
+	if code := api.do("admin", http.MethodPost, path, `{"grace_period":"0s"}`, nil); code != http.StatusCreated {
+		t.Errorf("zero grace period status %d", code)
+	}
+	if key, _ := apiKeys.Get(issued.APIKey.ID); key.RevokedAt == nil {
+		t.Error("old key still active after rotating without grace")
+	}
+}
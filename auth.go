@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+	"e-commerce/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Role      auth.Role `json:"role"`
+}
+
+var (
+	tokenIssuer *auth.TokenIssuer
+	authUsers   *auth.UserStore
+)
+
+// Akun sample untuk development kalau AUTH_USERS_FILE tidak diset. Di
+// mode release server tidak start tanpa AUTH_USERS_FILE.
+var defaultAuthUsers = []struct {
+	id, username, password string
+	role                   auth.Role
+}{
+	{"u1", "admin", "admin123", auth.RoleAdmin},
+	{"u2", "staff", "staff123", auth.RoleStaff},
+	{"u3", "customer", "customer123", auth.RoleCustomer},
+}
+
+// initializeAuth menyiapkan signing key dan user store dari environment:
+//   - AUTH_JWT_KEYS: "id=secret,id2=secret2", key pertama dipakai untuk sign
+//   - AUTH_TOKEN_TTL: umur token, default 24h
+//   - AUTH_USERS_FILE: file JSON berisi user dengan password_hash bcrypt
+func initializeAuth(cfg *config.Config) error {
+	var keys []auth.Key
+	if spec := os.Getenv("AUTH_JWT_KEYS"); spec != "" {
+		parsed, err := auth.ParseKeys(spec)
+		if err != nil {
+			return fmt.Errorf("AUTH_JWT_KEYS: %w", err)
+		}
+		keys = parsed
+	} else {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		slog.Warn("AUTH_JWT_KEYS not set, using a random signing key (tokens are invalidated on restart)")
+		keys = []auth.Key{{ID: "dev-" + hex.EncodeToString(secret[:4]), Secret: secret}}
+	}
+
+	ttl := 24 * time.Hour
+	if value := os.Getenv("AUTH_TOKEN_TTL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("AUTH_TOKEN_TTL: %w", err)
+		}
+		ttl = parsed
+	}
+
+	issuer, err := auth.NewTokenIssuer(keys, ttl)
+	if err != nil {
+		return err
+	}
+	tokenIssuer = issuer
+
+	if path := os.Getenv("AUTH_USERS_FILE"); path != "" {
+		store, err := auth.LoadUserStore(path)
+		if err != nil {
+			return fmt.Errorf("AUTH_USERS_FILE: %w", err)
+		}
+		authUsers = store
+		return nil
+	}
+
+	// Password akun sample ada di README, jadi tidak boleh dipakai di
+	// production
+	if cfg.Mode == gin.ReleaseMode {
+		return errors.New("AUTH_USERS_FILE is required in release mode")
+	}
+	slog.Warn("AUTH_USERS_FILE not set, using the sample accounts admin, staff and customer")
+
+	var users []auth.User
+	for _, u := range defaultAuthUsers {
+		hash, err := auth.HashPassword(u.password)
+		if err != nil {
+			return err
+		}
+		users = append(users, auth.User{ID: u.id, Username: u.username, PasswordHash: hash, Role: u.role})
+	}
+	store, err := auth.NewUserStore(users)
+	if err != nil {
+		return err
+	}
+	authUsers = store
+	return nil
+}
+
+// Auth handlers
+func login(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	// Validasi
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	user, err := authUsers.Authenticate(req.Username, req.Password)
+	if err != nil {
+		logging.From(c).Warn("Login failed", "username", req.Username)
+		respondError(c, http.StatusUnauthorized, "Login failed", apierror.New(apierror.CodeInvalidCredentials, err.Error()))
+		return
+	}
+
+	token, claims, err := tokenIssuer.Issue(user)
+	if err != nil {
+		logging.From(c).Error("Could not issue token", "user_id", user.ID, "error", err)
+		respondError(c, http.StatusInternalServerError, "Login failed", apierror.New(apierror.CodeInternal, "Could not issue token"))
+		return
+	}
+	logging.From(c).Info("Login successful", "user_id", user.ID, "role", user.Role)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Login successful",
+		Data: LoginResponse{
+			Token:     token,
+			TokenType: "Bearer",
+			ExpiresAt: time.Unix(claims.ExpiresAt, 0).UTC(),
+			Role:      claims.Role,
+		},
+		Error: nil,
+	})
+}
+
+func getMe(c *gin.Context) {
+	principal, _ := auth.FromContext(c)
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Current user retrieved successfully",
+		Data:    principal,
+		Error:   nil,
+	})
+}
+
+// Helper function untuk cek apakah principal boleh melihat transaksi.
+// Customer hanya boleh melihat transaksinya sendiri.
+func canAccessTransaction(principal *auth.Principal, transaction Transaction) bool {
+	if principal.HasRole(auth.RoleAdmin, auth.RoleStaff) {
+		return true
+	}
+	if transaction.UserID != "" && transaction.UserID == principal.Subject {
+		return true
+	}
+	// Termasuk pesanan guest yang sudah di-merge ke profil customer
+	customer, found := customerForUser(principal.Subject)
+	return found && transaction.CustomerID == customer.ID
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+	"e-commerce/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCanAccessTransaction(t *testing.T) {
+	saved := customers
+	customers = []Customer{{ID: "c1", UserID: "u3"}, {ID: "c2", UserID: "u4"}}
+	t.Cleanup(func() { customers = saved })
+
+	own := Transaction{ID: "t1", UserID: "u3", CustomerID: "c1"}
+	// Pesanan guest yang sudah di-merge ke customer c1
+	merged := Transaction{ID: "t2", CustomerID: "c1"}
+	// Dibuat staff atas nama customer c2
+	forOther := Transaction{ID: "t3", UserID: "u2", CustomerID: "c2"}
+
+	tests := []struct {
+		name        string
+		principal   auth.Principal
+		transaction Transaction
+		want        bool
+	}{
+		{"admin", auth.Principal{Subject: "u1", Role: auth.RoleAdmin}, own, true},
+		{"staff", auth.Principal{Subject: "u2", Role: auth.RoleStaff}, own, true},
+		{"own by user", auth.Principal{Subject: "u3", Role: auth.RoleCustomer}, own, true},
+		{"own merged guest order", auth.Principal{Subject: "u3", Role: auth.RoleCustomer}, merged, true},
+		{"own by customer", auth.Principal{Subject: "u4", Role: auth.RoleCustomer}, forOther, true},
+		{"other customer", auth.Principal{Subject: "u4", Role: auth.RoleCustomer}, own, false},
+		{"other customer merged order", auth.Principal{Subject: "u4", Role: auth.RoleCustomer}, merged, false},
+		{"without customer profile", auth.Principal{Subject: "u5", Role: auth.RoleCustomer}, merged, false},
+		{"supplier", auth.Principal{Subject: "apikey:1", Role: auth.RoleSupplier, KeyID: "1"}, own, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canAccessTransaction(&tt.principal, tt.transaction); got != tt.want {
+				t.Errorf("canAccessTransaction() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginLimitIgnoresForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := initializeAuth(testConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(cfg *config.Config) []int {
+		r := setupRouter(cfg)
+		var codes []int
+		for i := 1; i <= 3; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewBufferString(`{"username":"admin","password":"wrong"}`))
+			req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			codes = append(codes, w.Code)
+		}
+		return codes
+	}
+
+	// Header dari client yang bukan proxy tidak membuat bucket baru
+	cfg := testConfig()
+	cfg.RateLimits["auth"] = ratelimit.Rule{Requests: 2, Period: time.Minute}
+	if codes := login(cfg); codes[2] != http.StatusTooManyRequests {
+		t.Errorf("status codes = %v, want the third login limited", codes)
+	}
+
+	// Di belakang proxy yang dipercaya, setiap IP client punya bucket sendiri
+	cfg.Proxies.Trusted = []string{"192.0.2.1"}
+	if codes := login(cfg); codes[2] != http.StatusUnauthorized {
+		t.Errorf("status codes behind a trusted proxy = %v, want every login checked", codes)
+	}
+}
+
+func TestReleaseModeRequiresUsersFile(t *testing.T) {
+	t.Setenv("AUTH_USERS_FILE", "")
+	cfg := testConfig()
+	cfg.Mode = gin.ReleaseMode
+	if err := initializeAuth(cfg); err == nil {
+		t.Error("release mode started with the sample accounts")
+	}
+}
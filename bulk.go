@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Mode bulk operation
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+)
+
+// Satu operasi di dalam request bulk
+type BulkOperation struct {
+	Op   string          `json:"op" validate:"required,oneof=create update delete"`
+	ID   string          `json:"id" validate:"required_unless=Op create"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Operasi divalidasi satu per satu supaya error masuk ke status per item
+type BulkRequest struct {
+	Mode       string          `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	Operations []BulkOperation `json:"operations" validate:"required,min=1,max=1000"`
+}
+
+// Status per item, pesan error sama dengan endpoint tunggal
+type BulkItemResult struct {
+	Index  int             `json:"index"`
+	Op     string          `json:"op"`
+	ID     string          `json:"id,omitempty"`
+	Status int             `json:"status"`
+	Data   interface{}     `json:"data"`
+	Error  *apierror.Error `json:"error"`
+
+	// events dicatat ke outbox dan apply dijalankan di dalam commit hanya
+	// kalau perubahan benar-benar disimpan
+	events []events.Message
+	apply  func()
+}
+
+type BulkResult struct {
+	Mode      string           `json:"mode"`
+	Committed bool             `json:"committed"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// bulkBatch adalah salinan state yang diubah operasi bulk. Salinan baru
+// dipasang ke state bersama di dalam commit, jadi snapshot tidak pernah
+// melihat batch yang setengah jalan atau yang dibatalkan.
+type bulkBatch struct {
+	products []Product
+	sources  []Source
+}
+
+func bulkProducts(c *gin.Context) {
+	runBulk(c, "Products", applyProductOperation)
+}
+
+func bulkSources(c *gin.Context) {
+	runBulk(c, "Sources", applySourceOperation)
+}
+
+// runBulk menjalankan semua operasi secara berurutan. Di mode atomic,
+// kalau ada satu operasi yang gagal tidak ada perubahan yang disimpan.
+func runBulk(c *gin.Context, resource string, apply func(batch *bulkBatch, op BulkOperation) BulkItemResult) {
+	var req BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	// Validasi
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = BulkModeAtomic
+	}
+
+	// Batch dijalankan di antara dua commit di atas salinan state. ID yang
+	// sudah dibuat untuk batch yang dibatalkan tidak dipakai lagi.
+	result := BulkResult{Mode: req.Mode, Results: make([]BulkItemResult, 0, len(req.Operations))}
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		batch := &bulkBatch{
+			products: append([]Product(nil), products...),
+			sources:  append([]Source(nil), sources...),
+		}
+		firstFailed := -1
+		for i, op := range req.Operations {
+			item := apply(batch, op)
+			item.Index = i
+			item.Op = op.Op
+			if item.Status >= http.StatusBadRequest {
+				result.Failed++
+				if firstFailed < 0 {
+					firstFailed = i
+				}
+			} else {
+				result.Succeeded++
+			}
+			result.Results = append(result.Results, item)
+		}
+
+		if req.Mode == BulkModeAtomic && result.Failed > 0 {
+			// Operasi yang tadinya berhasil ikut dibatalkan
+			for i := range result.Results {
+				if result.Results[i].Status < http.StatusBadRequest {
+					if result.Results[i].Op == "create" {
+						result.Results[i].ID = ""
+					}
+					result.Results[i].Status = http.StatusFailedDependency
+					result.Results[i].Data = nil
+					result.Results[i].Error = apierror.Newf(apierror.CodeBulkRolledBack, "Rolled back because operation %d failed", firstFailed)
+				}
+			}
+			result.Failed = len(result.Results)
+			result.Succeeded = 0
+			return nil, nil, &rejection{
+				status:  http.StatusBadRequest,
+				message: "Bulk operation rolled back",
+				data:    result,
+				err:     apierror.Newf(apierror.CodeBulkRolledBack, "Operation %d failed", firstFailed),
+			}
+		}
+
+		// Semua event dicatat sekaligus; kalau outbox gagal ditulis, salinan
+		// tidak dipasang
+		var messages []events.Message
+		for _, item := range result.Results {
+			if item.Status < http.StatusBadRequest {
+				messages = append(messages, item.events...)
+			}
+		}
+		return func() {
+			products, sources = batch.products, batch.sources
+			for _, item := range result.Results {
+				if item.Status < http.StatusBadRequest && item.apply != nil {
+					item.apply()
+				}
+			}
+		}, messages, nil
+	}) {
+		return
+	}
+
+	result.Committed = true
+	status := http.StatusOK
+	if result.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	c.JSON(status, APIResponse{
+		Message: resource + " bulk operation completed",
+		Data:    result,
+		Error:   nil,
+	})
+}
+
+func bulkFailure(status int, err *apierror.Error) BulkItemResult {
+	return BulkItemResult{Status: status, Error: err}
+}
+
+func applyProductOperation(batch *bulkBatch, op BulkOperation) BulkItemResult {
+	if fields := validate.Struct(&op); len(fields) > 0 {
+		return bulkFailure(http.StatusBadRequest, apierror.Validation(fields...))
+	}
+
+	switch op.Op {
+	case "create":
+		var newProduct Product
+		if err := json.Unmarshal(op.Data, &newProduct); err != nil {
+			return bulkFailure(http.StatusBadRequest, apierror.InvalidBody(err))
+		}
+
+		normalizeCurrency(&newProduct.Currency)
+		if fields := validate.Struct(&newProduct); len(fields) > 0 {
+			return bulkFailure(http.StatusBadRequest, apierror.Validation(fields...))
+		}
+
+		newProduct.ID = generateID()
+		batch.products = append(batch.products, newProduct)
+		return BulkItemResult{ID: newProduct.ID, Status: http.StatusCreated, Data: newProduct,
+			events: []events.Message{{Type: EventProductCreated, Data: newProduct}}}
+
+	case "update":
+		var updatedProduct Product
+		if err := json.Unmarshal(op.Data, &updatedProduct); err != nil {
+			return bulkFailure(http.StatusBadRequest, apierror.InvalidBody(err))
+		}
+
+		for i, product := range batch.products {
+			if product.ID == op.ID {
+				normalizeCurrency(&updatedProduct.Currency)
+				if fields := validate.Struct(&updatedProduct); len(fields) > 0 {
+					return bulkFailure(http.StatusBadRequest, apierror.Validation(fields...))
+				}
+				if apiErr := checkStockFloor(op.ID, updatedProduct.Stock); apiErr != nil {
+					return bulkFailure(http.StatusConflict, apiErr)
+				}
+
+				updatedProduct.ID = op.ID
+				batch.products[i] = updatedProduct
+				return BulkItemResult{ID: op.ID, Status: http.StatusOK, Data: updatedProduct,
+					events: productUpdated(product, updatedProduct, StockReasonUpdate)}
+			}
+		}
+		return bulkFailure(http.StatusNotFound, apierror.NotFound("Product", op.ID))
+
+	case "delete":
+		for i, product := range batch.products {
+			if product.ID == op.ID {
+				batch.products = append(batch.products[:i], batch.products[i+1:]...)
+				return BulkItemResult{ID: op.ID, Status: http.StatusOK,
+					events: []events.Message{{Type: EventProductDeleted, Data: product}},
+					apply:  func() { removeProductStock(op.ID) }}
+			}
+		}
+		return bulkFailure(http.StatusNotFound, apierror.NotFound("Product", op.ID))
+	}
+
+	return bulkFailure(http.StatusBadRequest, apierror.Validation(apierror.Field("op", "oneof", "Op must be one of create, update or delete")))
+}
+
+func applySourceOperation(batch *bulkBatch, op BulkOperation) BulkItemResult {
+	if fields := validate.Struct(&op); len(fields) > 0 {
+		return bulkFailure(http.StatusBadRequest, apierror.Validation(fields...))
+	}
+
+	switch op.Op {
+	case "create":
+		var newSource Source
+		if err := json.Unmarshal(op.Data, &newSource); err != nil {
+			return bulkFailure(http.StatusBadRequest, apierror.InvalidBody(err))
+		}
+
+		if fields := validate.Struct(&newSource); len(fields) > 0 {
+			return bulkFailure(http.StatusBadRequest, apierror.Validation(fields...))
+		}
+
+		newSource.ID = generateID()
+		batch.sources = append(batch.sources, newSource)
+		return BulkItemResult{ID: newSource.ID, Status: http.StatusCreated, Data: newSource,
+			events: []events.Message{{Type: EventSourceCreated, Data: newSource}}}
+
+	case "update":
+		var updatedSource Source
+		if err := json.Unmarshal(op.Data, &updatedSource); err != nil {
+			return bulkFailure(http.StatusBadRequest, apierror.InvalidBody(err))
+		}
+
+		for i, source := range batch.sources {
+			if source.ID == op.ID {
+				if fields := validate.Struct(&updatedSource); len(fields) > 0 {
+					return bulkFailure(http.StatusBadRequest, apierror.Validation(fields...))
+				}
+
+				updatedSource.ID = op.ID
+				batch.sources[i] = updatedSource
+				return BulkItemResult{ID: op.ID, Status: http.StatusOK, Data: updatedSource,
+					events: []events.Message{{Type: EventSourceUpdated, Data: updatedSource}}}
+			}
+		}
+		return bulkFailure(http.StatusNotFound, apierror.NotFound("Source", op.ID))
+
+	case "delete":
+		for i, source := range batch.sources {
+			if source.ID == op.ID {
+				batch.sources = append(batch.sources[:i], batch.sources[i+1:]...)
+				revokeKeys, keyEvents := revokeSourceKeys(source.ID)
+				return BulkItemResult{ID: op.ID, Status: http.StatusOK,
+					events: append([]events.Message{{Type: EventSourceDeleted, Data: source}}, keyEvents...),
+					apply:  revokeKeys}
+			}
+		}
+		return bulkFailure(http.StatusNotFound, apierror.NotFound("Source", op.ID))
+	}
+
+	return bulkFailure(http.StatusBadRequest, apierror.Validation(apierror.Field("op", "oneof", "Op must be one of create, update or delete")))
+}
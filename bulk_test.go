@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/config"
+	"e-commerce/internal/fixture"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulk mengirim request ke endpoint bulk dan mengembalikan status beserta
+// hasil per item
+func bulk(t *testing.T, path, body string) (int, BulkResult) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/products/bulk", bulkProducts)
+	r.POST("/sources/bulk", bulkSources)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body)))
+	var result BulkResult
+	if err := json.Unmarshal(w.Body.Bytes(), &APIResponse{Data: &result}); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	return w.Code, result
+}
+
+func loadDemoFixtures(t *testing.T) {
+	t.Helper()
+	if err := loadFixtures(config.Fixtures{Set: fixture.SetDemo}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// hasError mengecek error item, code kosong berarti item tidak boleh error
+func hasError(item BulkItemResult, code apierror.Code, message string) bool {
+	if code == "" {
+		return item.Error == nil
+	}
+	return item.Error != nil && item.Error.Code == code && item.Error.Message == message
+}
+
+func TestBulkAtomicRollsBack(t *testing.T) {
+	loadDemoFixtures(t)
+
+	code, result := bulk(t, "/products/bulk", `{"operations":[
+		{"op":"create","data":{"name":"Keyboard","price":500000,"stock":5,"source_id":"1"}},
+		{"op":"update","id":"1","data":{"name":"Laptop Pro","price":20000000,"stock":3,"source_id":"1"}},
+		{"op":"delete","id":"99"}
+	]}`)
+	if code != http.StatusBadRequest || result.Committed || result.Succeeded != 0 || result.Failed != 3 {
+		t.Fatalf("status %d: %+v", code, result)
+	}
+
+	// Operasi yang gagal membawa pesan aslinya, yang lain ikut dibatalkan
+	for i, id := range []string{"", "1"} {
+		item := result.Results[i]
+		if item.Status != http.StatusFailedDependency || item.ID != id || item.Data != nil || !hasError(item, apierror.CodeBulkRolledBack, "Rolled back because operation 2 failed") {
+			t.Errorf("result %d = %+v", i, item)
+		}
+	}
+	if item := result.Results[2]; item.Index != 2 || item.Status != http.StatusNotFound || !hasError(item, apierror.CodeNotFound, "Product with ID 99 not found") {
+		t.Errorf("failed result = %+v", item)
+	}
+
+	if len(products) != 2 || products[0].Name != "Laptop" {
+		t.Errorf("state changed after rollback: %+v", products)
+	}
+}
+
+func TestBulkBestEffortKeepsSucceededOperations(t *testing.T) {
+	loadDemoFixtures(t)
+
+	code, result := bulk(t, "/products/bulk", `{"mode":"best_effort","operations":[
+		{"op":"create","data":{"name":"Keyboard","price":500000,"stock":5,"source_id":"1"}},
+		{"op":"create","data":{"name":"Monitor","price":0,"stock":5,"source_id":"1"}},
+		{"op":"create","data":{"name":"Webcam","price":300000,"stock":5,"source_id":"9"}},
+		{"op":"archive","id":"1"},
+		{"op":"delete","id":"2"}
+	]}`)
+	if code != http.StatusMultiStatus || !result.Committed || result.Succeeded != 2 || result.Failed != 3 {
+		t.Fatalf("status %d: %+v", code, result)
+	}
+
+	// Pesan per item sama dengan endpoint tunggal
+	want := []struct {
+		status  int
+		code    apierror.Code
+		message string
+	}{
+		{http.StatusCreated, "", ""},
+		{http.StatusBadRequest, apierror.CodeValidationFailed, "Price must be greater than 0"},
+		{http.StatusBadRequest, apierror.CodeValidationFailed, "Source ID not found"},
+		{http.StatusBadRequest, apierror.CodeValidationFailed, "Op must be one of create, update, delete"},
+		{http.StatusOK, "", ""},
+	}
+	for i, item := range result.Results {
+		if item.Status != want[i].status || !hasError(item, want[i].code, want[i].message) {
+			t.Errorf("result %d = %+v, error %+v", i, item, item.Error)
+		}
+	}
+	if result.Results[0].ID != "3" {
+		t.Errorf("created ID %q", result.Results[0].ID)
+	}
+
+	if len(products) != 2 || products[0].ID != "1" || products[1].ID != "3" {
+		t.Errorf("products after best effort: %+v", products)
+	}
+}
+
+func TestBulkSources(t *testing.T) {
+	loadDemoFixtures(t)
+
+	code, result := bulk(t, "/sources/bulk", `{"operations":[
+		{"op":"update","id":"2","data":{"name":"Supplier C"}},
+		{"op":"create","data":{"name":"Supplier D"}}
+	]}`)
+	if code != http.StatusOK || !result.Committed || result.Succeeded != 2 {
+		t.Fatalf("status %d: %+v", code, result)
+	}
+	if len(sources) != 3 || sources[1].Name != "Supplier C" || sources[2].ID != "3" {
+		t.Errorf("sources: %+v", sources)
+	}
+}
+
+func TestBulkRejectsInvalidRequest(t *testing.T) {
+	loadDemoFixtures(t)
+
+	for _, body := range []string{
+		`{"operations":[]}`,
+		`{"mode":"partial","operations":[{"op":"delete","id":"1"}]}`,
+		`{"operations":`,
+	} {
+		if code, _ := bulk(t, "/products/bulk", body); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d", body, code)
+		}
+	}
+	if len(products) != 2 {
+		t.Errorf("products changed: %+v", products)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"e-commerce/internal/apierror"
+)
+
+// envelope sama dengan APIResponse di server. Data dibiarkan mentah dan
+// di-decode sesuai endpoint.
+type envelope struct {
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Error   *apierror.Error `json:"error"`
+}
+
+// APIError adalah response error dari server
+type APIError struct {
+	Status  int
+	Message string
+	Err     *apierror.Error
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%d %s)", e.Message, e.Status, e.Err.Code)
+	if len(e.Err.Fields) == 0 && e.Err.Message != "" && e.Err.Message != e.Message {
+		fmt.Fprintf(&b, ": %s", e.Err.Message)
+	}
+	for _, f := range e.Err.Fields {
+		fmt.Fprintf(&b, "\n  %s: %s", f.Field, f.Message)
+	}
+	if e.Err.RequestID != "" {
+		fmt.Fprintf(&b, "\n  request_id: %s", e.Err.RequestID)
+	}
+	return b.String()
+}
+
+// Client memanggil API dengan kredensial dari profile
+type Client struct {
+	profile Profile
+	http    *http.Client
+	token   string
+}
+
+func newClient(profile Profile, timeout time.Duration) *Client {
+	return &Client{
+		profile: profile,
+		http:    &http.Client{Timeout: timeout},
+		token:   profile.Token,
+	}
+}
+
+// do mengirim request dan men-decode data ke out (boleh nil). Body non-nil
+// dikirim sebagai JSON.
+func (c *Client) do(method, path string, query url.Values, body, out interface{}) error {
+	if err := c.login(); err != nil {
+		return err
+	}
+	return c.send(method, path, query, body, out)
+}
+
+// login menukar username dan password dengan token sekali per proses
+func (c *Client) login() error {
+	if c.token != "" || c.profile.APIKey != "" || c.profile.Username == "" {
+		return nil
+	}
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	req := map[string]string{"username": c.profile.Username, "password": c.profile.Password}
+	if err := c.send(http.MethodPost, "/auth/login", nil, req, &resp); err != nil {
+		return fmt.Errorf("login as %s: %w", c.profile.Username, err)
+	}
+	c.token = resp.Token
+	return nil
+}
+
+func (c *Client) send(method, path string, query url.Values, body, out interface{}) error {
+	u := strings.TrimRight(c.profile.BaseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.token != "":
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	case c.profile.APIKey != "":
+		req.Header.Set("X-API-Key", c.profile.APIKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("unexpected response from %s %s: %s", method, path, resp.Status)
+	}
+	// Data tetap di-decode kalau ada error, misalnya hasil per baris dari
+	// bulk yang di-rollback
+	if out != nil && len(env.Data) > 0 && !bytes.Equal(env.Data, []byte("null")) {
+		decoder := json.NewDecoder(bytes.NewReader(env.Data))
+		decoder.UseNumber()
+		if err := decoder.Decode(out); err != nil && env.Error == nil {
+			return err
+		}
+	}
+	if env.Error != nil {
+		return &APIError{Status: resp.StatusCode, Message: env.Message, Err: env.Error}
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return nil
+}
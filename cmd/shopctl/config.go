@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultBaseURL = "http://localhost:8080"
+
+// Profile berisi alamat server dan kredensial. Cukup isi salah satu:
+// username dan password, token, atau api_key.
+type Profile struct {
+	BaseURL  string `yaml:"base_url"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	Token    string `yaml:"token,omitempty"`
+	APIKey   string `yaml:"api_key,omitempty"`
+}
+
+// authMethod untuk ditampilkan di "config list", tanpa rahasianya
+func (p Profile) authMethod() string {
+	switch {
+	case p.Token != "":
+		return "token"
+	case p.APIKey != "":
+		return "api_key"
+	case p.Username != "":
+		return "login:" + p.Username
+	}
+	return "none"
+}
+
+// Config adalah isi file konfigurasi shopctl
+type Config struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// defaultConfigPath adalah $XDG_CONFIG_HOME/shopctl/config.yaml atau
+// padanannya di OS lain
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "shopctl.yaml"
+	}
+	return filepath.Join(dir, "shopctl", "config.yaml")
+}
+
+// loadConfig membaca file konfigurasi. File yang belum ada dianggap kosong.
+func loadConfig(path string) (*Config, error) {
+	cfg := &Config{Profiles: map[string]Profile{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = map[string]Profile{}
+	}
+	return cfg, nil
+}
+
+// save menulis konfigurasi dengan permission 0600 karena berisi kredensial
+func (c *Config) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+func (c *Config) names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve memilih profile dengan prioritas flag > env > file. Tanpa
+// profile sama sekali, dipakai server lokal tanpa kredensial.
+func (c *Config) resolve(g *globals) (Profile, error) {
+	name := firstNonEmpty(g.profile, os.Getenv("SHOPCTL_PROFILE"), c.Current)
+
+	var profile Profile
+	if name != "" {
+		p, ok := c.Profiles[name]
+		if !ok {
+			return Profile{}, fmt.Errorf("profile %q not found", name)
+		}
+		profile = p
+	}
+
+	profile.BaseURL = firstNonEmpty(g.baseURL, os.Getenv("SHOPCTL_BASE_URL"), profile.BaseURL, defaultBaseURL)
+	if token := firstNonEmpty(g.token, os.Getenv("SHOPCTL_TOKEN")); token != "" {
+		profile.Token = token
+	}
+	if key := firstNonEmpty(g.apiKey, os.Getenv("SHOPCTL_API_KEY")); key != "" {
+		profile.APIKey = key
+	}
+	return profile, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,282 @@
+// Command shopctl adalah client command line untuk API e-commerce. Produk,
+// source dan transaksi bisa di-list, dibuat, diubah, dihapus, di-import dari
+// CSV/JSON dan di-export. Alamat server dan kredensial disimpan per profile.
+//
+//	shopctl config set local --base-url http://localhost:8080 --username admin --password admin123
+//	shopctl products list --source-id 1 -o csv
+//	shopctl products import products.csv --mode best_effort
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// globals berisi flag yang berlaku untuk semua command
+type globals struct {
+	configPath string
+	profile    string
+	output     string
+	baseURL    string
+	token      string
+	apiKey     string
+	timeout    time.Duration
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run mengembalikan exit code: 0 sukses, 1 error dari API atau I/O, 2 salah
+// pemakaian
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	g := &globals{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("shopctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&g.configPath, "config", firstNonEmpty(os.Getenv("SHOPCTL_CONFIG"), defaultConfigPath()), "config file (SHOPCTL_CONFIG)")
+	fs.StringVar(&g.profile, "profile", "", "profile to use (SHOPCTL_PROFILE)")
+	fs.StringVar(&g.baseURL, "base-url", "", "override the profile base URL (SHOPCTL_BASE_URL)")
+	fs.StringVar(&g.token, "token", "", "override the profile token (SHOPCTL_TOKEN)")
+	fs.StringVar(&g.apiKey, "api-key", "", "override the profile API key (SHOPCTL_API_KEY)")
+	fs.DurationVar(&g.timeout, "timeout", 30*time.Second, "HTTP timeout")
+	outputFlag(fs, g)
+	fs.Usage = func() { usage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() == 0 {
+		usage(fs)
+		return 2
+	}
+
+	err := dispatch(g, fs.Arg(0), fs.Args()[1:])
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(stderr, "shopctl:", err)
+		return 2
+	}
+	fmt.Fprintln(stderr, "Error:", err)
+	return 1
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintln(out, "Usage: shopctl [flags] <resource> <action> [args]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Resources:")
+	for _, name := range resourceNames() {
+		r := resources[name]
+		fmt.Fprintf(out, "  %-14s %s\n", name, strings.Join(r.actions(), ", "))
+	}
+	fmt.Fprintf(out, "  %-14s list, show, use, set, delete\n", "config")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	fs.PrintDefaults()
+}
+
+// usageError ditampilkan dengan exit code 2
+type usageError struct{ msg string }
+
+func (e *usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func dispatch(g *globals, name string, args []string) error {
+	if name == "config" {
+		return runConfig(g, args)
+	}
+
+	r, ok := resources[name]
+	if !ok {
+		return usagef("unknown resource %q", name)
+	}
+	if len(args) == 0 {
+		return usagef("%s: missing action (%s)", name, strings.Join(r.actions(), ", "))
+	}
+	return r.run(g, args[0], args[1:])
+}
+
+// outputFlag didaftarkan di flag global dan di setiap action supaya -o bisa
+// ditulis sebelum atau sesudah command
+func outputFlag(fs *flag.FlagSet, g *globals) {
+	if g.output == "" {
+		g.output = FormatTable
+	}
+	fs.StringVar(&g.output, "o", g.output, "output format: table, json or csv")
+}
+
+// parseInterspersed mem-parse flag yang boleh ditulis sesudah argumen posisi,
+// misalnya "products get 1 -o json"
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// Setelah "--" semua argumen dianggap posisi
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// client membuat client API dari profile yang dipilih
+func (g *globals) client() (*Client, error) {
+	if !validFormat(g.output) {
+		return nil, usagef("invalid output format %q", g.output)
+	}
+	cfg, err := loadConfig(g.configPath)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := cfg.resolve(g)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(profile, g.timeout), nil
+}
+
+func runConfig(g *globals, args []string) error {
+	if len(args) == 0 {
+		return usagef("config: missing action (list, show, use, set, delete)")
+	}
+	action, args := args[0], args[1:]
+
+	cfg, err := loadConfig(g.configPath)
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("config "+action, flag.ContinueOnError)
+	fs.SetOutput(g.stderr)
+	var p Profile
+	if action == "set" {
+		fs.StringVar(&p.BaseURL, "base-url", "", "server base URL")
+		fs.StringVar(&p.Username, "username", "", "login username")
+		fs.StringVar(&p.Password, "password", "", "login password")
+		fs.StringVar(&p.Token, "token", "", "bearer token")
+		fs.StringVar(&p.APIKey, "api-key", "", "source API key")
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		var records []record
+		for _, name := range cfg.names() {
+			current := ""
+			if name == cfg.Current {
+				current = "*"
+			}
+			profile := cfg.Profiles[name]
+			records = append(records, record{"current": current, "name": name, "base_url": profile.BaseURL, "auth": profile.authMethod()})
+		}
+		return writeRecords(g.stdout, g.output, []string{"current", "name", "base_url", "auth"}, records)
+
+	case "show":
+		profile, err := cfg.resolve(g)
+		if err != nil {
+			return err
+		}
+		return writeRecords(g.stdout, g.output, []string{"base_url", "auth"},
+			[]record{{"base_url": profile.BaseURL, "auth": profile.authMethod()}})
+
+	case "use":
+		if len(positional) != 1 {
+			return usagef("config use: expected profile name")
+		}
+		if _, ok := cfg.Profiles[positional[0]]; !ok {
+			return fmt.Errorf("profile %q not found", positional[0])
+		}
+		cfg.Current = positional[0]
+
+	case "set":
+		if len(positional) != 1 {
+			return usagef("config set: expected profile name")
+		}
+		// Hanya flag yang ditulis yang mengubah profile lama
+		name := positional[0]
+		profile := cfg.Profiles[name]
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "base-url":
+				profile.BaseURL = p.BaseURL
+			case "username":
+				profile.Username = p.Username
+			case "password":
+				profile.Password = p.Password
+			case "token":
+				profile.Token = p.Token
+			case "api-key":
+				profile.APIKey = p.APIKey
+			}
+		})
+		if profile.BaseURL == "" {
+			profile.BaseURL = defaultBaseURL
+		}
+		cfg.Profiles[name] = profile
+		if cfg.Current == "" {
+			cfg.Current = name
+		}
+
+	case "delete":
+		if len(positional) != 1 {
+			return usagef("config delete: expected profile name")
+		}
+		if _, ok := cfg.Profiles[positional[0]]; !ok {
+			return fmt.Errorf("profile %q not found", positional[0])
+		}
+		delete(cfg.Profiles, positional[0])
+		if cfg.Current == positional[0] {
+			cfg.Current = ""
+		}
+
+	default:
+		return usagef("config: unknown action %q", action)
+	}
+
+	if err := cfg.save(g.configPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(g.stdout, "Config saved to %s\n", g.configPath)
+	return nil
+}
+
+func resourceNames() []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
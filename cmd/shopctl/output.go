@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format output
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+func validFormat(format string) bool {
+	return format == FormatTable || format == FormatJSON || format == FormatCSV
+}
+
+// record adalah satu objek dari response API
+type record = map[string]interface{}
+
+// cell mengubah nilai JSON menjadi teks untuk tabel dan CSV
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// writeRecords menulis daftar record. Kolom hanya dipakai untuk tabel dan
+// CSV; JSON ditulis apa adanya.
+func writeRecords(w io.Writer, format string, columns []string, records []record) error {
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, records)
+	case FormatCSV:
+		return writeCSV(w, columns, records)
+	}
+	return writeTable(w, columns, records)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeCSV(w io.Writer, columns []string, records []record) error {
+	cw := csv.NewWriter(w)
+	cw.Write(columns)
+	for _, r := range records {
+		row := make([]string, len(columns))
+		for i, col := range columns {
+			row[i] = cell(r[col])
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTable(w io.Writer, columns []string, records []record) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, r := range records {
+		row := make([]string, len(columns))
+		for i, col := range columns {
+			// Tab dan baris baru di dalam nilai merusak kolom
+			row[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(cell(r[col]))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Batas operasi per request bulk di server
+const maxBulkOperations = 1000
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindInt
+	kindFloat
+	// kindJSON untuk objek, ditulis sebagai JSON di flag dan kolom CSV
+	kindJSON
+)
+
+// field adalah atribut yang bisa diisi lewat flag atau kolom CSV
+type field struct {
+	name  string
+	kind  fieldKind
+	usage string
+}
+
+// flagName mengubah source_id menjadi --source-id
+func (f field) flagName() string {
+	return strings.ReplaceAll(f.name, "_", "-")
+}
+
+// parse mengubah teks dari flag atau CSV ke tipe JSON yang diharapkan server
+func (f field) parse(value string) (interface{}, error) {
+	switch f.kind {
+	case kindInt:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an integer", f.name, value)
+		}
+		return n, nil
+	case kindFloat:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not a number", f.name, value)
+		}
+		return n, nil
+	case kindJSON:
+		if strings.TrimSpace(value) == "" {
+			return nil, nil
+		}
+		var v interface{}
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&v); err != nil {
+			return nil, fmt.Errorf("%s: %q is not valid JSON", f.name, value)
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// resource menjelaskan satu resource API. Semua resource memakai pola
+// endpoint yang sama: /{path}, /{path}/:id dan /{path}/bulk.
+type resource struct {
+	name     string
+	singular string
+	path     string
+	// columns ditampilkan di tabel; export memakai id dan semua field
+	columns []string
+	fields  []field
+	// filters adalah query string yang didukung list
+	filters []string
+	// bulk berarti import memakai /{path}/bulk
+	bulk bool
+	// immutable berarti tidak ada update dan delete
+	immutable bool
+}
+
+var resources = map[string]*resource{
+	"products": {
+		name:     "products",
+		singular: "Product",
+		path:     "/products",
+		columns:  []string{"id", "name", "price", "currency", "stock", "source_id"},
+		fields: []field{
+			{name: "name", kind: kindString, usage: "product name"},
+			{name: "description", kind: kindString, usage: "product description"},
+			{name: "price", kind: kindFloat, usage: "unit price"},
+			{name: "currency", kind: kindString, usage: "price currency, default IDR"},
+			{name: "stock", kind: kindInt, usage: "stock quantity"},
+			{name: "source_id", kind: kindString, usage: "source ID"},
+			{name: "weight_grams", kind: kindInt, usage: "unit weight in grams for shipping"},
+			{name: "dimensions", kind: kindJSON, usage: `package size as JSON, e.g. {"length_cm":40,"width_cm":30,"height_cm":8}`},
+		},
+		filters: []string{"source_id", "currency", "sort"},
+		bulk:    true,
+	},
+	"sources": {
+		name:     "sources",
+		singular: "Source",
+		path:     "/sources",
+		columns:  []string{"id", "name", "lead_time_days", "min_order_quantity"},
+		fields: []field{
+			{name: "name", kind: kindString, usage: "source name"},
+			{name: "lead_time_days", kind: kindInt, usage: "supplier lead time in days"},
+			{name: "min_order_quantity", kind: kindInt, usage: "minimum order quantity"},
+		},
+		bulk: true,
+	},
+	"transactions": {
+		name:     "transactions",
+		singular: "Transaction",
+		path:     "/transactions",
+		columns:  []string{"id", "product_id", "quantity", "total", "currency", "status", "customer_id", "warehouse_id"},
+		fields: []field{
+			{name: "product_id", kind: kindString, usage: "product ID"},
+			{name: "quantity", kind: kindInt, usage: "quantity to buy"},
+			{name: "customer_id", kind: kindString, usage: "customer ID, required for admin and staff"},
+			{name: "warehouse_id", kind: kindString, usage: "warehouse to ship from, default by allocation rule"},
+			{name: "currency", kind: kindString, usage: "currency to pay in, default product currency"},
+			{name: "payment_token", kind: kindString, usage: "payment method token from the payment provider"},
+		},
+		immutable: true,
+	},
+}
+
+func (r *resource) actions() []string {
+	if r.immutable {
+		return []string{"list", "get", "create", "import", "export"}
+	}
+	return []string{"list", "get", "create", "update", "delete", "import", "export"}
+}
+
+func (r *resource) field(name string) (field, bool) {
+	for _, f := range r.fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// exportColumns adalah id diikuti semua field, sama dengan kolom import
+func (r *resource) exportColumns() []string {
+	columns := []string{"id"}
+	for _, f := range r.fields {
+		columns = append(columns, f.name)
+	}
+	// Kolom hasil hitungan server ikut di-export tapi diabaikan saat import
+	for _, col := range r.columns {
+		if _, ok := r.field(col); !ok && col != "id" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+func (r *resource) run(g *globals, action string, args []string) error {
+	supported := false
+	for _, a := range r.actions() {
+		supported = supported || a == action
+	}
+	if !supported {
+		return usagef("%s: unknown action %q (%s)", r.name, action, strings.Join(r.actions(), ", "))
+	}
+
+	fs := flag.NewFlagSet(r.name+" "+action, flag.ContinueOnError)
+	fs.SetOutput(g.stderr)
+	outputFlag(fs, g)
+
+	values := map[string]*string{}
+	if action == "list" {
+		for _, name := range r.filters {
+			values[name] = fs.String(strings.ReplaceAll(name, "_", "-"), "", "filter by "+name)
+		}
+	}
+	if action == "create" || action == "update" {
+		for _, f := range r.fields {
+			values[f.name] = fs.String(f.flagName(), "", f.usage)
+		}
+	}
+	var mode, file string
+	if action == "import" {
+		fs.StringVar(&mode, "mode", "atomic", "import mode: atomic or best_effort")
+	}
+	if action == "export" {
+		fs.StringVar(&file, "file", "", "export to file instead of stdout; format follows the extension")
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// Hanya flag yang benar-benar ditulis yang dikirim
+	set := map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		name := strings.ReplaceAll(f.Name, "-", "_")
+		if v, ok := values[name]; ok {
+			set[name] = *v
+		}
+	})
+
+	client, err := g.client()
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "list":
+		if len(positional) != 0 {
+			return usagef("%s list: unexpected arguments", r.name)
+		}
+		query := url.Values{}
+		for name, value := range set {
+			query.Set(name, value)
+		}
+		records, err := r.list(client, query)
+		if err != nil {
+			return err
+		}
+		return writeRecords(g.stdout, g.output, r.columns, records)
+
+	case "get":
+		if len(positional) != 1 {
+			return usagef("%s get: expected ID", r.name)
+		}
+		var item record
+		if err := client.do(http.MethodGet, r.path+"/"+url.PathEscape(positional[0]), nil, nil, &item); err != nil {
+			return err
+		}
+		return r.writeOne(g, item)
+
+	case "create":
+		if len(positional) != 0 {
+			return usagef("%s create: unexpected arguments", r.name)
+		}
+		body, err := r.body(set)
+		if err != nil {
+			return usagef("%s", err)
+		}
+		var item record
+		if err := client.do(http.MethodPost, r.path, nil, body, &item); err != nil {
+			return err
+		}
+		return r.writeOne(g, item)
+
+	case "update":
+		if len(positional) != 1 {
+			return usagef("%s update: expected ID", r.name)
+		}
+		if len(set) == 0 {
+			return usagef("%s update: nothing to update", r.name)
+		}
+		return r.update(g, client, positional[0], set)
+
+	case "delete":
+		if len(positional) == 0 {
+			return usagef("%s delete: expected ID", r.name)
+		}
+		for _, id := range positional {
+			if err := client.do(http.MethodDelete, r.path+"/"+url.PathEscape(id), nil, nil, nil); err != nil {
+				return err
+			}
+			fmt.Fprintf(g.stdout, "%s %s deleted\n", r.singular, id)
+		}
+		return nil
+
+	case "import":
+		if len(positional) != 1 {
+			return usagef("%s import: expected file (.csv or .json, - for stdin)", r.name)
+		}
+		if mode != "atomic" && mode != "best_effort" {
+			return usagef("invalid mode %q", mode)
+		}
+		return r.importFile(g, client, positional[0], mode)
+
+	case "export":
+		if len(positional) != 0 {
+			return usagef("%s export: unexpected arguments", r.name)
+		}
+		return r.export(g, client, file)
+	}
+	return nil
+}
+
+func (r *resource) list(client *Client, query url.Values) ([]record, error) {
+	var records []record
+	if err := client.do(http.MethodGet, r.path, query, nil, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *resource) writeOne(g *globals, item record) error {
+	if g.output == FormatJSON {
+		return writeJSON(g.stdout, item)
+	}
+	return writeRecords(g.stdout, g.output, r.exportColumns(), []record{item})
+}
+
+// body mengubah nilai teks menjadi body JSON sesuai tipe field
+func (r *resource) body(values map[string]string) (record, error) {
+	body := record{}
+	for name, value := range values {
+		f, ok := r.field(name)
+		if !ok {
+			continue
+		}
+		v, err := f.parse(value)
+		if err != nil {
+			return nil, err
+		}
+		body[name] = v
+	}
+	return body, nil
+}
+
+// update mengambil data lama lalu menimpa field yang diberikan, karena PUT
+// di server mengganti seluruh objek
+func (r *resource) update(g *globals, client *Client, id string, values map[string]string) error {
+	path := r.path + "/" + url.PathEscape(id)
+
+	var current record
+	if err := client.do(http.MethodGet, path, nil, nil, &current); err != nil {
+		return err
+	}
+	changes, err := r.body(values)
+	if err != nil {
+		return usagef("%s", err)
+	}
+
+	body := record{}
+	for _, f := range r.fields {
+		if v, ok := current[f.name]; ok {
+			body[f.name] = v
+		}
+	}
+	for name, v := range changes {
+		body[name] = v
+	}
+
+	var item record
+	if err := client.do(http.MethodPut, path, nil, body, &item); err != nil {
+		return err
+	}
+	return r.writeOne(g, item)
+}
+
+func (r *resource) export(g *globals, client *Client, file string) error {
+	records, err := r.list(client, nil)
+	if err != nil {
+		return err
+	}
+	if records == nil {
+		records = []record{}
+	}
+
+	format := g.output
+	if file == "" {
+		if format == FormatTable {
+			format = FormatCSV
+		}
+		return writeRecords(g.stdout, format, r.exportColumns(), records)
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		format = FormatJSON
+	case ".csv":
+		format = FormatCSV
+	default:
+		if format == FormatTable {
+			format = FormatCSV
+		}
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := writeRecords(f, format, r.exportColumns(), records); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(g.stderr, "Exported %d %s to %s\n", len(records), r.name, file)
+	return nil
+}
+
+// readImport membaca file CSV atau JSON array. Nilai CSV dikonversi ke tipe
+// field; kolom yang bukan field (misalnya total) diabaikan.
+func (r *resource) readImport(g *globals, name string) ([]record, error) {
+	in := bufio.NewReader(g.stdin)
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = bufio.NewReader(f)
+	}
+
+	// Dari stdin formatnya ditebak dari karakter pertama
+	isJSON := strings.ToLower(filepath.Ext(name)) == ".json"
+	if name == "-" {
+		for {
+			b, err := in.Peek(1)
+			if err != nil || !unicode.IsSpace(rune(b[0])) {
+				isJSON = err == nil && b[0] == '['
+				break
+			}
+			in.ReadByte()
+		}
+	}
+
+	if isJSON {
+		var rows []record
+		decoder := json.NewDecoder(in)
+		decoder.UseNumber()
+		if err := decoder.Decode(&rows); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return rows, nil
+	}
+	return r.readCSV(in)
+}
+
+func (r *resource) readCSV(in io.Reader) ([]record, error) {
+	lines, err := csv.NewReader(in).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	header := lines[0]
+	var rows []record
+	for i, line := range lines[1:] {
+		row := record{}
+		for j, col := range header {
+			col = strings.TrimSpace(col)
+			value := line[j]
+			if col == "id" {
+				if value != "" {
+					row["id"] = value
+				}
+				continue
+			}
+			f, ok := r.field(col)
+			if !ok {
+				continue
+			}
+			v, err := f.parse(value)
+			if err != nil {
+				// Baris 1 adalah header
+				return nil, fmt.Errorf("line %d: %w", i+2, err)
+			}
+			row[col] = v
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// importFile mengirim baris ke endpoint bulk. Baris dengan id menjadi
+// update, tanpa id menjadi create. Di mode best_effort baris dikirim per
+// 1000 operasi; mode atomic harus muat dalam satu request.
+func (r *resource) importFile(g *globals, client *Client, name, mode string) error {
+	rows, err := r.readImport(g, name)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%s: no rows to import", name)
+	}
+
+	var results []record
+	if r.bulk {
+		results, err = r.importBulk(client, rows, mode)
+	} else {
+		results, err = r.importEach(client, rows)
+	}
+	// Import atomic yang di-rollback tetap menampilkan hasil per baris
+	if err != nil && len(results) == 0 {
+		return err
+	}
+	importErr := err
+
+	failed := 0
+	for _, result := range results {
+		if result["error"] != nil {
+			failed++
+		}
+		if e, ok := result["error"].(map[string]interface{}); ok {
+			result["error"] = e["message"]
+		}
+	}
+	if err := writeRecords(g.stdout, g.output, []string{"index", "op", "id", "status", "error"}, results); err != nil {
+		return err
+	}
+	if importErr != nil {
+		return importErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", failed, len(rows))
+	}
+	return nil
+}
+
+func (r *resource) importBulk(client *Client, rows []record, mode string) ([]record, error) {
+	if mode == "atomic" && len(rows) > maxBulkOperations {
+		return nil, usagef("atomic import is limited to %d rows, got %d; use --mode best_effort", maxBulkOperations, len(rows))
+	}
+
+	var results []record
+	for start := 0; start < len(rows); start += maxBulkOperations {
+		end := min(start+maxBulkOperations, len(rows))
+
+		operations := make([]record, 0, end-start)
+		for _, row := range rows[start:end] {
+			data := record{}
+			for _, f := range r.fields {
+				if v, ok := row[f.name]; ok {
+					data[f.name] = v
+				}
+			}
+			op := record{"op": "create", "data": data}
+			if id := cell(row["id"]); id != "" {
+				op["op"] = "update"
+				op["id"] = id
+			}
+			operations = append(operations, op)
+		}
+
+		var result struct {
+			Results []record `json:"results"`
+		}
+		err := client.do(http.MethodPost, r.path+"/bulk", nil, record{"mode": mode, "operations": operations}, &result)
+		// Index dari server dihitung per request
+		for _, item := range result.Results {
+			if n, ok := item["index"].(json.Number); ok {
+				i, _ := n.Int64()
+				item["index"] = int(i) + start
+			}
+			results = append(results, item)
+		}
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// importEach untuk resource tanpa endpoint bulk: setiap baris dikirim
+// sendiri dan error dicatat per baris
+func (r *resource) importEach(client *Client, rows []record) ([]record, error) {
+	results := make([]record, 0, len(rows))
+	for i, row := range rows {
+		data := record{}
+		for _, f := range r.fields {
+			if v, ok := row[f.name]; ok {
+				data[f.name] = v
+			}
+		}
+
+		result := record{"index": i, "op": "create", "status": http.StatusCreated}
+		var item record
+		var apiErr *APIError
+		err := client.do(http.MethodPost, r.path, nil, data, &item)
+		if errors.As(err, &apiErr) {
+			result["status"] = apiErr.Status
+			result["error"] = apiErr.Err.Message
+		} else if err != nil {
+			return nil, err
+		} else {
+			result["id"] = item["id"]
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeAPI meniru envelope server dan mencatat request terakhir
+type fakeAPI struct {
+	lastBody map[string]interface{}
+	lastAuth string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lastAuth = r.Header.Get("Authorization")
+	f.lastBody = nil
+	json.NewDecoder(r.Body).Decode(&f.lastBody)
+
+	reply := func(status int, body string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/auth/login":
+		reply(http.StatusOK, `{"message":"Login successful","data":{"token":"tok"},"error":null}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/products":
+		reply(http.StatusOK, `{"message":"ok","data":[
+			{"id":"1","name":"Laptop, 14\"","price":15000000,"currency":"IDR","stock":10,"source_id":"1"},
+			{"id":"2","name":"Mouse","price":250000.5,"currency":"IDR","stock":50,"source_id":"2"}],"error":null}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/products/1":
+		reply(http.StatusOK, `{"message":"ok","data":{"id":"1","name":"Laptop","price":15000000,"currency":"IDR","stock":10,"source_id":"1",
+			"weight_grams":2500,"dimensions":{"length_cm":40,"width_cm":30,"height_cm":8},"average_rating":4.5},"error":null}`)
+	case r.Method == http.MethodPut && r.URL.Path == "/products/1":
+		reply(http.StatusOK, `{"message":"ok","data":{"id":"1"},"error":null}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/products":
+		reply(http.StatusBadRequest, `{"message":"Validation failed","data":null,"error":{
+			"code":"VALIDATION_FAILED","message":"Validation failed",
+			"fields":[{"field":"price","code":"gt","message":"Price must be greater than 0"}],
+			"request_id":"req-1"}}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/products/bulk":
+		reply(http.StatusOK, `{"message":"ok","data":{"results":[
+			{"index":0,"op":"create","id":"3","status":201,"error":null},
+			{"index":1,"op":"update","id":"1","status":200,"error":null}]},"error":null}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/sources/bulk":
+		reply(http.StatusBadRequest, `{"message":"Bulk operation rolled back","data":{"mode":"atomic","committed":false,"results":[
+			{"index":0,"op":"create","status":424,"error":{"code":"BULK_ROLLED_BACK","message":"Rolled back because operation 1 failed"}},
+			{"index":1,"op":"update","id":"9","status":404,"error":{"code":"NOT_FOUND","message":"Source 9 not found"}}]},
+			"error":{"code":"BULK_ROLLED_BACK","message":"Operation 1 failed","request_id":"req-2"}}`)
+	default:
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("bad gateway"))
+	}
+}
+
+func runCLI(t *testing.T, api *fakeAPI, args ...string) (int, string, string) {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	t.Setenv("SHOPCTL_CONFIG", filepath.Join(t.TempDir(), "config.yaml"))
+	t.Setenv("SHOPCTL_BASE_URL", srv.URL)
+	t.Setenv("SHOPCTL_PROFILE", "")
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(""), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestListOutputFormats(t *testing.T) {
+	api := &fakeAPI{}
+
+	code, out, _ := runCLI(t, api, "--token", "abc", "products", "list")
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	if api.lastAuth != "Bearer abc" {
+		t.Errorf("authorization = %q", api.lastAuth)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") || !strings.Contains(lines[2], "250000.5") {
+		t.Errorf("table output:\n%s", out)
+	}
+
+	_, out, _ = runCLI(t, api, "products", "list", "-o", "csv")
+	want := "id,name,price,currency,stock,source_id\n1,\"Laptop, 14\"\"\",15000000,IDR,10,1\n2,Mouse,250000.5,IDR,50,2\n"
+	if out != want {
+		t.Errorf("csv output:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestAPIErrorIsDecoded(t *testing.T) {
+	code, _, stderr := runCLI(t, &fakeAPI{}, "products", "create", "--name", "Pen", "--price", "0")
+	if code != 1 {
+		t.Fatalf("exit code %d", code)
+	}
+	for _, want := range []string{"Validation failed (400 VALIDATION_FAILED)", "price: Price must be greater than 0", "request_id: req-1"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr %q does not contain %q", stderr, want)
+		}
+	}
+
+	// Response yang bukan envelope
+	code, _, stderr = runCLI(t, &fakeAPI{}, "sources", "list")
+	if code != 1 || !strings.Contains(stderr, "502") {
+		t.Errorf("exit code %d, stderr %q", code, stderr)
+	}
+}
+
+func TestImportCSVConvertsTypes(t *testing.T) {
+	api := &fakeAPI{}
+	file := filepath.Join(t.TempDir(), "products.csv")
+	csv := "id,name,price,stock,source_id,total\n,Pen,1.5,10,1,ignored\n1,Laptop,2,5,1,\n"
+	if err := os.WriteFile(file, []byte(csv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, stderr := runCLI(t, api, "products", "import", file, "--mode", "best_effort")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	if !strings.Contains(out, "update") {
+		t.Errorf("result table:\n%s", out)
+	}
+
+	got, _ := json.Marshal(api.lastBody)
+	want := `{"mode":"best_effort","operations":[` +
+		`{"data":{"name":"Pen","price":1.5,"source_id":"1","stock":10},"op":"create"},` +
+		`{"data":{"name":"Laptop","price":2,"source_id":"1","stock":5},"id":"1","op":"update"}]}`
+	if string(got) != want {
+		t.Errorf("bulk body:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestProfileResolution(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg := &Config{
+		Current: "local",
+		Profiles: map[string]Profile{
+			"local": {BaseURL: "http://localhost:8080", Username: "admin", Password: "admin123"},
+			"prod":  {BaseURL: "https://shop.example.com", APIKey: "sk_live"},
+		},
+	}
+	if err := cfg.save(path); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SHOPCTL_BASE_URL", "")
+	t.Setenv("SHOPCTL_TOKEN", "")
+	t.Setenv("SHOPCTL_API_KEY", "")
+	t.Setenv("SHOPCTL_PROFILE", "prod")
+
+	// Env mengalahkan current
+	p, err := cfg.resolve(&globals{})
+	if err != nil || p.BaseURL != "https://shop.example.com" || p.authMethod() != "api_key" {
+		t.Errorf("env profile = %+v, %v", p, err)
+	}
+
+	// Flag mengalahkan env
+	p, err = cfg.resolve(&globals{profile: "local", baseURL: "http://127.0.0.1:9000"})
+	if err != nil || p.BaseURL != "http://127.0.0.1:9000" || p.Username != "admin" {
+		t.Errorf("flag profile = %+v, %v", p, err)
+	}
+
+	if _, err := cfg.resolve(&globals{profile: "missing"}); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestUpdateKeepsDimensions(t *testing.T) {
+	api := &fakeAPI{}
+	if code, _, stderr := runCLI(t, api, "products", "update", "1", "--stock", "42"); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	got, _ := json.Marshal(api.lastBody)
+	want := `{"currency":"IDR","dimensions":{"height_cm":8,"length_cm":40,"width_cm":30},"name":"Laptop","price":15000000,"source_id":"1","stock":42,"weight_grams":2500}`
+	if string(got) != want {
+		t.Errorf("update body:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Kolom dimensions hasil export berisi JSON dan bisa di-import kembali
+	file := filepath.Join(t.TempDir(), "products.csv")
+	csv := "id,name,price,stock,source_id,dimensions\n1,Laptop,2,5,1,\"{\"\"length_cm\"\":40,\"\"width_cm\"\":30,\"\"height_cm\"\":8}\"\n"
+	if err := os.WriteFile(file, []byte(csv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, stderr := runCLI(t, api, "products", "import", file); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr)
+	}
+	got, _ = json.Marshal(api.lastBody)
+	want = `{"mode":"atomic","operations":[{"data":{"dimensions":{"height_cm":8,"length_cm":40,"width_cm":30},"name":"Laptop","price":2,"source_id":"1","stock":5},"id":"1","op":"update"}]}`
+	if string(got) != want {
+		t.Errorf("bulk body:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestImportRollbackShowsRows(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sources.csv")
+	if err := os.WriteFile(file, []byte("id,name\n,Supplier\n9,Missing\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, stderr := runCLI(t, &fakeAPI{}, "sources", "import", file)
+	if code != 1 {
+		t.Fatalf("exit code %d", code)
+	}
+	for _, want := range []string{"Rolled back because operation 1 failed", "Source 9 not found"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("result table does not contain %q:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(stderr, "BULK_ROLLED_BACK") {
+		t.Errorf("stderr %q", stderr)
+	}
+}
@@ -0,0 +1,311 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CostPrice adalah harga beli satu unit produk dari sebuah source, berlaku
+// mulai EffectiveFrom sampai ada harga lain yang lebih baru untuk produk
+// dan source yang sama. Seperti kurs, harga yang sudah berlaku tidak bisa
+// dihapus; koreksi dilakukan dengan menambah harga baru.
+type CostPrice struct {
+	ID            string    `json:"id"`
+	ProductID     string    `json:"product_id"`
+	SourceID      string    `json:"source_id" validate:"required,source_exists"`
+	Cost          float64   `json:"cost" validate:"gt=0"`
+	Currency      string    `json:"currency" validate:"currency"`
+	EffectiveFrom time.Time `json:"effective_from"`
+}
+
+// SaleCost membekukan pendapatan dan harga beli sebuah transaksi dalam
+// IDR saat checkout, supaya laporan margin tidak berubah kalau harga beli
+// atau kurs diubah kemudian. CostPriceID kosong kalau belum ada harga beli
+// untuk produk dan source-nya; transaksi seperti itu tidak dihitung di
+// margin. Tidak ikut di response transaksi karena customer bisa melihatnya.
+type SaleCost struct {
+	TransactionID string  `json:"transaction_id"`
+	ProductID     string  `json:"product_id"`
+	SourceID      string  `json:"source_id"`
+	Quantity      int     `json:"quantity"`
+	Revenue       float64 `json:"revenue"`
+	Cost          float64 `json:"cost"`
+	CostPriceID   string  `json:"cost_price_id,omitempty"`
+}
+
+// MarginRow adalah satu baris laporan margin. Semua nilai dalam IDR dan
+// pendapatan tidak termasuk ongkos kirim. Transaksi tanpa harga beli hanya
+// dihitung di Uncosted.
+type MarginRow struct {
+	ID            string  `json:"id"`
+	Transactions  int     `json:"transactions"`
+	Units         int     `json:"units"`
+	Revenue       float64 `json:"revenue"`
+	Cost          float64 `json:"cost"`
+	GrossMargin   float64 `json:"gross_margin"`
+	MarginPercent float64 `json:"margin_percent"`
+	Uncosted      int     `json:"uncosted_transactions"`
+}
+
+// TransactionMargin adalah margin satu transaksi dalam IDR
+type TransactionMargin struct {
+	TransactionID string   `json:"transaction_id"`
+	ProductID     string   `json:"product_id"`
+	SourceID      string   `json:"source_id"`
+	Quantity      int      `json:"quantity"`
+	Revenue       float64  `json:"revenue"`
+	Cost          *float64 `json:"cost"`
+	GrossMargin   *float64 `json:"gross_margin"`
+	MarginPercent *float64 `json:"margin_percent"`
+}
+
+// Domain event harga beli
+const (
+	EventCostPriceCreated = "cost_price.created"
+	EventCostPriceDeleted = "cost_price.deleted"
+	EventSaleCostRecorded = "sale_cost.recorded"
+)
+
+var (
+	costPrices = []CostPrice{}
+	saleCosts  = []SaleCost{}
+)
+
+// costAt mengembalikan harga beli produk dari source yang berlaku pada at
+func costAt(productID, sourceID string, at time.Time) (CostPrice, bool) {
+	var found *CostPrice
+	for i, cost := range costPrices {
+		if cost.ProductID != productID || cost.SourceID != sourceID || cost.EffectiveFrom.After(at) {
+			continue
+		}
+		if found == nil || cost.EffectiveFrom.After(found.EffectiveFrom) {
+			found = &costPrices[i]
+		}
+	}
+	if found == nil {
+		return CostPrice{}, false
+	}
+	return *found, true
+}
+
+// newSaleCost menghitung pendapatan (tanpa ongkos kirim) dan harga beli
+// transaksi dalam IDR dengan kurs dan harga beli yang berlaku pada at
+func newSaleCost(transaction Transaction, product Product, at time.Time) SaleCost {
+	sale := SaleCost{
+		TransactionID: transaction.ID,
+		ProductID:     product.ID,
+		SourceID:      product.SourceID,
+		Quantity:      transaction.Quantity,
+	}
+	sale.Revenue, _, _ = convert(product.Price*float64(transaction.Quantity), product.Currency, baseCurrency, at)
+	if cost, ok := costAt(product.ID, product.SourceID, at); ok {
+		if total, _, ok := convert(cost.Cost*float64(transaction.Quantity), cost.Currency, baseCurrency, at); ok {
+			sale.Cost = total
+			sale.CostPriceID = cost.ID
+		}
+	}
+	return sale
+}
+
+// Cost price handlers
+func getCostPrices(c *gin.Context) {
+	id := c.Param("id")
+	if _, found := findProduct(id); !found {
+		respondError(c, http.StatusNotFound, "Product not found", apierror.NotFound("Product", id))
+		return
+	}
+	sourceID := c.Query("source_id")
+
+	filtered := []CostPrice{}
+	for _, cost := range costPrices {
+		if cost.ProductID == id && (sourceID == "" || cost.SourceID == sourceID) {
+			filtered = append(filtered, cost)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].SourceID != filtered[j].SourceID {
+			return filtered[i].SourceID < filtered[j].SourceID
+		}
+		return filtered[i].EffectiveFrom.Before(filtered[j].EffectiveFrom)
+	})
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Cost prices retrieved successfully",
+		Data:    filtered,
+		Error:   nil,
+	})
+}
+
+func createCostPrice(c *gin.Context) {
+	id := c.Param("id")
+
+	var newCost CostPrice
+	if err := c.ShouldBindJSON(&newCost); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	normalizeCurrency(&newCost.Currency)
+	if newCost.EffectiveFrom.IsZero() {
+		newCost.EffectiveFrom = time.Now().UTC()
+	}
+
+	// Source dan produk dicek di dalam commit supaya tidak terhapus di
+	// antara pengecekan dan penyimpanan
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if fields := validate.Struct(&newCost); len(fields) > 0 {
+			return nil, nil, reject(http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		}
+		if _, found := findProduct(id); !found {
+			return nil, nil, reject(http.StatusNotFound, "Product not found", apierror.NotFound("Product", id))
+		}
+		for _, cost := range costPrices {
+			if cost.ProductID == id && cost.SourceID == newCost.SourceID && cost.EffectiveFrom.Equal(newCost.EffectiveFrom) {
+				return nil, nil, reject(http.StatusConflict, "Cost price already exists", apierror.Newf(apierror.CodeConflict, "Cost price %s for source %s at %s already exists", cost.ID, cost.SourceID, cost.EffectiveFrom.Format(time.RFC3339)))
+			}
+		}
+
+		newCost.ID = generateID()
+		newCost.ProductID = id
+		return func() { costPrices = append(costPrices, newCost) }, []events.Message{{Type: EventCostPriceCreated, Data: newCost}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Cost price created", "cost_price_id", newCost.ID, "product_id", id, "source_id", newCost.SourceID, "cost", newCost.Cost, "effective_from", newCost.EffectiveFrom)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Cost price created successfully",
+		Data:    newCost,
+		Error:   nil,
+	})
+}
+
+func deleteCostPrice(c *gin.Context) {
+	id := c.Param("cost_id")
+	productID := c.Param("id")
+
+	var deleted CostPrice
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		for i, cost := range costPrices {
+			if cost.ID == id && cost.ProductID == productID {
+				if !cost.EffectiveFrom.After(time.Now()) {
+					return nil, nil, reject(http.StatusConflict, "Cost price already in effect", apierror.New(apierror.CodeConflict, "Only scheduled cost prices can be deleted; add a new cost price to correct one that is in effect"))
+				}
+				deleted = cost
+				return func() { costPrices = append(costPrices[:i], costPrices[i+1:]...) }, []events.Message{{Type: EventCostPriceDeleted, Data: cost}}, nil
+			}
+		}
+		return nil, nil, reject(http.StatusNotFound, "Cost price not found", apierror.NotFound("Cost price", id))
+	}) {
+		return
+	}
+	logging.From(c).Info("Cost price deleted", "cost_price_id", id, "product_id", deleted.ProductID)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Cost price deleted successfully",
+		Data:    nil,
+		Error:   nil,
+	})
+}
+
+// Margin report handlers. Hanya transaksi paid yang dihitung; transaksi
+// dari sebelum ada harga beli tidak punya SaleCost dan tidak masuk laporan.
+
+func getProductMargins(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Product margins retrieved successfully",
+		Data:    marginsBy(func(sale SaleCost) string { return sale.ProductID }),
+		Error:   nil,
+	})
+}
+
+func getSourceMargins(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Source margins retrieved successfully",
+		Data:    marginsBy(func(sale SaleCost) string { return sale.SourceID }),
+		Error:   nil,
+	})
+}
+
+func getTransactionMargins(c *gin.Context) {
+	rows := []TransactionMargin{}
+	for _, sale := range paidSales() {
+		row := TransactionMargin{
+			TransactionID: sale.TransactionID,
+			ProductID:     sale.ProductID,
+			SourceID:      sale.SourceID,
+			Quantity:      sale.Quantity,
+			Revenue:       sale.Revenue,
+		}
+		if sale.CostPriceID != "" {
+			cost, margin, percent := sale.Cost, sale.Revenue-sale.Cost, marginPercent(sale.Revenue-sale.Cost, sale.Revenue)
+			row.Cost, row.GrossMargin, row.MarginPercent = &cost, &margin, &percent
+		}
+		rows = append(rows, row)
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Transaction margins retrieved successfully",
+		Data:    rows,
+		Error:   nil,
+	})
+}
+
+// marginsBy menjumlahkan margin per key, diurutkan dari margin terbesar
+func marginsBy(key func(SaleCost) string) []MarginRow {
+	index := map[string]int{}
+	rows := []MarginRow{}
+	for _, sale := range paidSales() {
+		id := key(sale)
+		i, ok := index[id]
+		if !ok {
+			i = len(rows)
+			index[id] = i
+			rows = append(rows, MarginRow{ID: id})
+		}
+		row := &rows[i]
+		row.Transactions++
+		if sale.CostPriceID == "" {
+			row.Uncosted++
+			continue
+		}
+		row.Units += sale.Quantity
+		row.Revenue += sale.Revenue
+		row.Cost += sale.Cost
+	}
+	for i := range rows {
+		rows[i].GrossMargin = rows[i].Revenue - rows[i].Cost
+		rows[i].MarginPercent = marginPercent(rows[i].GrossMargin, rows[i].Revenue)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].GrossMargin > rows[j].GrossMargin })
+	return rows
+}
+
+func paidSales() []SaleCost {
+	status := make(map[string]string, len(transactions))
+	for _, transaction := range transactions {
+		status[transaction.ID] = transaction.Status
+	}
+	paid := []SaleCost{}
+	for _, sale := range saleCosts {
+		if status[sale.TransactionID] == TransactionPaid {
+			paid = append(paid, sale)
+		}
+	}
+	return paid
+}
+
+func marginPercent(margin, revenue float64) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(margin/revenue*10000) / 100
+}
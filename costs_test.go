@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"e-commerce/internal/auth"
+)
+
+func TestMarginUsesCostAtCheckout(t *testing.T) {
+	api := newTestAPI(t,
+		auth.User{ID: "u2", Username: "staff", Role: auth.RoleStaff},
+		auth.User{ID: "u3", Username: "customer", Role: auth.RoleCustomer},
+	)
+	do := api.do
+
+	// Mouse: harga 250000 IDR, harga beli demo 15 SGD = 177000 IDR
+	if code := do("customer", http.MethodGet, "/products/2/costs", "", nil); code != http.StatusForbidden {
+		t.Errorf("customer cost history status %d", code)
+	}
+	scheduled := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	var future CostPrice
+	if code := do("staff", http.MethodPost, "/products/2/costs", `{"source_id":"2","cost":1,"effective_from":"`+scheduled+`"}`, &future); code != http.StatusCreated {
+		t.Fatalf("scheduled cost status %d", code)
+	}
+	if code := do("customer", http.MethodPost, "/transactions", `{"product_id":"2","quantity":2}`, nil); code != http.StatusCreated {
+		t.Fatalf("transaction status %d", code)
+	}
+
+	// Harga beli baru tidak mengubah margin transaksi yang sudah ada
+	if code := do("staff", http.MethodPost, "/products/2/costs", `{"source_id":"2","cost":200000}`, nil); code != http.StatusCreated {
+		t.Fatalf("new cost status %d", code)
+	}
+	if code := do("staff", http.MethodDelete, "/products/2/costs/2", "", nil); code != http.StatusConflict {
+		t.Errorf("delete cost in effect status %d", code)
+	}
+	if code := do("staff", http.MethodDelete, "/products/2/costs/"+future.ID, "", nil); code != http.StatusOK {
+		t.Errorf("delete scheduled cost status %d", code)
+	}
+	var history []CostPrice
+	if do("staff", http.MethodGet, "/products/2/costs?source_id=2", "", &history); len(history) != 2 || history[1].Cost != 200000 {
+		t.Errorf("cost history %+v", history)
+	}
+
+	var bySource []MarginRow
+	if code := do("staff", http.MethodGet, "/reports/margins/sources", "", &bySource); code != http.StatusOK || len(bySource) != 1 {
+		t.Fatalf("source margins status %d: %+v", code, bySource)
+	}
+	want := MarginRow{ID: "2", Transactions: 1, Units: 2, Revenue: 500000, Cost: 354000, GrossMargin: 146000, MarginPercent: 29.2}
+	if bySource[0] != want {
+		t.Errorf("source margin %+v, want %+v", bySource[0], want)
+	}
+
+	var byTransaction []TransactionMargin
+	do("staff", http.MethodGet, "/reports/margins/transactions", "", &byTransaction)
+	if len(byTransaction) != 1 || byTransaction[0].GrossMargin == nil || *byTransaction[0].GrossMargin != 146000 {
+		t.Errorf("transaction margins %+v", byTransaction)
+	}
+}
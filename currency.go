@@ -0,0 +1,249 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Semua kurs dinyatakan dalam mata uang dasar ini
+const baseCurrency = "IDR"
+
+// Jumlah digit desimal per mata uang, default 2
+var currencyDecimals = map[string]int{
+	"IDR": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+}
+
+// ExchangeRate berarti 1 unit Currency bernilai Rate IDR mulai
+// EffectiveFrom sampai ada kurs lain dengan EffectiveFrom lebih baru.
+// Kurs yang sudah berlaku tidak bisa dihapus supaya riwayat tetap utuh;
+// koreksi dilakukan dengan menambah kurs baru.
+type ExchangeRate struct {
+	ID            string    `json:"id"`
+	Currency      string    `json:"currency" validate:"currency_code"`
+	Rate          float64   `json:"rate" validate:"gt=0"`
+	EffectiveFrom time.Time `json:"effective_from"`
+}
+
+// Domain event kurs
+const (
+	EventExchangeRateCreated = "exchange_rate.created"
+	EventExchangeRateDeleted = "exchange_rate.deleted"
+)
+
+var exchangeRates = []ExchangeRate{}
+
+// currencyOf mengembalikan mata uang harga produk. Produk dari sebelum
+// ada multi-currency tidak punya currency dan berarti IDR.
+func currencyOf(code string) string {
+	if code == "" {
+		return baseCurrency
+	}
+	return code
+}
+
+// normalizeCurrency mengubah kode ke huruf besar dan mengisi IDR kalau
+// kosong. Dipanggil sebelum validasi.
+func normalizeCurrency(code *string) {
+	*code = currencyOf(strings.ToUpper(strings.TrimSpace(*code)))
+}
+
+// legacyCurrency mengisi currency produk dan transaksi yang disimpan
+// sebelum ada multi-currency; semuanya IDR dengan kurs 1. Transaksi dari
+// sebelum ada payment provider dianggap sudah dibayar.
+func legacyCurrency(products []Product, transactions []Transaction) {
+	for i := range products {
+		normalizeCurrency(&products[i].Currency)
+	}
+	for i := range transactions {
+		legacyTransaction(&transactions[i])
+	}
+}
+
+func legacyTransaction(t *Transaction) {
+	if t.Currency == "" {
+		t.Currency, t.ExchangeRate = baseCurrency, 1
+	}
+	if t.Status == "" {
+		t.Status = TransactionPaid
+	}
+}
+
+// rateAt mengembalikan nilai 1 unit currency dalam IDR pada waktu at
+func rateAt(currency string, at time.Time) (float64, bool) {
+	if currency == baseCurrency {
+		return 1, true
+	}
+	var found *ExchangeRate
+	for i, rate := range exchangeRates {
+		if rate.Currency != currency || rate.EffectiveFrom.After(at) {
+			continue
+		}
+		if found == nil || rate.EffectiveFrom.After(found.EffectiveFrom) {
+			found = &exchangeRates[i]
+		}
+	}
+	if found == nil {
+		return 0, false
+	}
+	return found.Rate, true
+}
+
+// supportedCurrency true untuk IDR dan mata uang yang punya kurs berlaku
+func supportedCurrency(currency string) bool {
+	_, ok := rateAt(currency, time.Now())
+	return ok
+}
+
+// convert mengubah amount dari from ke to memakai kurs pada at. rate
+// adalah pengali yang dipakai, dicatat di transaksi.
+func convert(amount float64, from, to string, at time.Time) (converted, rate float64, ok bool) {
+	from, to = currencyOf(from), currencyOf(to)
+	if from == to {
+		return amount, 1, true
+	}
+	fromRate, ok := rateAt(from, at)
+	if !ok {
+		return 0, 0, false
+	}
+	toRate, ok := rateAt(to, at)
+	if !ok {
+		return 0, 0, false
+	}
+	rate = fromRate / toRate
+	return roundCurrency(amount*rate, to), rate, true
+}
+
+func roundCurrency(amount float64, currency string) float64 {
+	decimals, ok := currencyDecimals[currency]
+	if !ok {
+		decimals = 2
+	}
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(amount*scale) / scale
+}
+
+// queryCurrency membaca ?currency= dan menjawab 400 kalau tidak didukung.
+// String kosong berarti harga ditampilkan dalam mata uang aslinya.
+func queryCurrency(c *gin.Context) (string, bool) {
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency == "" || supportedCurrency(currency) {
+		return currency, true
+	}
+	respondError(c, http.StatusBadRequest, "Unsupported currency", apierror.Newf(apierror.CodeUnsupportedCurrency, "No exchange rate for %s", currency))
+	return "", false
+}
+
+// priceIn mengembalikan salinan produk dengan harga dalam currency.
+// Currency kosong berarti tidak dikonversi.
+func priceIn(product Product, currency string) Product {
+	if currency == "" {
+		return product
+	}
+	// Currency produk selalu punya kurs berlaku karena divalidasi saat
+	// disimpan, dan queryCurrency sudah memastikan currency tujuan
+	price, _, ok := convert(product.Price, product.Currency, currency, time.Now())
+	if ok {
+		product.Price = price
+		product.Currency = currency
+	}
+	return product
+}
+
+// Exchange rate handlers
+func getExchangeRates(c *gin.Context) {
+	currency := strings.ToUpper(c.Query("currency"))
+
+	filtered := []ExchangeRate{}
+	for _, rate := range exchangeRates {
+		if currency == "" || rate.Currency == currency {
+			filtered = append(filtered, rate)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].Currency != filtered[j].Currency {
+			return filtered[i].Currency < filtered[j].Currency
+		}
+		return filtered[i].EffectiveFrom.Before(filtered[j].EffectiveFrom)
+	})
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Exchange rates retrieved successfully",
+		Data:    filtered,
+		Error:   nil,
+	})
+}
+
+func createExchangeRate(c *gin.Context) {
+	var newRate ExchangeRate
+	if err := c.ShouldBindJSON(&newRate); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	newRate.Currency = strings.ToUpper(strings.TrimSpace(newRate.Currency))
+	if fields := validate.Struct(&newRate); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+	if newRate.EffectiveFrom.IsZero() {
+		newRate.EffectiveFrom = time.Now().UTC()
+	}
+
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		for _, rate := range exchangeRates {
+			if rate.Currency == newRate.Currency && rate.EffectiveFrom.Equal(newRate.EffectiveFrom) {
+				return nil, nil, reject(http.StatusConflict, "Exchange rate already exists", apierror.Newf(apierror.CodeConflict, "Exchange rate %s for %s at %s already exists", rate.ID, rate.Currency, rate.EffectiveFrom.Format(time.RFC3339)))
+			}
+		}
+		newRate.ID = generateID()
+		return func() { exchangeRates = append(exchangeRates, newRate) }, []events.Message{{Type: EventExchangeRateCreated, Data: newRate}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Exchange rate created", "exchange_rate_id", newRate.ID, "currency", newRate.Currency, "rate", newRate.Rate, "effective_from", newRate.EffectiveFrom)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Exchange rate created successfully",
+		Data:    newRate,
+		Error:   nil,
+	})
+}
+
+func deleteExchangeRate(c *gin.Context) {
+	id := c.Param("id")
+
+	var deleted ExchangeRate
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		for i, rate := range exchangeRates {
+			if rate.ID == id {
+				if !rate.EffectiveFrom.After(time.Now()) {
+					return nil, nil, reject(http.StatusConflict, "Exchange rate already in effect", apierror.New(apierror.CodeConflict, "Only scheduled exchange rates can be deleted; add a new rate to correct one that is in effect"))
+				}
+				deleted = rate
+				return func() { exchangeRates = append(exchangeRates[:i], exchangeRates[i+1:]...) }, []events.Message{{Type: EventExchangeRateDeleted, Data: rate}}, nil
+			}
+		}
+		return nil, nil, reject(http.StatusNotFound, "Exchange rate not found", apierror.NotFound("Exchange rate", id))
+	}) {
+		return
+	}
+	logging.From(c).Info("Exchange rate deleted", "exchange_rate_id", id, "currency", deleted.Currency)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Exchange rate deleted successfully",
+		Data:    nil,
+		Error:   nil,
+	})
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConvertUsesRateInEffect(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	saved := exchangeRates
+	t.Cleanup(func() { exchangeRates = saved })
+	exchangeRates = []ExchangeRate{
+		{ID: "1", Currency: "SGD", Rate: 12000, EffectiveFrom: day(10)},
+		{ID: "2", Currency: "SGD", Rate: 11000, EffectiveFrom: day(1)},
+		{ID: "3", Currency: "MYR", Rate: 3500, EffectiveFrom: day(1)},
+	}
+
+	for _, tc := range []struct {
+		amount   float64
+		from, to string
+		at       time.Time
+		want     float64
+		ok       bool
+	}{
+		{110000, "IDR", "SGD", day(5), 10, true},
+		{120000, "IDR", "SGD", day(10), 10, true},
+		{10, "SGD", "", day(10), 120000, true},
+		{10, "SGD", "MYR", day(5), 31.43, true},
+		{1, "USD", "IDR", day(5), 0, false},
+		{1, "IDR", "SGD", day(1).Add(-time.Second), 0, false},
+	} {
+		got, _, ok := convert(tc.amount, tc.from, tc.to, tc.at)
+		if ok != tc.ok || got != tc.want {
+			t.Errorf("convert(%v, %s, %s, %s) = %v, %v; want %v, %v", tc.amount, tc.from, tc.to, tc.at.Format(time.DateOnly), got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/shipping"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Customer adalah pembeli. UserID diisi kalau customer punya akun login;
+// customer yang dibuat staff (misalnya pesanan lewat telepon) boleh tanpa
+// akun. Email unik tanpa membedakan huruf besar.
+type Customer struct {
+	ID        string             `json:"id"`
+	UserID    string             `json:"user_id,omitempty"`
+	Name      string             `json:"name" validate:"required,max=200"`
+	Email     string             `json:"email" validate:"required,email,max=254"`
+	Phone     string             `json:"phone" validate:"max=30"`
+	Addresses []shipping.Address `json:"addresses" validate:"max=10,dive"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// GuestContact adalah kontak pembeli tanpa akun
+type GuestContact struct {
+	Name  string `json:"name" validate:"required,max=200"`
+	Email string `json:"email" validate:"required,email,max=254"`
+	Phone string `json:"phone" validate:"max=30"`
+}
+
+// MergeGuestOrdersRequest memindahkan pesanan guest ke customer. Customer
+// mengklaim pesanan dengan guest_token dari guest checkout; admin dan
+// staff boleh memakai Email untuk semua pesanan guest dengan email itu.
+type MergeGuestOrdersRequest struct {
+	Orders []GuestOrderClaim `json:"orders" validate:"dive"`
+	Email  string            `json:"email" validate:"omitempty,email"`
+}
+
+type GuestOrderClaim struct {
+	TransactionID string `json:"transaction_id" validate:"required"`
+	GuestToken    string `json:"guest_token" validate:"required"`
+}
+
+// Payload event customer.guest_orders_merged
+type GuestOrdersMergedEvent struct {
+	CustomerID     string   `json:"customer_id"`
+	TransactionIDs []string `json:"transaction_ids"`
+}
+
+// Domain event customer
+const (
+	EventCustomerCreated           = "customer.created"
+	EventCustomerUpdated           = "customer.updated"
+	EventCustomerGuestOrdersMerged = "customer.guest_orders_merged"
+)
+
+var customers = []Customer{}
+
+// guestTokenKey menandatangani guest_token. Token tidak disimpan, cukup
+// dihitung ulang dari ID transaksi saat diklaim.
+var guestTokenKey = randomGuestTokenKey()
+
+func randomGuestTokenKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// initializeCustomers membaca GUEST_TOKEN_SECRET. Kalau kosong, key random
+// dipakai dan guest_token yang sudah dibagikan tidak berlaku setelah
+// restart.
+func initializeCustomers() {
+	if secret := os.Getenv("GUEST_TOKEN_SECRET"); secret != "" {
+		guestTokenKey = []byte(secret)
+		return
+	}
+	slog.Warn("GUEST_TOKEN_SECRET not set, using a random key (guest order tokens are invalidated on restart)")
+}
+
+func guestToken(transactionID string) string {
+	h := hmac.New(sha256.New, guestTokenKey)
+	h.Write([]byte(transactionID))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validGuestToken(transactionID, token string) bool {
+	return hmac.Equal([]byte(guestToken(transactionID)), []byte(token))
+}
+
+// checkoutCustomer menentukan customer untuk checkout yang login.
+// Customer memakai profilnya sendiri; admin dan staff wajib mengisi
+// customer_id (keberadaannya sudah dicek aturan customer_exists). Kalau
+// gagal, request sudah dijawab.
+func checkoutCustomer(c *gin.Context, principal *auth.Principal, customerID string) (string, bool) {
+	if principal.HasRole(auth.RoleAdmin, auth.RoleStaff) {
+		if customerID == "" {
+			respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(apierror.Field("customer_id", "required", "customer_id is required")))
+			return "", false
+		}
+		return customerID, true
+	}
+
+	customer, found := customerForUser(principal.Subject)
+	if !found {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(apierror.Field("customer_id", "required", "Create a customer profile with POST /customers before checkout")))
+		return "", false
+	}
+	if customerID != "" && customerID != customer.ID {
+		respondError(c, http.StatusForbidden, "Forbidden", apierror.New(apierror.CodeForbidden, "Customers can only check out for themselves"))
+		return "", false
+	}
+	return customer.ID, true
+}
+
+// Customer handlers
+func getCustomers(c *gin.Context) {
+	email := c.Query("email")
+
+	filtered := []Customer{}
+	for _, customer := range customers {
+		if email == "" || strings.EqualFold(customer.Email, email) {
+			filtered = append(filtered, customer)
+		}
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Customers retrieved successfully",
+		Data:    filtered,
+		Error:   nil,
+	})
+}
+
+func getCustomer(c *gin.Context) {
+	customer, ok := accessibleCustomer(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Customer retrieved successfully",
+		Data:    customer,
+		Error:   nil,
+	})
+}
+
+// createCustomer membuat profil. Customer hanya bisa membuat profilnya
+// sendiri (satu per akun); admin dan staff boleh mengisi user_id.
+func createCustomer(c *gin.Context) {
+	var newCustomer Customer
+	if !bindCustomer(c, &newCustomer) {
+		return
+	}
+
+	principal, _ := auth.FromContext(c)
+	if !principal.HasRole(auth.RoleAdmin, auth.RoleStaff) {
+		newCustomer.UserID = principal.Subject
+	}
+
+	// Satu profil per akun dan email unik dicek di dalam commit supaya dua
+	// request tidak bisa lolos bersamaan
+	newCustomer.CreatedAt = time.Now().UTC()
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if newCustomer.UserID != "" {
+			if existing, found := customerForUser(newCustomer.UserID); found {
+				return nil, nil, reject(http.StatusConflict, "Customer already exists", apierror.Newf(apierror.CodeConflict, "User %s already has customer %s", newCustomer.UserID, existing.ID))
+			}
+		}
+		if err := uniqueEmail(newCustomer.Email, ""); err != nil {
+			return nil, nil, err
+		}
+		newCustomer.ID = generateID()
+		return func() { customers = append(customers, newCustomer) }, []events.Message{{Type: EventCustomerCreated, Data: newCustomer}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Customer created", "customer_id", newCustomer.ID, "linked_user_id", newCustomer.UserID)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Customer created successfully",
+		Data:    newCustomer,
+		Error:   nil,
+	})
+}
+
+// updateCustomer mengganti profil. ID, user_id dan created_at tidak bisa
+// diubah lewat endpoint ini.
+func updateCustomer(c *gin.Context) {
+	customer, ok := accessibleCustomer(c)
+	if !ok {
+		return
+	}
+
+	var updated Customer
+	if !bindCustomer(c, &updated) {
+		return
+	}
+	updated.ID, updated.UserID, updated.CreatedAt = customer.ID, customer.UserID, customer.CreatedAt
+
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		i := customerIndex(customer.ID)
+		if i < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Customer not found", apierror.NotFound("Customer", customer.ID))
+		}
+		if err := uniqueEmail(updated.Email, customer.ID); err != nil {
+			return nil, nil, err
+		}
+		return func() { customers[i] = updated }, []events.Message{{Type: EventCustomerUpdated, Data: updated}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Customer updated", "customer_id", updated.ID)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Customer updated successfully",
+		Data:    updated,
+		Error:   nil,
+	})
+}
+
+func getCustomerTransactions(c *gin.Context) {
+	customer, ok := accessibleCustomer(c)
+	if !ok {
+		return
+	}
+
+	history := []Transaction{}
+	for _, transaction := range transactions {
+		if transaction.CustomerID == customer.ID {
+			history = append(history, transaction)
+		}
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Customer transactions retrieved successfully",
+		Data:    history,
+		Error:   nil,
+	})
+}
+
+// mergeGuestOrders memindahkan pesanan guest ke customer. Hanya pesanan
+// guest yang belum punya customer yang bisa dipindahkan.
+func mergeGuestOrders(c *gin.Context) {
+	customer, ok := accessibleCustomer(c)
+	if !ok {
+		return
+	}
+
+	var req MergeGuestOrdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+	principal, _ := auth.FromContext(c)
+	if req.Email != "" && !principal.HasRole(auth.RoleAdmin, auth.RoleStaff) {
+		respondError(c, http.StatusForbidden, "Forbidden", apierror.New(apierror.CodeForbidden, "Only admin and staff can merge guest orders by email; use guest tokens instead"))
+		return
+	}
+	if req.Email == "" && len(req.Orders) == 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(apierror.Field("orders", "required", "Orders or email is required")))
+		return
+	}
+
+	// Semua klaim dicek dulu supaya merge terjadi semua atau tidak sama
+	// sekali, di dalam commit supaya pesanan yang sama tidak bisa diklaim
+	// dua customer
+	var merged []string
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if customerIndex(customer.ID) < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Customer not found", apierror.NotFound("Customer", customer.ID))
+		}
+		merged = []string{}
+		var fields []apierror.FieldError
+		for i, claim := range req.Orders {
+			transaction, found := findTransaction(claim.TransactionID)
+			switch {
+			case !found || !validGuestToken(claim.TransactionID, claim.GuestToken):
+				fields = append(fields, apierror.Field(fmt.Sprintf("orders[%d].guest_token", i), "guest_token", "Guest token does not match the transaction"))
+			case transaction.Guest == nil || transaction.CustomerID != "":
+				fields = append(fields, apierror.Field(fmt.Sprintf("orders[%d].transaction_id", i), "guest_order", "Transaction is not an unclaimed guest order"))
+			default:
+				merged = append(merged, transaction.ID)
+			}
+		}
+		if len(fields) > 0 {
+			return nil, nil, reject(http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		}
+		if req.Email != "" {
+			for _, transaction := range transactions {
+				if transaction.Guest != nil && transaction.CustomerID == "" && strings.EqualFold(transaction.Guest.Email, req.Email) && !containsString(merged, transaction.ID) {
+					merged = append(merged, transaction.ID)
+				}
+			}
+		}
+
+		if len(merged) == 0 {
+			return nil, nil, nil
+		}
+		event := GuestOrdersMergedEvent{CustomerID: customer.ID, TransactionIDs: merged}
+		return func() { applyGuestOrdersMerged(event) }, []events.Message{{Type: EventCustomerGuestOrdersMerged, Data: event}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Guest orders merged", "customer_id", customer.ID, "transaction_ids", merged)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Guest orders merged successfully",
+		Data:    GuestOrdersMergedEvent{CustomerID: customer.ID, TransactionIDs: merged},
+		Error:   nil,
+	})
+}
+
+// applyGuestOrdersMerged juga dipakai replay journal
+func applyGuestOrdersMerged(event GuestOrdersMergedEvent) {
+	for i := range transactions {
+		if containsString(event.TransactionIDs, transactions[i].ID) {
+			transactions[i].CustomerID = event.CustomerID
+		}
+	}
+}
+
+func bindCustomer(c *gin.Context, customer *Customer) bool {
+	if err := c.ShouldBindJSON(customer); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return false
+	}
+	customer.Email = strings.TrimSpace(customer.Email)
+	for i := range customer.Addresses {
+		normalizeAddress(&customer.Addresses[i])
+	}
+	if customer.Addresses == nil {
+		customer.Addresses = []shipping.Address{}
+	}
+	if fields := validate.Struct(customer); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return false
+	}
+	return true
+}
+
+// uniqueEmail dipanggil di dalam prepare commitFunc
+func uniqueEmail(email, except string) error {
+	for _, customer := range customers {
+		if customer.ID != except && strings.EqualFold(customer.Email, email) {
+			return reject(http.StatusConflict, "Email already registered", apierror.Newf(apierror.CodeConflict, "Email is already used by customer %s", customer.ID))
+		}
+	}
+	return nil
+}
+
+// accessibleCustomer mencari customer dari parameter id. Customer hanya
+// bisa mengakses profilnya sendiri; selain itu dijawab 404.
+func accessibleCustomer(c *gin.Context) (Customer, bool) {
+	id := c.Param("id")
+	principal, _ := auth.FromContext(c)
+	customer, found := findCustomer(id)
+	if !found || !(principal.HasRole(auth.RoleAdmin, auth.RoleStaff) || customer.UserID == principal.Subject) {
+		respondError(c, http.StatusNotFound, "Customer not found", apierror.NotFound("Customer", id))
+		return Customer{}, false
+	}
+	return customer, true
+}
+
+func findCustomer(id string) (Customer, bool) {
+	if i := customerIndex(id); i >= 0 {
+		return customers[i], true
+	}
+	return Customer{}, false
+}
+
+func customerIndex(id string) int {
+	for i := range customers {
+		if customers[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func customerForUser(userID string) (Customer, bool) {
+	for _, customer := range customers {
+		if userID != "" && customer.UserID == userID {
+			return customer, true
+		}
+	}
+	return Customer{}, false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
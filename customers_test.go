@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"e-commerce/internal/auth"
+	"e-commerce/internal/stream"
+)
+
+func TestGuestCheckoutMergedIntoCustomer(t *testing.T) {
+	api := newTestAPI(t,
+		auth.User{ID: "u2", Username: "staff", Role: auth.RoleStaff},
+		auth.User{ID: "u3", Username: "customer", Role: auth.RoleCustomer},
+		auth.User{ID: "u4", Username: "other", Role: auth.RoleCustomer},
+	)
+	do := api.do
+
+	// Checkout yang login selalu punya customer
+	if code := do("staff", http.MethodPost, "/transactions", `{"product_id":"2","quantity":1}`, nil); code != http.StatusBadRequest {
+		t.Errorf("staff without customer_id status %d", code)
+	}
+	if code := do("other", http.MethodPost, "/transactions", `{"product_id":"2","quantity":1}`, nil); code != http.StatusBadRequest {
+		t.Errorf("customer without profile status %d", code)
+	}
+	var own Transaction
+	if code := do("customer", http.MethodPost, "/transactions", `{"product_id":"2","quantity":1}`, &own); code != http.StatusCreated || own.CustomerID != "1" {
+		t.Fatalf("customer checkout status %d: %+v", code, own)
+	}
+
+	if code := do("", http.MethodPost, "/guest/transactions", `{"product_id":"2","quantity":1}`, nil); code != http.StatusBadRequest {
+		t.Errorf("guest without contact status %d", code)
+	}
+	var guest Transaction
+	if code := do("", http.MethodPost, "/guest/transactions", `{"product_id":"2","quantity":1,"guest":{"name":"Budi","email":"budi@example.com"}}`, &guest); code != http.StatusCreated || guest.GuestToken == "" || guest.CustomerID != "" {
+		t.Fatalf("guest checkout status %d: %+v", code, guest)
+	}
+	if stored, _ := findTransaction(guest.ID); stored.GuestToken != "" {
+		t.Error("guest token was stored")
+	}
+
+	// Token salah atau milik customer lain tidak bisa dipakai
+	claim := func(token string) string {
+		return `{"orders":[{"transaction_id":"` + guest.ID + `","guest_token":"` + token + `"}]}`
+	}
+	if code := do("customer", http.MethodPost, "/customers/1/merge-guest-orders", claim("bad"), nil); code != http.StatusBadRequest {
+		t.Errorf("invalid token status %d", code)
+	}
+	if code := do("other", http.MethodPost, "/customers/1/merge-guest-orders", claim(guest.GuestToken), nil); code != http.StatusNotFound {
+		t.Errorf("other customer merge status %d", code)
+	}
+	if code := do("customer", http.MethodPost, "/customers/1/merge-guest-orders", claim(guest.GuestToken), nil); code != http.StatusOK {
+		t.Fatalf("merge status %d", code)
+	}
+	if code := do("customer", http.MethodPost, "/customers/1/merge-guest-orders", claim(guest.GuestToken), nil); code != http.StatusBadRequest {
+		t.Errorf("second merge status %d", code)
+	}
+
+	var history []Transaction
+	if code := do("customer", http.MethodGet, "/customers/1/transactions", "", &history); code != http.StatusOK || len(history) != 2 {
+		t.Fatalf("history status %d: %+v", code, history)
+	}
+	if code := do("customer", http.MethodGet, "/transactions/"+guest.ID, "", nil); code != http.StatusOK {
+		t.Errorf("merged order status %d", code)
+	}
+	if code := do("other", http.MethodGet, "/customers/1/transactions", "", nil); code != http.StatusNotFound {
+		t.Errorf("other customer history status %d", code)
+	}
+}
+
+func TestTransactionStreamFollowsAccess(t *testing.T) {
+	newTestAPI(t, auth.User{ID: "u3", Username: "customer", Role: auth.RoleCustomer})
+	message := func(transaction Transaction) stream.Message {
+		data, _ := json.Marshal(transaction)
+		return stream.Message{Data: data, Attrs: map[string]string{"product_id": transaction.ProductID}}
+	}
+
+	// Pesanan guest yang sudah di-merge ikut terkirim, sama seperti GET
+	customer := &auth.Principal{Subject: "u3", Role: auth.RoleCustomer}
+	filter := transactionFilter(customer, StreamQuery{})
+	if !filter(message(Transaction{ID: "10", ProductID: "2", CustomerID: "1"})) {
+		t.Error("merged guest order filtered out")
+	}
+	if filter(message(Transaction{ID: "11", ProductID: "2", UserID: "u4"})) {
+		t.Error("other user's transaction sent")
+	}
+
+	staff := &auth.Principal{Subject: "u2", Role: auth.RoleStaff}
+	if transactionFilter(staff, StreamQuery{ProductID: "1"})(message(Transaction{ID: "11", ProductID: "2", UserID: "u4"})) {
+		t.Error("product filter ignored")
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Domain event yang dicatat di outbox. Nama yang sama dengan event webhook
+// diteruskan ke webhook.
+const (
+	EventProductCreated      = "product.created"
+	EventProductUpdated      = "product.updated"
+	EventProductDeleted      = "product.deleted"
+	EventPriceChanged        = "product.price_changed"
+	EventStockChanged        = "product.stock_changed"
+	EventStockLow            = "product.stock_low"
+	EventSourceCreated       = "source.created"
+	EventSourceUpdated       = "source.updated"
+	EventSourceDeleted       = "source.deleted"
+	EventTransactionCreated  = "transaction.created"
+	EventTransactionRefunded = "transaction.refunded"
+)
+
+// Alasan perubahan stock
+const (
+	StockReasonTransaction = "transaction"
+	StockReasonAdjustment  = "adjustment"
+	StockReasonUpdate      = "update"
+
+	// StockReasonTransfer hanya dipakai StockMovement, karena transfer
+	// antar gudang tidak mengubah stock produk
+	StockReasonTransfer = "transfer"
+)
+
+// Stock dianggap menipis kalau turun sampai nilai ini atau lebih rendah
+const lowStockThreshold = 5
+
+// Payload event product.stock_changed
+type StockChangedEvent struct {
+	ProductID string `json:"product_id"`
+	SourceID  string `json:"source_id"`
+	Previous  int    `json:"previous"`
+	Stock     int    `json:"stock"`
+	Reason    string `json:"reason"`
+}
+
+// Payload event product.price_changed
+type PriceChangedEvent struct {
+	ProductID string  `json:"product_id"`
+	Previous  float64 `json:"previous"`
+	Price     float64 `json:"price"`
+}
+
+// Payload event product.stock_low
+type StockLowEvent struct {
+	Product   Product `json:"product"`
+	Threshold int     `json:"threshold"`
+}
+
+// bus diganti di main kalau outbox disimpan di disk. Consumer baru
+// berjalan setelah bus.Start.
+var bus = newEventBus(events.NewMemoryStore())
+
+// newEventBus mendaftarkan semua subscriber dan consumer. Subscriber
+// berjalan di dalam request untuk efek samping di state yang sama,
+// consumer membaca outbox di background. Kalau backend journal aktif,
+// setiap event juga dicatat di journal, lihat journal.go.
+func newEventBus(store events.Store) *events.Bus {
+	var opts events.Options
+	if eventLog != nil {
+		opts.Journal = eventLog
+	}
+	b := events.NewBus(store, opts)
+
+	b.Subscribe(EventStockChanged, func(ctx context.Context, e events.Event) error {
+		var change StockChangedEvent
+		if err := e.Decode(&change); err != nil {
+			return err
+		}
+		if change.Previous <= lowStockThreshold || change.Stock > lowStockThreshold {
+			return nil
+		}
+		product, ok := findProduct(change.ProductID)
+		if !ok {
+			return nil
+		}
+		_, err := b.Commit(ctx, nil, events.Message{Type: EventStockLow, Data: StockLowEvent{Product: product, Threshold: lowStockThreshold}})
+		return err
+	})
+
+	// Stream SSE untuk storefront
+	b.Subscribe(EventStockChanged, publishStockChange)
+	b.Subscribe(EventTransactionCreated, publishTransaction)
+
+	b.Consume("webhooks", forwardToWebhooks)
+	b.Consume("metrics", func(ctx context.Context, e events.Event) error {
+		if e.Type != EventTransactionCreated {
+			return nil
+		}
+		var transaction Transaction
+		if err := e.Decode(&transaction); err != nil {
+			return err
+		}
+		recordTransaction(transaction)
+		return nil
+	})
+	return b
+}
+
+// forwardToWebhooks meneruskan event yang bisa di-subscribe ke webhook
+// dengan ID yang sama dengan outbox
+func forwardToWebhooks(ctx context.Context, e events.Event) error {
+	if !webhook.ValidEvent(e.Type) {
+		return nil
+	}
+	return webhooks.Send(webhook.Event{ID: e.ID, Type: e.Type, CreatedAt: e.OccurredAt, Data: e.Data})
+}
+
+// commit mencatat messages ke outbox lalu menjalankan apply. Kalau outbox
+// gagal ditulis, state tidak berubah dan request dijawab 500.
+func commit(c *gin.Context, apply func(), messages ...events.Message) bool {
+	if _, err := bus.Commit(c.Request.Context(), apply, messages...); err != nil {
+		logging.From(c).Error("Could not write event outbox", "error", err)
+		respondError(c, http.StatusInternalServerError, "Could not save changes", apierror.New(apierror.CodeInternal, "Could not save changes"))
+		return false
+	}
+	return true
+}
+
+// rejection dikembalikan prepare di commitFunc untuk membatalkan commit
+// dan menjawab request dengan error
+type rejection struct {
+	status  int
+	message string
+	data    interface{}
+	err     *apierror.Error
+}
+
+func (r *rejection) Error() string { return r.err.Message }
+
+func reject(status int, message string, err *apierror.Error) error {
+	return &rejection{status: status, message: message, err: err}
+}
+
+// commitFunc seperti commit, tapi prepare dijalankan di antara dua commit
+// sehingga pengecekan state dan perubahannya tidak diselingi request lain.
+// prepare mencari data berdasarkan ID lalu mengembalikan apply dan
+// messages, atau error dari reject untuk menjawab request tanpa mengubah
+// apa pun. prepare tidak boleh memanggil commit atau captureState.
+func commitFunc(c *gin.Context, prepare func() (apply func(), messages []events.Message, err error)) bool {
+	_, err := bus.CommitFunc(c.Request.Context(), prepare)
+	if err == nil {
+		return true
+	}
+	var rejected *rejection
+	if errors.As(err, &rejected) {
+		c.JSON(rejected.status, APIResponse{
+			Message: rejected.message,
+			Data:    rejected.data,
+			Error:   apierror.ForRequest(c, rejected.err),
+		})
+		return false
+	}
+	logging.From(c).Error("Could not write event outbox", "error", err)
+	respondError(c, http.StatusInternalServerError, "Could not save changes", apierror.New(apierror.CodeInternal, "Could not save changes"))
+	return false
+}
+
+// productUpdated mengembalikan event product.updated, ditambah
+// product.price_changed dan product.stock_changed kalau harga atau stock
+// berubah
+func productUpdated(before, after Product, reason string) []events.Message {
+	messages := []events.Message{{Type: EventProductUpdated, Data: after}}
+	if before.Price != after.Price {
+		messages = append(messages, events.Message{Type: EventPriceChanged, Data: PriceChangedEvent{
+			ProductID: after.ID,
+			Previous:  before.Price,
+			Price:     after.Price,
+		}})
+	}
+	return append(messages, stockChanged(before, after, reason)...)
+}
+
+// stockChanged mengembalikan event product.stock_changed kalau stock
+// berbeda
+func stockChanged(before, after Product, reason string) []events.Message {
+	if before.Stock == after.Stock {
+		return nil
+	}
+	return []events.Message{{Type: EventStockChanged, Data: StockChangedEvent{
+		ProductID: after.ID,
+		SourceID:  after.SourceID,
+		Previous:  before.Stock,
+		Stock:     after.Stock,
+		Reason:    reason,
+	}}}
+}
+
+func findProduct(id string) (Product, bool) {
+	for _, product := range products {
+		if product.ID == id {
+			return product, true
+		}
+	}
+	return Product{}, false
+}
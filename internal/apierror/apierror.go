@@ -0,0 +1,112 @@
+// Package apierror berisi format error terstruktur yang dipakai di field
+// "error" pada APIResponse. Code bersifat stabil sehingga client tidak perlu
+// mencocokkan teks pesan.
+package apierror
+
+import (
+	"fmt"
+
+	"e-commerce/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code error yang stabil untuk client
+type Code string
+
+const (
+	CodeInvalidBody         Code = "INVALID_BODY"
+	CodeValidationFailed    Code = "VALIDATION_FAILED"
+	CodeNotFound            Code = "NOT_FOUND"
+	CodeConflict            Code = "CONFLICT"
+	CodeUnauthorized        Code = "UNAUTHORIZED"
+	CodeForbidden           Code = "FORBIDDEN"
+	CodeInvalidCredentials  Code = "INVALID_CREDENTIALS"
+	CodeRateLimited         Code = "RATE_LIMITED"
+	CodeInsufficientStock   Code = "INSUFFICIENT_STOCK"
+	CodeUnsupportedCurrency Code = "UNSUPPORTED_CURRENCY"
+	CodePaymentDeclined     Code = "PAYMENT_DECLINED"
+	CodePaymentTimeout      Code = "PAYMENT_TIMEOUT"
+	CodePaymentFailed       Code = "PAYMENT_FAILED"
+	CodeShippingUnavailable Code = "SHIPPING_UNAVAILABLE"
+	CodeCarrierFailed       Code = "CARRIER_FAILED"
+	CodeBulkRolledBack      Code = "BULK_ROLLED_BACK"
+	CodeUnavailable         Code = "UNAVAILABLE"
+	CodeInternal            Code = "INTERNAL_ERROR"
+)
+
+// FieldError menjelaskan satu aturan validasi yang gagal. Code adalah nama
+// aturan seperti "required" atau "gt".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error adalah isi field "error" di response
+type Error struct {
+	Code    Code         `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+
+	// RequestID sama dengan header X-Request-ID, untuk mencari log server
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Field membuat FieldError
+func Field(field, code, message string) FieldError {
+	return FieldError{Field: field, Code: code, Message: message}
+}
+
+// InvalidBody untuk body yang gagal di-decode
+func InvalidBody(err error) *Error {
+	return New(CodeInvalidBody, err.Error())
+}
+
+// NotFound untuk resource yang tidak ada, contoh "Product with ID 3 not found"
+func NotFound(resource, id string) *Error {
+	return New(CodeNotFound, resource+" with ID "+id+" not found")
+}
+
+// Validation menggabungkan semua field yang gagal validasi
+func Validation(fields ...FieldError) *Error {
+	message := "Validation failed"
+	switch len(fields) {
+	case 0:
+	case 1:
+		message = fields[0].Message
+	default:
+		message = fmt.Sprintf("%s (and %d more)", fields[0].Message, len(fields)-1)
+	}
+	return &Error{Code: CodeValidationFailed, Message: message, Fields: fields}
+}
+
+// ForRequest mengisi RequestID dari request yang sedang diproses
+func ForRequest(c *gin.Context, err *Error) *Error {
+	if err != nil {
+		err.RequestID = requestid.Get(c)
+	}
+	return err
+}
+
+// Abort dipakai middleware untuk menolak request dengan format response
+// yang sama dengan handler.
+func Abort(c *gin.Context, status int, message string, err *Error) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"message": message,
+		"data":    nil,
+		"error":   ForRequest(c, err),
+	})
+}
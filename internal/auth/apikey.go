@@ -0,0 +1,393 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+	ErrAPIKeyRevoked  = errors.New("API key has been revoked")
+)
+
+// Scope membatasi apa yang boleh dilakukan oleh sebuah API key
+type Scope string
+
+const (
+	// Update stock untuk produk milik source pemilik key
+	ScopeStockWrite Scope = "stock:write"
+)
+
+var knownScopes = map[Scope]bool{
+	ScopeStockWrite: true,
+}
+
+func (s Scope) Valid() bool {
+	return knownScopes[s]
+}
+
+const apiKeyPrefix = "sk_"
+
+// APIKey untuk integrasi supplier. Key asli hanya ditampilkan sekali saat
+// dibuat, yang disimpan hanya hash SHA-256.
+type APIKey struct {
+	ID         string     `json:"id"`
+	SourceID   string     `json:"source_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []Scope    `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	RotatedTo  string     `json:"rotated_to,omitempty"`
+}
+
+// StoredAPIKey adalah APIKey beserta hash-nya untuk disimpan di snapshot.
+// Key asli tidak pernah ikut. Jangan dikirim ke client.
+type StoredAPIKey struct {
+	APIKey
+	Hash string `json:"hash"`
+}
+
+// Stored menyertakan hash key untuk disimpan
+func (k APIKey) Stored() StoredAPIKey {
+	return StoredAPIKey{APIKey: k, Hash: k.Hash}
+}
+
+// Key mengembalikan APIKey dengan hash dari storage
+func (k StoredAPIKey) Key() APIKey {
+	key := k.APIKey
+	key.Hash = k.Hash
+	return key
+}
+
+// Active mengecek apakah key masih bisa dipakai pada waktu now
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// HasScope mengecek apakah key punya scope tertentu
+func (k APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyStore menyimpan API key di memory, diindeks berdasarkan hash
+type APIKeyStore struct {
+	mu     sync.Mutex
+	keys   map[string]*APIKey
+	byHash map[string]string
+	nextID int
+	now    func() time.Time
+}
+
+func NewAPIKeyStore() *APIKeyStore {
+	return &APIKeyStore{
+		keys:   map[string]*APIKey{},
+		byHash: map[string]string{},
+		nextID: 1,
+		now:    time.Now,
+	}
+}
+
+// Issue membuat key baru untuk source dan mengembalikan key asli
+func (s *APIKeyStore) Issue(sourceID, name string, scopes []Scope) (string, APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plaintext, key, err := s.newKeyLocked(sourceID, name, scopes)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	s.putLocked(key)
+	return plaintext, key, nil
+}
+
+// NewKey seperti Issue tapi belum menyimpan key-nya; simpan dengan Put.
+// ID langsung dipakai, jadi key yang tidak jadi disimpan tidak berbagi ID
+// dengan key berikutnya.
+func (s *APIKeyStore) NewKey(sourceID, name string, scopes []Scope) (string, APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.newKeyLocked(sourceID, name, scopes)
+}
+
+func (s *APIKeyStore) newKeyLocked(sourceID, name string, scopes []Scope) (string, APIKey, error) {
+	if sourceID == "" {
+		return "", APIKey{}, errors.New("source ID is required")
+	}
+	if len(scopes) == 0 {
+		return "", APIKey{}, errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return "", APIKey{}, errors.New("unknown scope " + string(scope))
+		}
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIKey{}, err
+	}
+	plaintext := apiKeyPrefix + hex.EncodeToString(secret)
+
+	key := APIKey{
+		ID:        strconv.Itoa(s.nextID),
+		SourceID:  sourceID,
+		Name:      name,
+		Prefix:    plaintext[:len(apiKeyPrefix)+6],
+		Hash:      hashAPIKey(plaintext),
+		Scopes:    append([]Scope(nil), scopes...),
+		CreatedAt: s.now().UTC(),
+	}
+	s.nextID++
+	return plaintext, key, nil
+}
+
+// Rotate membuat key pengganti dengan source dan scope yang sama. Key lama
+// masih berlaku selama grace, lalu tidak bisa dipakai lagi.
+func (s *APIKeyStore) Rotate(id string, grace time.Duration) (string, APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	plaintext, old, replacement, err := s.rotationLocked(id, grace)
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	s.putLocked(old, replacement)
+	return plaintext, replacement, nil
+}
+
+// Rotation seperti Rotate tapi belum menyimpan apa pun. Hasilnya key lama
+// yang sudah diubah dan penggantinya; simpan keduanya dengan Put.
+func (s *APIKeyStore) Rotation(id string, grace time.Duration) (string, APIKey, APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rotationLocked(id, grace)
+}
+
+func (s *APIKeyStore) rotationLocked(id string, grace time.Duration) (string, APIKey, APIKey, error) {
+	stored, ok := s.keys[id]
+	if !ok {
+		return "", APIKey{}, APIKey{}, ErrAPIKeyNotFound
+	}
+	now := s.now().UTC()
+	if !stored.Active(now) {
+		return "", APIKey{}, APIKey{}, ErrAPIKeyRevoked
+	}
+
+	plaintext, replacement, err := s.newKeyLocked(stored.SourceID, stored.Name, stored.Scopes)
+	if err != nil {
+		return "", APIKey{}, APIKey{}, err
+	}
+
+	old := *stored
+	old.RotatedTo = replacement.ID
+	if grace > 0 {
+		expiresAt := now.Add(grace)
+		old.ExpiresAt = &expiresAt
+	} else {
+		old.RevokedAt = &now
+	}
+	return plaintext, old, replacement, nil
+}
+
+// Revoke menonaktifkan key secara permanen
+func (s *APIKeyStore) Revoke(id string) (APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, err := s.revocationLocked(id)
+	if err != nil {
+		return APIKey{}, err
+	}
+	s.putLocked(key)
+	return key, nil
+}
+
+// Revocation seperti Revoke tapi belum menyimpan key-nya; simpan dengan Put
+func (s *APIKeyStore) Revocation(id string) (APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revocationLocked(id)
+}
+
+func (s *APIKeyStore) revocationLocked(id string) (APIKey, error) {
+	stored, ok := s.keys[id]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	key := *stored
+	if key.RevokedAt == nil {
+		now := s.now().UTC()
+		key.RevokedAt = &now
+	}
+	return key, nil
+}
+
+// RevokeForSource menonaktifkan semua key milik source, dipakai saat
+// source dihapus.
+func (s *APIKeyStore) RevokeForSource(sourceID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revoked := s.sourceRevocationsLocked(sourceID)
+	s.putLocked(revoked...)
+	return len(revoked)
+}
+
+// SourceRevocations seperti RevokeForSource tapi belum menyimpan apa pun.
+// Hasilnya key aktif milik source yang sudah dicabut, urut berdasarkan ID.
+func (s *APIKeyStore) SourceRevocations(sourceID string) []APIKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sourceRevocationsLocked(sourceID)
+}
+
+func (s *APIKeyStore) sourceRevocationsLocked(sourceID string) []APIKey {
+	now := s.now().UTC()
+	revoked := []APIKey{}
+	for _, stored := range s.keys {
+		if stored.SourceID == sourceID && stored.RevokedAt == nil {
+			key := *stored
+			key.RevokedAt = &now
+			revoked = append(revoked, key)
+		}
+	}
+	sortKeys(revoked)
+	return revoked
+}
+
+// Put menyimpan hasil NewKey, Rotation, Revocation dan SourceRevocations,
+// juga key dari replay journal. Key dengan ID yang sama diganti.
+func (s *APIKeyStore) Put(keys ...APIKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.putLocked(keys...)
+}
+
+func (s *APIKeyStore) putLocked(keys ...APIKey) {
+	for _, key := range keys {
+		key := key
+		key.Scopes = append([]Scope(nil), key.Scopes...)
+		if old, ok := s.keys[key.ID]; ok {
+			delete(s.byHash, old.Hash)
+		}
+		s.keys[key.ID] = &key
+		s.byHash[key.Hash] = key.ID
+		if n, err := strconv.Atoi(key.ID); err == nil && n >= s.nextID {
+			s.nextID = n + 1
+		}
+	}
+}
+
+// Get mengambil metadata key berdasarkan ID
+func (s *APIKeyStore) Get(id string) (APIKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return APIKey{}, false
+	}
+	return *key, true
+}
+
+// List mengembalikan semua key milik source, urut berdasarkan ID
+func (s *APIKeyStore) List(sourceID string) []APIKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []APIKey{}
+	for _, key := range s.keys {
+		if key.SourceID == sourceID {
+			result = append(result, *key)
+		}
+	}
+	sortKeys(result)
+	return result
+}
+
+func sortKeys(keys []APIKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(keys[i].ID)
+		b, _ := strconv.Atoi(keys[j].ID)
+		return a < b
+	})
+}
+
+// Authenticate mencari key berdasarkan hash dan mencatat last-used
+func (s *APIKeyStore) Authenticate(plaintext string) (APIKey, error) {
+	if !strings.HasPrefix(plaintext, apiKeyPrefix) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.byHash[hashAPIKey(plaintext)]
+	if !ok {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+	key := s.keys[id]
+	now := s.now().UTC()
+	if !key.Active(now) {
+		return APIKey{}, ErrAPIKeyRevoked
+	}
+
+	key.LastUsedAt = &now
+	return *key, nil
+}
+
+// Export mengembalikan semua key beserta hash-nya, urut berdasarkan ID
+func (s *APIKeyStore) Export() []StoredAPIKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := make([]StoredAPIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		stored = append(stored, key.Stored())
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		a, _ := strconv.Atoi(stored[i].ID)
+		b, _ := strconv.Atoi(stored[j].ID)
+		return a < b
+	})
+	return stored
+}
+
+// Restore mengganti semua key dengan hasil Export. ID berikutnya
+// dilanjutkan dari ID terbesar.
+func (s *APIKeyStore) Restore(stored []StoredAPIKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = map[string]*APIKey{}
+	s.byHash = map[string]string{}
+	s.nextID = 1
+	for _, key := range stored {
+		s.putLocked(key.Key())
+	}
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
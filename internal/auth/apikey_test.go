@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeyStoreLookupRotateRevoke(t *testing.T) {
+	store := NewAPIKeyStore()
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+
+	plaintext, key, err := store.Issue("1", "erp", []Scope{ScopeStockWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Yang disimpan hanya hash, key asli tetap bisa dicari lewat hash-nya
+	if key.Hash == "" || key.Hash == plaintext || key.Hash != hashAPIKey(plaintext) {
+		t.Fatalf("stored hash %q", key.Hash)
+	}
+	if key.Prefix != plaintext[:len(key.Prefix)] {
+		t.Errorf("prefix %q does not match key", key.Prefix)
+	}
+	got, err := store.Authenticate(plaintext)
+	if err != nil || got.ID != key.ID || got.LastUsedAt == nil {
+		t.Fatalf("Authenticate() = %+v, %v", got, err)
+	}
+	for _, invalid := range []string{"", "sk_", plaintext + "x", "pk_" + plaintext[3:]} {
+		if _, err := store.Authenticate(invalid); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("Authenticate(%q) error = %v", invalid, err)
+		}
+	}
+
+	// Key lama masih berlaku selama grace, lalu ditolak
+	rotated, replacement, err := store.Rotate(key.ID, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replacement.SourceID != "1" || !replacement.HasScope(ScopeStockWrite) {
+		t.Errorf("replacement %+v", replacement)
+	}
+	if old, _ := store.Get(key.ID); old.RotatedTo != replacement.ID {
+		t.Errorf("old key rotated to %q", old.RotatedTo)
+	}
+	if _, err := store.Authenticate(plaintext); err != nil {
+		t.Errorf("old key during grace: %v", err)
+	}
+	now = now.Add(time.Hour)
+	if _, err := store.Authenticate(plaintext); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("old key after grace error = %v", err)
+	}
+	if _, _, err := store.Rotate(key.ID, time.Hour); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("rotating expired key error = %v", err)
+	}
+
+	if _, err := store.Authenticate(rotated); err != nil {
+		t.Fatalf("replacement key: %v", err)
+	}
+	if _, err := store.Revoke(replacement.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Authenticate(rotated); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("revoked key error = %v", err)
+	}
+	if _, err := store.Revoke("99"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revoking unknown key error = %v", err)
+	}
+
+	// Rotasi tanpa grace langsung menonaktifkan key lama
+	other, otherKey, err := store.Issue("2", "pos", []Scope{ScopeStockWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Rotate(otherKey.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Authenticate(other); !errors.Is(err, ErrAPIKeyRevoked) {
+		t.Errorf("old key rotated without grace error = %v", err)
+	}
+}
+
+func TestAPIKeyStoreExportRestore(t *testing.T) {
+	store := NewAPIKeyStore()
+	plaintext, key, err := store.Issue("1", "erp", []Scope{ScopeStockWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hash ikut disimpan, key asli tidak
+	stored := store.Export()
+	data, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), key.Hash) || strings.Contains(string(data), plaintext) {
+		t.Fatalf("exported keys %s", data)
+	}
+
+	var decoded []StoredAPIKey
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewAPIKeyStore()
+	restored.Restore(decoded)
+	if got, err := restored.Authenticate(plaintext); err != nil || got.ID != key.ID {
+		t.Fatalf("Authenticate() after restore = %+v, %v", got, err)
+	}
+	if _, next, err := restored.Issue("1", "pos", []Scope{ScopeStockWrite}); err != nil || next.ID != "2" {
+		t.Errorf("Issue() after restore = %+v, %v", next, err)
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := NewAPIKeyStore()
+	// Scope lain belum ada, jadi key tanpa scope dibuat langsung di store
+	withScope, _, err := store.Issue("1", "erp", []Scope{ScopeStockWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutScope, key, err := store.Issue("1", "reporting", []Scope{ScopeStockWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.keys[key.ID].Scopes = nil
+
+	r := gin.New()
+	r.Use(AuthenticateAPIKey(store))
+	r.PATCH("/stock", RequireScope(ScopeStockWrite), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, tt := range []struct {
+		name string
+		key  string
+		want int
+	}{
+		{"with scope", withScope, http.StatusOK},
+		{"missing scope", withoutScope, http.StatusForbidden},
+		{"unknown key", "sk_unknown", http.StatusUnauthorized},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/stock", nil)
+			req.Header.Set("X-API-Key", tt.key)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"e-commerce/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "auth.principal"
+
+// Principal adalah identitas yang sudah terautentikasi untuk request ini
+type Principal struct {
+	Subject  string `json:"subject"`
+	Username string `json:"username"`
+	Role     Role   `json:"role"`
+
+	// Hanya diisi untuk request yang memakai API key supplier
+	KeyID    string  `json:"key_id,omitempty"`
+	SourceID string  `json:"source_id,omitempty"`
+	Scopes   []Scope `json:"scopes,omitempty"`
+}
+
+// HasRole mengecek apakah principal punya salah satu role
+func (p *Principal) HasRole(roles ...Role) bool {
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope mengecek scope API key. User dengan JWT tidak dibatasi scope.
+func (p *Principal) HasScope(scope Scope) bool {
+	if p.KeyID == "" {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// FromContext mengambil principal yang diset oleh Authenticate
+func FromContext(c *gin.Context) (*Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok
+}
+
+// SetPrincipal menyimpan principal di context request
+func SetPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(principalKey, principal)
+}
+
+// Authenticate membaca header "Authorization: Bearer <token>". Request
+// tanpa token tetap diteruskan, cek wajib login dilakukan RequireRoles.
+func Authenticate(tokens *TokenIssuer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			abort(c, http.StatusUnauthorized, "Unauthorized", apierror.CodeUnauthorized, "Authorization header must use the Bearer scheme")
+			return
+		}
+
+		claims, err := tokens.Verify(strings.TrimSpace(token))
+		if err != nil {
+			detail := "Invalid bearer token"
+			if errors.Is(err, ErrExpiredToken) {
+				detail = "Bearer token has expired"
+			}
+			abort(c, http.StatusUnauthorized, "Unauthorized", apierror.CodeUnauthorized, detail)
+			return
+		}
+
+		SetPrincipal(c, &Principal{Subject: claims.Subject, Username: claims.Username, Role: claims.Role})
+		c.Next()
+	}
+}
+
+// AuthenticateAPIKey membaca header "X-API-Key" untuk integrasi supplier.
+// Principal dari API key selalu punya role supplier.
+func AuthenticateAPIKey(keys *APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plaintext := c.GetHeader("X-API-Key")
+		if plaintext == "" {
+			c.Next()
+			return
+		}
+
+		if _, ok := FromContext(c); ok {
+			abort(c, http.StatusBadRequest, "Invalid credentials", apierror.CodeInvalidCredentials, "Use either a bearer token or an API key, not both")
+			return
+		}
+
+		key, err := keys.Authenticate(plaintext)
+		if err != nil {
+			abort(c, http.StatusUnauthorized, "Unauthorized", apierror.CodeUnauthorized, err.Error())
+			return
+		}
+
+		SetPrincipal(c, &Principal{
+			Subject:  "apikey:" + key.ID,
+			Username: key.Name,
+			Role:     RoleSupplier,
+			KeyID:    key.ID,
+			SourceID: key.SourceID,
+			Scopes:   key.Scopes,
+		})
+		c.Next()
+	}
+}
+
+// RequireScope menolak API key yang tidak punya scope (403)
+func RequireScope(scope Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := FromContext(c)
+		if ok && !principal.HasScope(scope) {
+			abort(c, http.StatusForbidden, "Forbidden", apierror.CodeForbidden, "API key is missing scope "+string(scope))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRoles menolak request yang belum login (401) atau yang role-nya
+// tidak termasuk daftar (403).
+func RequireRoles(roles ...Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := FromContext(c)
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			abort(c, http.StatusUnauthorized, "Unauthorized", apierror.CodeUnauthorized, "Authentication is required")
+			return
+		}
+
+		if !principal.HasRole(roles...) {
+			abort(c, http.StatusForbidden, "Forbidden", apierror.CodeForbidden, "Role "+string(principal.Role)+" is not allowed to access this resource")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireToken untuk server tanpa user store: request harus membawa
+// "Authorization: Bearer <token>" yang sama dengan token. Token kosong
+// berarti endpoint dinonaktifkan (403).
+func RequireToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			abort(c, http.StatusForbidden, "Forbidden", apierror.CodeForbidden, "This endpoint is disabled because no admin token is configured")
+			return
+		}
+
+		scheme, given, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(given)), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			abort(c, http.StatusUnauthorized, "Unauthorized", apierror.CodeUnauthorized, "A valid admin token is required")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func abort(c *gin.Context, status int, message string, code apierror.Code, detail string) {
+	apierror.Abort(c, status, message, apierror.New(code, detail))
+}
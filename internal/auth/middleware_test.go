@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	issuer := testIssuer(t, Key{ID: "k1", Secret: []byte("test-secret-0123456")})
+	r := gin.New()
+	r.Use(Authenticate(issuer))
+	r.GET("/staff", RequireRoles(RoleAdmin, RoleStaff), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	token := func(role Role) string {
+		token, _, err := issuer.Issue(User{ID: "u1", Username: string(role), Role: role})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + token
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		want          int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"wrong scheme", "Basic dXNlcjpwYXNz", http.StatusUnauthorized},
+		{"invalid token", "Bearer abc", http.StatusUnauthorized},
+		{"customer", token(RoleCustomer), http.StatusForbidden},
+		{"staff", token(RoleStaff), http.StatusOK},
+		{"admin", token(RoleAdmin), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/staff", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.authorization == "" && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("missing WWW-Authenticate header")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Package auth berisi JWT bearer auth, user store lokal dan middleware
+// untuk cek role di level route.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// Key adalah secret HMAC untuk tanda tangan token. ID dipakai sebagai
+// header "kid" supaya key lama tetap bisa diverifikasi setelah rotasi.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Claims yang disimpan di dalam token
+type Claims struct {
+	Subject   string `json:"sub"`
+	Username  string `json:"username"`
+	Role      Role   `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid,omitempty"`
+}
+
+// TokenIssuer membuat dan memverifikasi JWT HS256. Key pertama dipakai
+// untuk sign, semua key dipakai untuk verifikasi.
+type TokenIssuer struct {
+	keys []Key
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+func NewTokenIssuer(keys []Key, ttl time.Duration) (*TokenIssuer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+	for _, key := range keys {
+		if len(key.Secret) < 16 {
+			return nil, errors.New("signing key " + key.ID + " must be at least 16 bytes")
+		}
+	}
+	return &TokenIssuer{keys: keys, ttl: ttl, now: time.Now}, nil
+}
+
+// TTL mengembalikan umur token yang diterbitkan
+func (t *TokenIssuer) TTL() time.Duration {
+	return t.ttl
+}
+
+// Issue menandatangani claims untuk user dan mengisi iat/exp
+func (t *TokenIssuer) Issue(user User) (string, Claims, error) {
+	now := t.now()
+	claims := Claims{
+		Subject:   user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(t.ttl).Unix(),
+	}
+
+	key := t.keys[0]
+	header, err := json.Marshal(tokenHeader{Alg: "HS256", Typ: "JWT", Kid: key.ID})
+	if err != nil {
+		return "", Claims{}, err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	unsigned := encodeSegment(header) + "." + encodeSegment(payload)
+	return unsigned + "." + encodeSegment(sign(key.Secret, unsigned)), claims, nil
+}
+
+// Verify mengecek signature dan masa berlaku token
+func (t *TokenIssuer) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return Claims{}, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	unsigned := parts[0] + "." + parts[1]
+	verified := false
+	for _, key := range t.keys {
+		if header.Kid != "" && key.ID != header.Kid {
+			continue
+		}
+		if hmac.Equal(signature, sign(key.Secret, unsigned)) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if claims.Subject == "" || !claims.Role.Valid() {
+		return Claims{}, ErrInvalidToken
+	}
+	if t.now().Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+func sign(secret []byte, data string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ParseKeys membaca daftar key dengan format "id=secret,id2=secret2".
+// Key pertama adalah key aktif untuk sign.
+func ParseKeys(spec string) ([]Key, error) {
+	var keys []Key
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(item, "=")
+		if !ok || id == "" || secret == "" {
+			return nil, errors.New("invalid key " + item + ", expected id=secret")
+		}
+		keys = append(keys, Key{ID: id, Secret: []byte(secret)})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no keys configured")
+	}
+	return keys, nil
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testIssuer(t *testing.T, keys ...Key) *TokenIssuer {
+	t.Helper()
+	issuer, err := NewTokenIssuer(keys, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	issuer.now = func() time.Time { return now }
+	return issuer
+}
+
+// tamper mengganti payload token tanpa menandatangani ulang
+func tamper(t *testing.T, token string, edit func(*Claims)) string {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		t.Fatal(err)
+	}
+	edit(&claims)
+	payload, _ := json.Marshal(claims)
+	return parts[0] + "." + encodeSegment(payload) + "." + parts[2]
+}
+
+// withHeader mengganti header token lalu menandatanganinya dengan secret
+func withHeader(header tokenHeader, token string, secret []byte) string {
+	data, _ := json.Marshal(header)
+	parts := strings.Split(token, ".")
+	unsigned := encodeSegment(data) + "." + parts[1]
+	return unsigned + "." + encodeSegment(sign(secret, unsigned))
+}
+
+func TestVerify(t *testing.T) {
+	current := Key{ID: "k2", Secret: []byte("current-secret-0123")}
+	old := Key{ID: "k1", Secret: []byte("old-secret-0123456")}
+	issuer := testIssuer(t, current, old)
+	user := User{ID: "u3", Username: "customer", Role: RoleCustomer}
+
+	token, _, err := issuer.Issue(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, _, err := testIssuer(t, old).Issue(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := Key{ID: "k9", Secret: []byte("unknown-secret-0123")}
+	unknownToken, _, err := testIssuer(t, unknown).Issue(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired := testIssuer(t, current, old)
+	expired.now = func() time.Time { return issuer.now().Add(time.Hour) }
+
+	tests := []struct {
+		name   string
+		issuer *TokenIssuer
+		token  string
+		want   error
+	}{
+		{"valid", issuer, token, nil},
+		{"signed with rotated key", issuer, oldToken, nil},
+		{"unknown kid", issuer, unknownToken, ErrInvalidToken},
+		{"kid of other key", issuer, withHeader(tokenHeader{Alg: "HS256", Typ: "JWT", Kid: "k2"}, oldToken, old.Secret), ErrInvalidToken},
+		{"alg none", issuer, withHeader(tokenHeader{Alg: "none", Typ: "JWT", Kid: "k2"}, token, current.Secret), ErrInvalidToken},
+		{"tampered role", issuer, tamper(t, token, func(c *Claims) { c.Role = RoleAdmin }), ErrInvalidToken},
+		{"tampered expiry", issuer, tamper(t, token, func(c *Claims) { c.ExpiresAt += 3600 }), ErrInvalidToken},
+		{"tampered signature", issuer, token[:len(token)-2] + "AA", ErrInvalidToken},
+		{"not a JWT", issuer, "abc.def", ErrInvalidToken},
+		{"expired", expired, token, ErrExpiredToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.issuer.Verify(tt.token)
+			if !errors.Is(err, tt.want) || (err == nil) != (tt.want == nil) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.want)
+			}
+			if err == nil && (claims.Subject != user.ID || claims.Role != user.Role) {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
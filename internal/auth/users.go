@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// Role yang dikenal oleh API
+type Role string
+
+const (
+	RoleAdmin    Role = "admin"
+	RoleStaff    Role = "staff"
+	RoleCustomer Role = "customer"
+
+	// Role untuk principal dari API key, tidak bisa dipakai untuk login
+	RoleSupplier Role = "supplier"
+)
+
+// Valid mengecek role untuk akun user lokal
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAdmin, RoleStaff, RoleCustomer:
+		return true
+	}
+	return false
+}
+
+// User untuk login, password disimpan dalam bentuk hash bcrypt
+type User struct {
+	ID           string `json:"id"`
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+	Role         Role   `json:"role"`
+}
+
+// UserStore adalah user store lokal di memory
+type UserStore struct {
+	mu    sync.RWMutex
+	users map[string]User
+}
+
+func NewUserStore(users []User) (*UserStore, error) {
+	store := &UserStore{users: map[string]User{}}
+	for _, user := range users {
+		if err := store.Add(user); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// LoadUserStore membaca user dari file JSON berisi array User
+func LoadUserStore(path string) (*UserStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return NewUserStore(users)
+}
+
+func (s *UserStore) Add(user User) error {
+	if user.ID == "" || user.Username == "" || user.PasswordHash == "" {
+		return errors.New("user id, username and password_hash are required")
+	}
+	if !user.Role.Valid() {
+		return fmt.Errorf("user %s has unknown role %q", user.Username, user.Role)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.users[user.Username]; exists {
+		return fmt.Errorf("username %s already exists", user.Username)
+	}
+	s.users[user.Username] = user
+	return nil
+}
+
+// Authenticate mencocokkan username dan password
+func (s *UserStore) Authenticate(username, password string) (User, error) {
+	s.mu.RLock()
+	user, ok := s.users[username]
+	s.mu.RUnlock()
+
+	if !ok {
+		// Tetap hitung hash supaya waktu response tidak membocorkan username
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return User{}, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// HashPassword membuat hash bcrypt untuk disimpan di user store
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
@@ -0,0 +1,434 @@
+// Package config memuat konfigurasi server dari file YAML/TOML, environment
+// variable dan flag CLI. Prioritas: flag > env > file > default.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"e-commerce/internal/fixture"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/payment"
+	"e-commerce/internal/ratelimit"
+	"e-commerce/internal/shipping"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Storage backend yang didukung
+const (
+	BackendMemory   = "memory"
+	BackendSnapshot = "snapshot"
+	BackendJournal  = "journal"
+)
+
+var backends = []string{BackendMemory, BackendSnapshot, BackendJournal}
+
+// Aturan memilih gudang untuk transaksi yang tidak memilih warehouse_id
+const (
+	AllocationMostStock = "most_stock"
+	AllocationNearest   = "nearest"
+)
+
+var allocations = []string{AllocationMostStock, AllocationNearest}
+
+type Config struct {
+	Addr       string                    `yaml:"addr" toml:"addr"`
+	Mode       string                    `yaml:"mode" toml:"mode"`
+	Storage    Storage                   `yaml:"storage" toml:"storage"`
+	Outbox     Outbox                    `yaml:"outbox" toml:"outbox"`
+	Payment    Payment                   `yaml:"payment" toml:"payment"`
+	Shipping   Shipping                  `yaml:"shipping" toml:"shipping"`
+	Warehouses Warehouses                `yaml:"warehouses" toml:"warehouses"`
+	Seed       bool                      `yaml:"seed" toml:"seed"`
+	Fixtures   Fixtures                  `yaml:"fixtures" toml:"fixtures"`
+	CORS       CORS                      `yaml:"cors" toml:"cors"`
+	Proxies    Proxies                   `yaml:"proxies" toml:"proxies"`
+	Timeouts   Timeouts                  `yaml:"timeouts" toml:"timeouts"`
+	TLS        TLS                       `yaml:"tls" toml:"tls"`
+	Log        Log                       `yaml:"log" toml:"log"`
+	RateLimits map[string]ratelimit.Rule `yaml:"rate_limits" toml:"rate_limits"`
+
+	// PrintConfig diisi dari --print-config, tidak pernah dari file
+	PrintConfig bool `yaml:"-" toml:"-"`
+}
+
+// Storage memory hilang saat restart. Backend snapshot menyimpan seluruh
+// data ke Path secara berkala setiap SnapshotInterval dan saat shutdown,
+// lalu memuatnya lagi saat start. Path kosong berarti data/<nama server>.json.
+//
+// Backend journal mencatat setiap perubahan ke log di folder Path (kosong
+// berarti data/<nama server>) dan me-replay log saat start. Setelah
+// CompactThreshold entry, log dilipat ke snapshot di folder yang sama; 0
+// berarti hanya lewat endpoint admin.
+type Storage struct {
+	Backend          string   `yaml:"backend" toml:"backend"`
+	Path             string   `yaml:"path" toml:"path"`
+	SnapshotInterval Duration `yaml:"snapshot_interval" toml:"snapshot_interval"`
+	CompactThreshold int      `yaml:"compact_threshold" toml:"compact_threshold"`
+}
+
+// Outbox menyimpan domain event sampai semua consumer memprosesnya.
+// Dir kosong berarti outbox di memory dan event yang belum terkirim hilang
+// saat restart.
+type Outbox struct {
+	Dir string `yaml:"dir" toml:"dir"`
+}
+
+// Payment memilih payment provider. Timeout adalah batas waktu authorize
+// dan capture satu transaksi; kalau lewat, stock yang ditahan dilepas.
+// Secret webhook provider dibaca dari PAYMENT_WEBHOOK_SECRET.
+type Payment struct {
+	Provider string   `yaml:"provider" toml:"provider"`
+	Timeout  Duration `yaml:"timeout" toml:"timeout"`
+}
+
+// Shipping memilih kurir untuk mengirim shipment
+type Shipping struct {
+	Carrier string `yaml:"carrier" toml:"carrier"`
+}
+
+// Warehouses memilih aturan alokasi gudang. most_stock memakai gudang
+// dengan stock terbanyak; nearest memakai gudang di kota lalu negara yang
+// sama dengan alamat pengiriman, dan jatuh ke most_stock kalau transaksi
+// tanpa alamat.
+type Warehouses struct {
+	Allocation string `yaml:"allocation" toml:"allocation"`
+}
+
+// Fixtures memilih data awal yang dimuat kalau Seed aktif. File menimpa
+// Set; Size hanya dipakai set load-test.
+type Fixtures struct {
+	Set  string `yaml:"set" toml:"set"`
+	File string `yaml:"file" toml:"file"`
+	Size int    `yaml:"size" toml:"size"`
+}
+
+type CORS struct {
+	// Origins yang boleh memanggil API dari browser, "*" untuk semua.
+	// Kosong berarti CORS tidak aktif.
+	Origins []string `yaml:"origins" toml:"origins"`
+}
+
+// Proxies adalah IP atau CIDR reverse proxy yang header X-Forwarded-For
+// dan X-Real-IP-nya dipercaya untuk menentukan IP client. Kosong berarti
+// IP client selalu diambil dari koneksi, supaya client tidak bisa memilih
+// bucket rate limit sendiri lewat header.
+type Proxies struct {
+	Trusted []string `yaml:"trusted" toml:"trusted"`
+}
+
+// Timeouts untuk http.Server, 0 berarti tanpa batas. Shutdown adalah
+// waktu maksimal menunggu request yang sedang berjalan saat server berhenti.
+type Timeouts struct {
+	Read       Duration `yaml:"read" toml:"read"`
+	ReadHeader Duration `yaml:"read_header" toml:"read_header"`
+	Write      Duration `yaml:"write" toml:"write"`
+	Idle       Duration `yaml:"idle" toml:"idle"`
+	Shutdown   Duration `yaml:"shutdown" toml:"shutdown"`
+}
+
+// TLS aktif kalau cert dan key diisi
+type TLS struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file"`
+	KeyFile  string `yaml:"key_file" toml:"key_file"`
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+type Log struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+// Duration ditulis sebagai string seperti "15s" di file konfigurasi
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// Defaults adalah konfigurasi awal kedua server. Rate limit berbeda per
+// server, jadi diisi oleh pemanggil.
+func Defaults(rateLimits map[string]ratelimit.Rule) Config {
+	limits := make(map[string]ratelimit.Rule, len(rateLimits))
+	for group, rule := range rateLimits {
+		limits[group] = rule
+	}
+
+	return Config{
+		Addr:       ":8080",
+		Mode:       gin.DebugMode,
+		Storage:    Storage{Backend: BackendMemory, SnapshotInterval: Duration{time.Minute}, CompactThreshold: 10000},
+		Payment:    Payment{Provider: payment.ProviderFake, Timeout: Duration{10 * time.Second}},
+		Shipping:   Shipping{Carrier: shipping.CarrierFake},
+		Warehouses: Warehouses{Allocation: AllocationMostStock},
+		Seed:       true,
+		Fixtures: Fixtures{
+			Set:  fixture.SetDemo,
+			Size: 10000,
+		},
+		Timeouts: Timeouts{
+			Read:       Duration{15 * time.Second},
+			ReadHeader: Duration{5 * time.Second},
+			Write:      Duration{30 * time.Second},
+			Idle:       Duration{60 * time.Second},
+			Shutdown:   Duration{15 * time.Second},
+		},
+		Log:        Log{Level: "info", Format: logging.FormatJSON},
+		RateLimits: limits,
+	}
+}
+
+// Load membaca konfigurasi untuk program name dari args (tanpa nama
+// program). File konfigurasi dipilih lewat --config atau CONFIG_FILE.
+func Load(name string, defaults Config, args []string) (*Config, error) {
+	fs, flags := newFlagSet(name)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	cfg := defaults
+	cfg.RateLimits = Defaults(defaults.RateLimits).RateLimits
+
+	path := os.Getenv(envConfigFile)
+	if flags.configFile != "" {
+		path = flags.configFile
+	}
+	if path != "" {
+		if err := loadFile(&cfg, path); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok && value != "" {
+			if err := s.set(&cfg, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+	for _, s := range settings {
+		if value, ok := flags.values[s.flag]; ok && value.set {
+			if err := s.set(&cfg, value.value); err != nil {
+				return nil, fmt.Errorf("--%s: %w", s.flag, err)
+			}
+		}
+	}
+	cfg.PrintConfig = flags.printConfig
+
+	if err := cfg.Validate(defaults.RateLimits); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadFile membaca file YAML (.yaml, .yml) atau TOML (.toml). Key yang
+// tidak dikenal dianggap error supaya salah ketik tidak diam-diam diabaikan.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+
+	// Rate limit di file menimpa per group, bukan seluruh map
+	defaults := cfg.RateLimits
+	cfg.RateLimits = nil
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("config file %s must end in .yaml, .yml or .toml", path)
+	}
+
+	for group, rule := range cfg.RateLimits {
+		defaults[group] = rule
+	}
+	cfg.RateLimits = defaults
+	return nil
+}
+
+// Validate mengecek semua nilai dan mengembalikan semua kesalahan sekaligus.
+// groups adalah rate limit group yang dikenal server.
+func (c *Config) Validate(groups map[string]ratelimit.Rule) error {
+	var errs []error
+	fail := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Addr); err != nil || port == "" {
+		fail("addr", "%q must be host:port such as :8080", c.Addr)
+	}
+
+	switch c.Mode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	default:
+		fail("mode", "%q must be one of debug, release or test", c.Mode)
+	}
+
+	if !contains(backends, c.Storage.Backend) {
+		fail("storage.backend", "%q must be one of %s", c.Storage.Backend, strings.Join(backends, ", "))
+	}
+	if c.Storage.CompactThreshold < 0 {
+		fail("storage.compact_threshold", "must not be negative")
+	}
+
+	if !contains(payment.Providers, c.Payment.Provider) {
+		fail("payment.provider", "%q must be one of %s", c.Payment.Provider, strings.Join(payment.Providers, ", "))
+	}
+	if c.Payment.Timeout.Duration <= 0 {
+		fail("payment.timeout", "must be positive")
+	}
+
+	if !contains(shipping.Carriers, c.Shipping.Carrier) {
+		fail("shipping.carrier", "%q must be one of %s", c.Shipping.Carrier, strings.Join(shipping.Carriers, ", "))
+	}
+
+	if !contains(allocations, c.Warehouses.Allocation) {
+		fail("warehouses.allocation", "%q must be one of %s", c.Warehouses.Allocation, strings.Join(allocations, ", "))
+	}
+
+	if !contains(fixture.Sets, c.Fixtures.Set) {
+		fail("fixtures.set", "%q must be one of %s", c.Fixtures.Set, strings.Join(fixture.Sets, ", "))
+	}
+	if c.Fixtures.Size <= 0 {
+		fail("fixtures.size", "must be positive")
+	}
+	if c.Fixtures.File != "" {
+		if _, err := os.Stat(c.Fixtures.File); err != nil {
+			fail("fixtures.file", "%v", err)
+		}
+	}
+
+	for _, origin := range c.CORS.Origins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.TrimSuffix(u.Path, "/") != "" {
+			fail("cors.origins", "%q must be * or an origin such as https://shop.example.com", origin)
+		}
+	}
+
+	for _, proxy := range c.Proxies.Trusted {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				fail("proxies.trusted", "%q must be an IP address or CIDR such as 10.0.0.0/8", proxy)
+			}
+		}
+	}
+
+	timeouts := map[string]Duration{
+		"timeouts.read":        c.Timeouts.Read,
+		"timeouts.read_header": c.Timeouts.ReadHeader,
+		"timeouts.write":       c.Timeouts.Write,
+		"timeouts.idle":        c.Timeouts.Idle,
+		"timeouts.shutdown":    c.Timeouts.Shutdown,
+
+		"storage.snapshot_interval": c.Storage.SnapshotInterval,
+	}
+	for key, d := range timeouts {
+		if d.Duration < 0 {
+			fail(key, "must not be negative")
+		}
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		fail("log.level", "%q must be one of debug, info, warn or error", c.Log.Level)
+	}
+	if c.Log.Format != logging.FormatJSON && c.Log.Format != logging.FormatText {
+		fail("log.format", "%q must be json or text", c.Log.Format)
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		fail("tls", "cert_file and key_file must be set together")
+	}
+	for key, path := range map[string]string{"tls.cert_file": c.TLS.CertFile, "tls.key_file": c.TLS.KeyFile} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			fail(key, "%v", err)
+		}
+	}
+
+	for _, group := range sortedKeys(c.RateLimits) {
+		if _, ok := groups[group]; !ok {
+			fail("rate_limits."+group, "unknown group, expected one of %s", strings.Join(sortedKeys(groups), ", "))
+			continue
+		}
+		if err := c.RateLimits[group].Validate(); err != nil {
+			fail("rate_limits."+group, "%v", err)
+		}
+	}
+
+	// Urutan map tidak stabil, jadi error diurutkan supaya output konsisten
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+// Write menulis konfigurasi efektif sebagai YAML, dipakai oleh --print-config
+func (c *Config) Write(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(rules map[string]ratelimit.Rule) []string {
+	keys := make([]string, 0, len(rules))
+	for key := range rules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
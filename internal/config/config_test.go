@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"e-commerce/internal/ratelimit"
+)
+
+var testLimits = map[string]ratelimit.Rule{
+	"default": {Requests: 300, Period: time.Minute},
+	"auth":    {Requests: 10, Period: time.Minute},
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+addr: ":9000"
+mode: release
+seed: false
+cors:
+  origins: ["https://shop.example.com"]
+timeouts:
+  read: 5s
+rate_limits:
+  auth: 5/1m
+`)
+	t.Setenv("LISTEN_ADDR", ":9100")
+	t.Setenv("READ_TIMEOUT", "7s")
+
+	cfg, err := Load("test", Defaults(testLimits), []string{"--config", path, "--read-timeout", "9s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Addr != ":9100" {
+		t.Errorf("addr = %q, env should override file", cfg.Addr)
+	}
+	if cfg.Timeouts.Read.Duration != 9*time.Second {
+		t.Errorf("read timeout = %s, flag should override env", cfg.Timeouts.Read)
+	}
+	if cfg.Mode != "release" || cfg.Seed {
+		t.Errorf("mode = %q, seed = %v, file values not applied", cfg.Mode, cfg.Seed)
+	}
+	if cfg.Timeouts.Write.Duration != 30*time.Second {
+		t.Errorf("write timeout = %s, default should be kept", cfg.Timeouts.Write)
+	}
+	if got := cfg.RateLimits["auth"].String(); got != "5/1m" {
+		t.Errorf("auth rate limit = %s, want 5/1m", got)
+	}
+	if got := cfg.RateLimits["default"].String(); got != "300/1m" {
+		t.Errorf("default rate limit = %s, want 300/1m", got)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+addr = ":9200"
+
+[cors]
+origins = ["*"]
+
+[rate_limits]
+default = "100/1s"
+`)
+	cfg, err := Load("test", Defaults(testLimits), []string{"--config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != ":9200" || len(cfg.CORS.Origins) != 1 || cfg.RateLimits["default"].Requests != 100 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+func TestLoadValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"mode", []string{"--mode", "prod"}, "mode:"},
+		{"storage", []string{"--storage", "postgres"}, "storage.backend:"},
+		{"compact threshold", []string{"--storage", "journal", "--compact-threshold", "-1"}, "storage.compact_threshold:"},
+		{"payment provider", []string{"--payment-provider", "stripe"}, "payment.provider:"},
+		{"payment timeout", []string{"--payment-timeout", "0s"}, "payment.timeout:"},
+		{"shipping carrier", []string{"--shipping-carrier", "pigeon"}, "shipping.carrier:"},
+		{"warehouse allocation", []string{"--warehouse-allocation", "random"}, "warehouses.allocation:"},
+		{"cors", []string{"--cors-origins", "shop.example.com"}, "cors.origins:"},
+		{"trusted proxies", []string{"--trusted-proxies", "10.0.0.1,proxy.internal"}, "proxies.trusted:"},
+		{"rate limit group", []string{"--rate-limits", "likes=1/1s"}, "unknown group"},
+		{"duration", []string{"--idle-timeout", "soon"}, "invalid duration"},
+		{"fixture set", []string{"--seed-set", "huge"}, "fixtures.set:"},
+		{"fixture size", []string{"--seed-set", "load-test", "--seed-size", "0"}, "fixtures.size:"},
+		{"unknown key", []string{"--config", "unknown.yaml"}, "field port not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if args[0] == "--config" {
+				args = []string{"--config", writeFile(t, args[1], "port: 80\n")}
+			}
+			_, err := Load("test", Defaults(testLimits), args)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
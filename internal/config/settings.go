@@ -0,0 +1,164 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"e-commerce/internal/ratelimit"
+)
+
+const envConfigFile = "CONFIG_FILE"
+
+// setting adalah satu nilai yang bisa diisi dari environment dan flag.
+// Nilai dari file dibaca langsung ke struct Config.
+type setting struct {
+	env    string
+	flag   string
+	usage  string
+	isBool bool
+	set    func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{env: "LISTEN_ADDR", flag: "addr", usage: "listen address such as :8080",
+		set: func(c *Config, v string) error { c.Addr = v; return nil }},
+	{env: "GIN_MODE", flag: "mode", usage: "gin mode: debug, release or test",
+		set: func(c *Config, v string) error { c.Mode = v; return nil }},
+	{env: "STORAGE_BACKEND", flag: "storage", usage: "storage backend: memory, snapshot or journal",
+		set: func(c *Config, v string) error { c.Storage.Backend = v; return nil }},
+	{env: "STORAGE_PATH", flag: "storage-path", usage: "snapshot file for the snapshot backend, directory for the journal backend",
+		set: func(c *Config, v string) error { c.Storage.Path = v; return nil }},
+	{env: "SNAPSHOT_INTERVAL", flag: "snapshot-interval", usage: "how often the snapshot backend saves, 0 saves only on shutdown",
+		set: func(c *Config, v string) error { return setDuration(&c.Storage.SnapshotInterval, v) }},
+	{env: "COMPACT_THRESHOLD", flag: "compact-threshold", usage: "journal entries before the journal backend compacts into a snapshot, 0 disables",
+		set: func(c *Config, v string) error {
+			threshold, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", v)
+			}
+			c.Storage.CompactThreshold = threshold
+			return nil
+		}},
+	{env: "OUTBOX_DIR", flag: "outbox-dir", usage: "directory for the durable event outbox, empty keeps it in memory",
+		set: func(c *Config, v string) error { c.Outbox.Dir = v; return nil }},
+	{env: "PAYMENT_PROVIDER", flag: "payment-provider", usage: "payment provider: fake",
+		set: func(c *Config, v string) error { c.Payment.Provider = v; return nil }},
+	{env: "PAYMENT_TIMEOUT", flag: "payment-timeout", usage: "maximum duration to authorize and capture a payment",
+		set: func(c *Config, v string) error { return setDuration(&c.Payment.Timeout, v) }},
+	{env: "SHIPPING_CARRIER", flag: "shipping-carrier", usage: "shipping carrier: fake",
+		set: func(c *Config, v string) error { c.Shipping.Carrier = v; return nil }},
+	{env: "WAREHOUSE_ALLOCATION", flag: "warehouse-allocation", usage: "warehouse allocation rule: most_stock or nearest",
+		set: func(c *Config, v string) error { c.Warehouses.Allocation = v; return nil }},
+	{env: "SEED_DATA", flag: "seed", usage: "load sample data on startup", isBool: true,
+		set: func(c *Config, v string) error {
+			seed, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", v)
+			}
+			c.Seed = seed
+			return nil
+		}},
+	{env: "SEED_SET", flag: "seed-set", usage: "fixture set to seed: demo, empty or load-test",
+		set: func(c *Config, v string) error { c.Fixtures.Set = v; return nil }},
+	{env: "SEED_FILE", flag: "seed-file", usage: "JSON or YAML fixture file to seed instead of a fixture set",
+		set: func(c *Config, v string) error { c.Fixtures.File = v; return nil }},
+	{env: "SEED_SIZE", flag: "seed-size", usage: "number of items generated by the load-test fixture set",
+		set: func(c *Config, v string) error {
+			size, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", v)
+			}
+			c.Fixtures.Size = size
+			return nil
+		}},
+	{env: "CORS_ORIGINS", flag: "cors-origins", usage: "comma separated allowed origins, * for any",
+		set: func(c *Config, v string) error { c.CORS.Origins = splitList(v); return nil }},
+	{env: "TRUSTED_PROXIES", flag: "trusted-proxies", usage: "comma separated proxy IPs or CIDRs whose X-Forwarded-For is trusted, empty trusts none",
+		set: func(c *Config, v string) error { c.Proxies.Trusted = splitList(v); return nil }},
+	{env: "READ_TIMEOUT", flag: "read-timeout", usage: "maximum duration for reading a request",
+		set: func(c *Config, v string) error { return setDuration(&c.Timeouts.Read, v) }},
+	{env: "READ_HEADER_TIMEOUT", flag: "read-header-timeout", usage: "maximum duration for reading request headers",
+		set: func(c *Config, v string) error { return setDuration(&c.Timeouts.ReadHeader, v) }},
+	{env: "WRITE_TIMEOUT", flag: "write-timeout", usage: "maximum duration for writing a response",
+		set: func(c *Config, v string) error { return setDuration(&c.Timeouts.Write, v) }},
+	{env: "IDLE_TIMEOUT", flag: "idle-timeout", usage: "maximum keep-alive idle duration",
+		set: func(c *Config, v string) error { return setDuration(&c.Timeouts.Idle, v) }},
+	{env: "SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "maximum duration to drain in-flight requests on shutdown, and again for shutdown hooks",
+		set: func(c *Config, v string) error { return setDuration(&c.Timeouts.Shutdown, v) }},
+	{env: "TLS_CERT_FILE", flag: "tls-cert", usage: "TLS certificate file, enables HTTPS together with --tls-key",
+		set: func(c *Config, v string) error { c.TLS.CertFile = v; return nil }},
+	{env: "TLS_KEY_FILE", flag: "tls-key", usage: "TLS private key file",
+		set: func(c *Config, v string) error { c.TLS.KeyFile = v; return nil }},
+	{env: "LOG_LEVEL", flag: "log-level", usage: "log level: debug, info, warn or error",
+		set: func(c *Config, v string) error { c.Log.Level = v; return nil }},
+	{env: "LOG_FORMAT", flag: "log-format", usage: "log format: json or text",
+		set: func(c *Config, v string) error { c.Log.Format = v; return nil }},
+	{env: "RATE_LIMITS", flag: "rate-limits", usage: "rate limit overrides such as default=600/1m,auth=5/1m",
+		set: func(c *Config, v string) error {
+			rules, err := ratelimit.Override(c.RateLimits, v)
+			if err != nil {
+				return err
+			}
+			c.RateLimits = rules
+			return nil
+		}},
+}
+
+func setDuration(d *Duration, value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", value)
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// flagValue mencatat apakah flag benar-benar diberikan, supaya flag yang
+// tidak dipakai tidak menimpa nilai dari env atau file
+type flagValue struct {
+	value  string
+	set    bool
+	isBool bool
+}
+
+func (v *flagValue) String() string   { return v.value }
+func (v *flagValue) IsBoolFlag() bool { return v.isBool }
+
+func (v *flagValue) Set(value string) error {
+	v.value = value
+	v.set = true
+	return nil
+}
+
+type flagValues struct {
+	configFile  string
+	printConfig bool
+	values      map[string]*flagValue
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *flagValues) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags := &flagValues{values: map[string]*flagValue{}}
+
+	fs.StringVar(&flags.configFile, "config", "", "path to a YAML or TOML config file (env "+envConfigFile+")")
+	fs.BoolVar(&flags.printConfig, "print-config", false, "print the effective configuration and exit")
+	for _, s := range settings {
+		value := &flagValue{isBool: s.isBool}
+		flags.values[s.flag] = value
+		fs.Var(value, s.flag, s.usage+" (env "+s.env+")")
+	}
+	return fs, flags
+}
@@ -0,0 +1,61 @@
+// Package cors berisi middleware CORS sederhana untuk origin yang
+// dikonfigurasi.
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	allowMethods  = strings.Join([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}, ", ")
+	allowHeaders  = strings.Join([]string{"Authorization", "Content-Type", "X-API-Key", "X-Request-ID", "Last-Event-ID"}, ", ")
+	exposeHeaders = strings.Join([]string{"RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "X-Request-ID"}, ", ")
+	maxAge        = strconv.Itoa(int((12 * time.Hour).Seconds()))
+)
+
+// Middleware mengizinkan request dari origins. "*" mengizinkan semua
+// origin. Tanpa origin, middleware tidak melakukan apa-apa.
+func Middleware(origins []string) gin.HandlerFunc {
+	allowed := map[string]bool{}
+	allowAll := false
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if len(allowed) == 0 || origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		if !allowAll && !allowed[origin] {
+			// Browser yang akan menolak response karena header CORS tidak ada
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Expose-Headers", exposeHeaders)
+
+		// Preflight dijawab langsung tanpa lewat auth dan rate limit
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			h.Set("Access-Control-Allow-Headers", allowHeaders)
+			h.Set("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,443 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"e-commerce/internal/requestid"
+)
+
+// Handler memproses satu event. Consumer yang mengembalikan error akan
+// dipanggil ulang dengan event yang sama.
+type Handler func(ctx context.Context, e Event) error
+
+type Options struct {
+	// BatchSize adalah jumlah event yang dibaca consumer sekaligus,
+	// checkpoint disimpan setelah setiap batch. Default 100.
+	BatchSize int
+	// PollInterval adalah interval consumer mengecek outbox kalau tidak
+	// dibangunkan oleh Commit. Default 1 detik.
+	PollInterval time.Duration
+	// Backoff sebelum mencoba ulang handler yang gagal, berlipat dua
+	// sampai MaxBackoff. Default 1 detik dan 1 menit.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// TrimThreshold adalah jumlah event yang sudah diproses semua consumer
+	// sebelum outbox di-trim. Default 1000.
+	TrimThreshold int64
+	// Journal opsional, menerima semua event sebelum outbox. Dipakai untuk
+	// log permanen yang di-replay saat start.
+	Journal Journal
+	// Logger default slog.Default()
+	Logger *slog.Logger
+}
+
+// Journal adalah log permanen di samping outbox. Berbeda dengan outbox,
+// event di journal tidak dibuang setelah diproses consumer.
+type Journal interface {
+	Append(events []Event) error
+	// Rollback membuang Append terakhir, dipanggil kalau outbox gagal
+	// ditulis setelah journal berhasil
+	Rollback() error
+}
+
+func (o Options) withDefaults() Options {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Minute
+	}
+	if o.TrimThreshold <= 0 {
+		o.TrimThreshold = 1000
+	}
+	return o
+}
+
+type consumer struct {
+	name    string
+	handler Handler
+	wake    chan struct{}
+
+	// acked adalah checkpoint terakhir, dibaca untuk menentukan trim
+	acked int64
+}
+
+// Bus mencatat event ke outbox dan meneruskannya ke subscriber dan consumer
+type Bus struct {
+	store Store
+	opts  Options
+
+	// commitMu menjaga urutan Seq sama dengan urutan perubahan state
+	commitMu sync.Mutex
+
+	mu          sync.Mutex
+	subscribers map[string][]Handler
+	consumers   []*consumer
+	trimmed     int64
+	started     bool
+	closed      bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewBus(store Store, opts Options) *Bus {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Bus{
+		store:       store,
+		opts:        opts.withDefaults(),
+		subscribers: make(map[string][]Handler),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+func (b *Bus) logger() *slog.Logger {
+	if b.opts.Logger != nil {
+		return b.opts.Logger
+	}
+	return slog.Default()
+}
+
+// Subscribe mendaftarkan handler yang dipanggil langsung di goroutine
+// yang melakukan Commit, setelah state berubah. Handler boleh memanggil
+// Commit lagi untuk cascade. Error hanya dicatat di log karena event
+// sudah tersimpan.
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[eventType] = append(b.subscribers[eventType], handler)
+}
+
+// Consume mendaftarkan consumer durable yang menerima semua event
+// berurutan mulai dari checkpoint-nya. Harus dipanggil sebelum Start.
+// Karena delivery at-least-once, handler harus aman menerima event yang
+// sama lebih dari sekali (gunakan Event.ID).
+func (b *Bus) Consume(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started {
+		panic("events: Consume called after Start")
+	}
+	b.consumers = append(b.consumers, &consumer{name: name, handler: handler, wake: make(chan struct{}, 1)})
+}
+
+// Start menjalankan semua consumer. Event yang belum diproses sebelum
+// restart langsung dikirim ulang.
+func (b *Bus) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.started {
+		return nil
+	}
+	b.started = true
+
+	for _, c := range b.consumers {
+		seq, err := b.store.Checkpoint(c.name)
+		if err != nil {
+			return err
+		}
+		c.acked = seq
+		b.wg.Add(1)
+		go b.run(c)
+	}
+	return nil
+}
+
+// Commit meng-encode messages, menyimpannya ke outbox lalu menjalankan
+// apply. Kalau outbox gagal ditulis, apply tidak dijalankan sehingga state
+// dan outbox tidak pernah berbeda. apply tidak boleh memanggil Commit.
+func (b *Bus) Commit(ctx context.Context, apply func(), messages ...Message) ([]Event, error) {
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+	pending, err := encode(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	b.commitMu.Lock()
+	stored, err := b.commitLocked(pending, apply)
+	b.commitMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	b.publish(ctx, stored)
+	return stored, nil
+}
+
+// CommitFunc seperti Commit, tapi apply dan messages dibuat oleh prepare
+// di antara dua commit. Dipakai kalau perubahan bergantung pada state saat
+// ini, misalnya cek stock lalu kurangi, supaya tidak ada commit lain di
+// antara pengecekan dan perubahan. Kalau prepare mengembalikan error,
+// tidak ada yang ditulis dan error-nya dikembalikan apa adanya. prepare
+// tidak boleh memanggil Commit atau View.
+func (b *Bus) CommitFunc(ctx context.Context, prepare func() (apply func(), messages []Message, err error)) ([]Event, error) {
+	if err := b.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	b.commitMu.Lock()
+	apply, messages, err := prepare()
+	var stored []Event
+	if err == nil {
+		var pending []Event
+		if pending, err = encode(ctx, messages); err == nil {
+			stored, err = b.commitLocked(pending, apply)
+		}
+	}
+	b.commitMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	b.publish(ctx, stored)
+	return stored, nil
+}
+
+func (b *Bus) checkOpen() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	return nil
+}
+
+func encode(ctx context.Context, messages []Message) ([]Event, error) {
+	pending := make([]Event, len(messages))
+	now := time.Now().UTC()
+	requestID := requestid.FromContext(ctx)
+	for i, m := range messages {
+		data, err := json.Marshal(m.Data)
+		if err != nil {
+			return nil, err
+		}
+		pending[i] = Event{Type: m.Type, OccurredAt: now, RequestID: requestID, Data: data}
+	}
+	return pending, nil
+}
+
+// commitLocked harus dipanggil dengan commitMu
+func (b *Bus) commitLocked(pending []Event, apply func()) ([]Event, error) {
+	var stored []Event
+	if len(pending) > 0 {
+		var err error
+		if stored, err = b.appendLocked(pending); err != nil {
+			return nil, err
+		}
+	}
+	if apply != nil {
+		apply()
+	}
+	return stored, nil
+}
+
+// publish meneruskan event ke subscriber dan membangunkan consumer. Harus
+// dipanggil tanpa commitMu karena subscriber boleh memanggil Commit.
+func (b *Bus) publish(ctx context.Context, stored []Event) {
+	for _, e := range stored {
+		b.dispatch(ctx, e)
+	}
+	b.notify()
+}
+
+// appendLocked menulis ke journal lalu outbox. Kalau outbox gagal, event
+// dibuang lagi dari journal supaya replay tidak menerapkan perubahan yang
+// tidak pernah terjadi.
+func (b *Bus) appendLocked(pending []Event) ([]Event, error) {
+	journal := b.opts.Journal
+	if journal != nil {
+		if err := journal.Append(pending); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := b.store.Append(pending)
+	if err != nil && journal != nil {
+		if rollbackErr := journal.Rollback(); rollbackErr != nil {
+			b.logger().Error("Could not roll back journal", "error", rollbackErr)
+		}
+	}
+	return stored, err
+}
+
+// View menjalankan f di antara dua Commit, jadi f melihat state yang
+// konsisten dengan outbox. Dipakai untuk membaca seluruh state sekaligus,
+// misalnya snapshot. f tidak boleh memanggil Commit.
+func (b *Bus) View(f func()) {
+	b.commitMu.Lock()
+	defer b.commitMu.Unlock()
+	f()
+}
+
+func (b *Bus) dispatch(ctx context.Context, e Event) {
+	b.mu.Lock()
+	handlers := append([]Handler(nil), b.subscribers[e.Type]...)
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, e); err != nil {
+			b.logger().Error("Event subscriber failed", "event_id", e.ID, "type", e.Type, "error", err)
+		}
+	}
+}
+
+func (b *Bus) notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, c := range b.consumers {
+		select {
+		case c.wake <- struct{}{}:
+		default:
+		}
+	}
+	if len(b.consumers) == 0 {
+		b.maybeTrimLocked()
+	}
+}
+
+func (b *Bus) run(c *consumer) {
+	defer b.wg.Done()
+
+	log := b.logger().With("consumer", c.name)
+	after := c.acked
+	for {
+		batch, err := b.store.Read(after, b.opts.BatchSize)
+		if err != nil {
+			log.Error("Could not read outbox", "error", err)
+		}
+
+		stopped := false
+		for _, e := range batch {
+			if !b.handle(c, log, e) {
+				stopped = true
+				break
+			}
+			after = e.Seq
+		}
+
+		if after > c.acked {
+			if err := b.store.SaveCheckpoint(c.name, after); err != nil {
+				log.Error("Could not save checkpoint", "seq", after, "error", err)
+			}
+			b.mu.Lock()
+			c.acked = after
+			b.maybeTrimLocked()
+			b.mu.Unlock()
+		}
+		if stopped {
+			return
+		}
+		if len(batch) == b.opts.BatchSize {
+			continue
+		}
+
+		select {
+		case <-c.wake:
+		case <-time.After(b.opts.PollInterval):
+		case <-b.ctx.Done():
+			return
+		}
+	}
+}
+
+// handle memanggil handler sampai berhasil. false berarti bus ditutup
+// sebelum event berhasil diproses; event akan dikirim ulang setelah restart.
+func (b *Bus) handle(c *consumer, log *slog.Logger, e Event) bool {
+	backoff := b.opts.Backoff
+	for attempt := 1; ; attempt++ {
+		err := c.handler(b.ctx, e)
+		if err == nil {
+			return true
+		}
+		if b.ctx.Err() != nil {
+			return false
+		}
+		log.Warn("Event consumer failed, retrying", "event_id", e.ID, "type", e.Type, "attempt", attempt, "retry_in", backoff.String(), "error", err)
+
+		select {
+		case <-time.After(backoff):
+		case <-b.ctx.Done():
+			return false
+		}
+		backoff *= 2
+		if backoff > b.opts.MaxBackoff {
+			backoff = b.opts.MaxBackoff
+		}
+	}
+}
+
+// maybeTrimLocked membuang event yang sudah diproses semua consumer.
+// Tanpa consumer, semua event dianggap sudah diproses.
+func (b *Bus) maybeTrimLocked() {
+	upTo := b.store.LastSeq()
+	for _, c := range b.consumers {
+		if c.acked < upTo {
+			upTo = c.acked
+		}
+	}
+	if upTo-b.trimmed < b.opts.TrimThreshold {
+		return
+	}
+	if err := b.store.Trim(upTo); err != nil {
+		b.logger().Error("Could not trim outbox", "error", err)
+		return
+	}
+	b.trimmed = upTo
+}
+
+// Lag mengembalikan jumlah event yang belum diproses setiap consumer
+func (b *Bus) Lag() map[string]int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	last := b.store.LastSeq()
+	lag := make(map[string]int64, len(b.consumers))
+	for _, c := range b.consumers {
+		lag[c.name] = last - c.acked
+	}
+	return lag
+}
+
+// Close menghentikan consumer, menunggu handler yang sedang berjalan
+// selesai lalu menutup store. Event yang belum di-checkpoint dikirim ulang
+// setelah restart.
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	b.cancel()
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Tunggu Commit yang sedang berjalan sebelum store ditutup
+	b.commitMu.Lock()
+	defer b.commitMu.Unlock()
+	return b.store.Close()
+}
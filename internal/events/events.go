@@ -0,0 +1,42 @@
+// Package events adalah event bus in-process dengan transactional outbox.
+// Handler mencatat event lewat Bus.Commit bersamaan dengan perubahan state.
+// Subscriber dipanggil langsung setelah commit untuk efek samping di dalam
+// proses (misalnya cascade delete), sedangkan consumer membaca outbox secara
+// async dengan checkpoint sehingga setiap event diterima minimal sekali,
+// termasuk setelah restart.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var ErrClosed = errors.New("event bus is closed")
+
+// Event adalah satu perubahan yang sudah tercatat di outbox. Seq naik terus
+// dan menentukan urutan; ID stabil sehingga consumer bisa membuang duplikat.
+type Event struct {
+	Seq        int64           `json:"seq"`
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Decode membaca Data ke v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Message adalah event yang akan di-commit. Data di-encode ke JSON.
+type Message struct {
+	Type string
+	Data interface{}
+}
+
+func eventID(seq int64) string {
+	return "evt_" + strconv.FormatInt(seq, 10)
+}
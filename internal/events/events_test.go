@@ -0,0 +1,282 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder adalah consumer yang mencatat event dan gagal sekali untuk
+// event dengan tipe failOnce
+type recorder struct {
+	mu       sync.Mutex
+	seen     []string
+	failOnce string
+	failed   bool
+}
+
+func (r *recorder) handle(ctx context.Context, e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if e.Type == r.failOnce && !r.failed {
+		r.failed = true
+		return errors.New("temporary failure")
+	}
+	r.seen = append(r.seen, e.ID)
+	return nil
+}
+
+func (r *recorder) waitFor(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		seen := append([]string(nil), r.seen...)
+		r.mu.Unlock()
+		if len(seen) >= n {
+			return seen
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("consumer saw %v, want %d events", r.seen, n)
+	return nil
+}
+
+func testOptions() Options {
+	return Options{PollInterval: 10 * time.Millisecond, Backoff: time.Millisecond}
+}
+
+func TestCommitRunsSubscribersAndCascades(t *testing.T) {
+	bus := NewBus(NewMemoryStore(), testOptions())
+	defer bus.Close(context.Background())
+
+	var order []string
+	bus.Subscribe("user.deleted", func(ctx context.Context, e Event) error {
+		var userID string
+		if err := e.Decode(&userID); err != nil {
+			return err
+		}
+		order = append(order, "cascade "+userID)
+		_, err := bus.Commit(ctx, nil, Message{Type: "post.deleted", Data: "p1"})
+		return err
+	})
+	bus.Subscribe("post.deleted", func(ctx context.Context, e Event) error {
+		order = append(order, "post "+e.ID)
+		return nil
+	})
+
+	stored, err := bus.Commit(context.Background(), func() { order = append(order, "apply") }, Message{Type: "user.deleted", Data: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || stored[0].Seq != 1 || stored[0].ID != "evt_1" {
+		t.Fatalf("stored %+v", stored)
+	}
+
+	want := []string{"apply", "cascade u1", "post evt_2"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+// failingStore gagal menulis outbox
+type failingStore struct{ *MemoryStore }
+
+func (failingStore) Append([]Event) ([]Event, error) { return nil, errors.New("disk full") }
+
+func TestCommitDoesNotApplyWhenOutboxFails(t *testing.T) {
+	bus := NewBus(failingStore{NewMemoryStore()}, testOptions())
+	applied := false
+	if _, err := bus.Commit(context.Background(), func() { applied = true }, Message{Type: "x", Data: 1}); err == nil {
+		t.Fatal("expected error")
+	}
+	if applied {
+		t.Error("state changed although the outbox write failed")
+	}
+}
+
+func TestCommitFuncChecksAndAppliesAtomically(t *testing.T) {
+	bus := NewBus(NewMemoryStore(), testOptions())
+	defer bus.Close(context.Background())
+
+	// Dua goroutine mengurangi stock yang hanya cukup untuk satu
+	stock := 1
+	errSoldOut := errors.New("sold out")
+	var wg sync.WaitGroup
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := bus.CommitFunc(context.Background(), func() (func(), []Message, error) {
+				if stock < 1 {
+					return nil, nil, errSoldOut
+				}
+				return func() { stock-- }, []Message{{Type: "stock.changed", Data: stock - 1}}, nil
+			})
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var soldOut int
+	for err := range results {
+		if errors.Is(err, errSoldOut) {
+			soldOut++
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if stock != 0 || soldOut != 1 {
+		t.Errorf("stock = %d, %d rejected; want 0 and 1", stock, soldOut)
+	}
+	if seq := bus.store.LastSeq(); seq != 1 {
+		t.Errorf("outbox last seq = %d, want 1", seq)
+	}
+}
+
+func TestConsumerRetriesAndResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := NewBus(store, testOptions())
+	first := &recorder{failOnce: "b"}
+	bus.Consume("test", first.handle)
+	if err := bus.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range []string{"a", "b"} {
+		if _, err := bus.Commit(context.Background(), nil, Message{Type: typ, Data: typ}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if seen := first.waitFor(t, 2); seen[1] != "evt_2" {
+		t.Fatalf("seen %v", seen)
+	}
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Event ketiga tercatat saat consumer tidak berjalan
+	store, err = OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append([]Event{{Type: "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// Simulasi crash di tengah penulisan baris berikutnya
+	f, err := os.OpenFile(filepath.Join(dir, logFile), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":4,"id":"evt_4","ty`)
+	f.Close()
+
+	store, err = OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus = NewBus(store, testOptions())
+	second := &recorder{}
+	bus.Consume("test", second.handle)
+	if err := bus.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer bus.Close(context.Background())
+
+	if seen := second.waitFor(t, 1); len(seen) != 1 || seen[0] != "evt_3" {
+		t.Fatalf("after restart consumer saw %v, want only evt_3", seen)
+	}
+	stored, err := bus.Commit(context.Background(), nil, Message{Type: "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored[0].ID != "evt_4" {
+		t.Errorf("truncated event was not discarded, next id %s", stored[0].ID)
+	}
+}
+
+func TestTrimKeepsSequence(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append([]Event{{Type: "a"}, {Type: "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Trim(2); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = OpenFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if events, _ := store.Read(0, 0); len(events) != 0 {
+		t.Errorf("trimmed events still present: %+v", events)
+	}
+	stored, err := store.Append([]Event{{Type: "c"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored[0].Seq != 3 {
+		t.Errorf("seq after trim = %d, want 3", stored[0].Seq)
+	}
+}
+
+// memoryJournal mencatat Append dan Rollback
+type memoryJournal struct {
+	entries []string
+	undo    int
+}
+
+func (j *memoryJournal) Append(events []Event) error {
+	j.undo = len(j.entries)
+	for _, e := range events {
+		j.entries = append(j.entries, e.Type)
+	}
+	return nil
+}
+
+func (j *memoryJournal) Rollback() error {
+	j.entries = j.entries[:j.undo]
+	return nil
+}
+
+func TestCommitRollsBackJournalWhenOutboxFails(t *testing.T) {
+	journal := &memoryJournal{}
+	opts := testOptions()
+	opts.Journal = journal
+
+	bus := NewBus(NewMemoryStore(), opts)
+	if _, err := bus.Commit(context.Background(), nil, Message{Type: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	bus = NewBus(failingStore{NewMemoryStore()}, opts)
+	if _, err := bus.Commit(context.Background(), nil, Message{Type: "b"}); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(journal.entries) != 1 || journal.entries[0] != "a" {
+		t.Errorf("journal = %v, want [a]", journal.entries)
+	}
+}
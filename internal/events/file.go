@@ -0,0 +1,271 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	logFile   = "outbox.jsonl"
+	stateFile = "state.json"
+)
+
+// FileStore menyimpan outbox sebagai JSON lines di dir. Setiap Append
+// di-fsync sebelum kembali, checkpoint ditulis atomic lewat file sementara
+// lalu rename.
+type FileStore struct {
+	dir string
+
+	mu   sync.Mutex
+	file *os.File
+	mem  *MemoryStore
+}
+
+// state.json menyimpan checkpoint dan Seq terakhir supaya Seq tidak
+// terpakai ulang setelah outbox di-trim habis
+type fileState struct {
+	LastSeq     int64            `json:"last_seq"`
+	Checkpoints map[string]int64 `json:"checkpoints"`
+}
+
+// OpenFileStore membuka atau membuat outbox di dir. Baris terakhir yang
+// terpotong (misalnya karena crash saat menulis) dibuang.
+func OpenFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &FileStore{dir: dir, mem: NewMemoryStore()}
+	if err := s.loadState(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, logFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadLog(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+func (s *FileStore) loadState() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, stateFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var state fileState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("%s: %w", stateFile, err)
+	}
+	s.mem.lastSeq = state.LastSeq
+	for consumer, seq := range state.Checkpoints {
+		s.mem.checkpoints[consumer] = seq
+	}
+	return nil
+}
+
+func (s *FileStore) loadLog(file *os.File) error {
+	reader := bufio.NewReader(file)
+	var offset int64
+	for line := 1; ; line++ {
+		raw, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(raw) > 0 {
+				// Baris terakhir tanpa newline belum selesai ditulis
+				return s.truncate(file, offset)
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var e Event
+		if err := json.Unmarshal(raw, &e); err != nil {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				return s.truncate(file, offset)
+			}
+			return fmt.Errorf("%s line %d: %w", logFile, line, err)
+		}
+		s.mem.events = append(s.mem.events, e)
+		if e.Seq > s.mem.lastSeq {
+			s.mem.lastSeq = e.Seq
+		}
+		offset += int64(len(raw))
+	}
+
+	_, err := file.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (s *FileStore) truncate(file *os.File, size int64) error {
+	if err := file.Truncate(size); err != nil {
+		return err
+	}
+	_, err := file.Seek(size, io.SeekStart)
+	return err
+}
+
+func (s *FileStore) Append(events []Event) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil, ErrClosed
+	}
+
+	// Seq dihitung dulu, memory baru diubah setelah file berhasil di-sync
+	next := s.mem.LastSeq()
+	var buf bytes.Buffer
+	for _, e := range events {
+		next++
+		e.Seq = next
+		e.ID = eventID(next)
+		line, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	size, err := s.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.file.Write(buf.Bytes()); err != nil {
+		s.truncate(s.file, size)
+		return nil, err
+	}
+	if err := s.file.Sync(); err != nil {
+		s.truncate(s.file, size)
+		return nil, err
+	}
+	return s.mem.Append(events)
+}
+
+func (s *FileStore) Read(after int64, limit int) ([]Event, error) {
+	return s.mem.Read(after, limit)
+}
+
+func (s *FileStore) LastSeq() int64 {
+	return s.mem.LastSeq()
+}
+
+func (s *FileStore) Checkpoint(consumer string) (int64, error) {
+	return s.mem.Checkpoint(consumer)
+}
+
+func (s *FileStore) SaveCheckpoint(consumer string, seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.mem.SaveCheckpoint(consumer, seq)
+	return s.writeStateLocked()
+}
+
+func (s *FileStore) writeStateLocked() error {
+	s.mem.mu.Lock()
+	state := fileState{LastSeq: s.mem.lastSeq, Checkpoints: make(map[string]int64, len(s.mem.checkpoints))}
+	for consumer, seq := range s.mem.checkpoints {
+		state.Checkpoints[consumer] = seq
+	}
+	s.mem.mu.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.dir, stateFile), data)
+}
+
+// Trim menulis ulang outbox tanpa event yang sudah diproses semua consumer
+func (s *FileStore) Trim(upTo int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return ErrClosed
+	}
+	// Seq terakhir disimpan dulu supaya tidak hilang kalau outbox kosong
+	if err := s.writeStateLocked(); err != nil {
+		return err
+	}
+
+	s.mem.Trim(upTo)
+	remaining, _ := s.mem.Read(upTo, 0)
+	var buf bytes.Buffer
+	for _, e := range remaining {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	path := filepath.Join(s.dir, logFile)
+	if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return err
+	}
+	s.file.Close()
+	s.file = file
+	return nil
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// writeFileAtomic menulis ke file sementara di folder yang sama lalu
+// rename, jadi pembaca tidak pernah melihat file setengah jadi
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
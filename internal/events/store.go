@@ -0,0 +1,110 @@
+package events
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Store menyimpan outbox dan checkpoint setiap consumer
+type Store interface {
+	// Append memberi Seq dan ID lalu menyimpan semua event sekaligus.
+	// Kalau gagal tidak ada event yang tersimpan.
+	Append(events []Event) ([]Event, error)
+	// Read mengembalikan maksimal limit event dengan Seq > after
+	Read(after int64, limit int) ([]Event, error)
+	// LastSeq adalah Seq event terakhir yang pernah di-append
+	LastSeq() int64
+	Checkpoint(consumer string) (int64, error)
+	SaveCheckpoint(consumer string, seq int64) error
+	// Trim membuang event dengan Seq <= upTo
+	Trim(upTo int64) error
+	Close() error
+}
+
+// MemoryStore menyimpan outbox di memory, hilang saat restart
+type MemoryStore struct {
+	mu          sync.Mutex
+	events      []Event
+	lastSeq     int64
+	checkpoints map[string]int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{checkpoints: make(map[string]int64)}
+}
+
+func (s *MemoryStore) Append(events []Event) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.appendLocked(events), nil
+}
+
+func (s *MemoryStore) appendLocked(events []Event) []Event {
+	stored := make([]Event, len(events))
+	for i, e := range events {
+		s.lastSeq++
+		e.Seq = s.lastSeq
+		e.ID = eventID(e.Seq)
+		stored[i] = e
+	}
+	s.events = append(s.events, stored...)
+	return stored
+}
+
+func (s *MemoryStore) Read(after int64, limit int) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := sort.Search(len(s.events), func(i int) bool { return s.events[i].Seq > after })
+	end := len(s.events)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+	return append([]Event(nil), s.events[start:end]...), nil
+}
+
+func (s *MemoryStore) LastSeq() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastSeq
+}
+
+func (s *MemoryStore) Checkpoint(consumer string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoints[consumer], nil
+}
+
+func (s *MemoryStore) SaveCheckpoint(consumer string, seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[consumer] = seq
+	return nil
+}
+
+func (s *MemoryStore) Trim(upTo int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trimLocked(upTo)
+	return nil
+}
+
+func (s *MemoryStore) trimLocked(upTo int64) {
+	n := sort.Search(len(s.events), func(i int) bool { return s.events[i].Seq > upTo })
+	s.events = append([]Event(nil), s.events[n:]...)
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// Open membuka outbox milik server name di dir/name. Dir kosong berarti
+// outbox di memory.
+func Open(dir, name string) (Store, error) {
+	if dir == "" {
+		return NewMemoryStore(), nil
+	}
+	return OpenFileStore(filepath.Join(dir, name))
+}
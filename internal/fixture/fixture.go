@@ -0,0 +1,138 @@
+// Package fixture membaca data awal (seed) dari file JSON atau YAML.
+// Struktur data dan aturan validasinya ditentukan masing-masing server;
+// package ini hanya menyediakan decoding, pengumpulan error dan generator
+// data acak untuk load test.
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"e-commerce/internal/apierror"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Set fixture bawaan. Demo dan empty dibaca dari file yang di-embed di
+// server, load-test dibuat oleh generator.
+const (
+	SetDemo     = "demo"
+	SetEmpty    = "empty"
+	SetLoadTest = "load-test"
+)
+
+var Sets = []string{SetDemo, SetEmpty, SetLoadTest}
+
+// Load membaca file fixture dari disk
+func Load(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read fixture: %w", err)
+	}
+	return Decode(path, data, v)
+}
+
+// LoadFS membaca file fixture dari fs, biasanya embed.FS
+func LoadFS(fsys fs.FS, name string, v interface{}) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("read fixture: %w", err)
+	}
+	return Decode(name, data, v)
+}
+
+// Decode memilih format dari ekstensi name (.json, .yaml atau .yml). YAML
+// diubah ke JSON lebih dulu supaya struct cukup punya tag json, sama dengan
+// body request. Key yang tidak dikenal dianggap error supaya salah ketik
+// tidak diam-diam diabaikan.
+func Decode(name string, data []byte, v interface{}) error {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+	case ".yaml", ".yml":
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("parse %s: %w", name, err)
+		}
+		converted, err := json.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", name, err)
+		}
+		data = converted
+	default:
+		return fmt.Errorf("fixture file %s must end in .json, .yaml or .yml", name)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// Errors mengumpulkan semua kesalahan di fixture supaya bisa diperbaiki
+// sekaligus, bukan satu per satu setiap restart
+type Errors struct {
+	errs []string
+}
+
+// Add mencatat error validasi untuk item, contoh path "products[3]"
+func (e *Errors) Add(path string, fields ...apierror.FieldError) {
+	for _, f := range fields {
+		e.errs = append(e.errs, fmt.Sprintf("%s.%s: %s", path, f.Field, f.Message))
+	}
+}
+
+func (e *Errors) Addf(path, format string, args ...interface{}) {
+	e.errs = append(e.errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+// Err mengembalikan nil kalau tidak ada error. Daftar error dibatasi
+// supaya log tetap terbaca untuk fixture besar.
+func (e *Errors) Err() error {
+	const limit = 20
+	if len(e.errs) == 0 {
+		return nil
+	}
+	errs := e.errs
+	suffix := ""
+	if len(errs) > limit {
+		suffix = fmt.Sprintf("\n... and %d more", len(errs)-limit)
+		errs = errs[:limit]
+	}
+	return fmt.Errorf("invalid fixture:\n%s%s", strings.Join(errs, "\n"), suffix)
+}
+
+// IDs mencatat ID yang sudah dipakai untuk cek duplikat dan referensi
+type IDs map[string]bool
+
+// Add mengembalikan false kalau id sudah ada
+func (ids IDs) Add(id string) bool {
+	if ids[id] {
+		return false
+	}
+	ids[id] = true
+	return true
+}
+
+// NextID mengembalikan ID numerik berikutnya setelah semua ID di fixture,
+// supaya ID yang dibuat server tidak bentrok. ID non-numerik diabaikan.
+func NextID(ids ...IDs) int {
+	highest := 0
+	for _, set := range ids {
+		for id := range set {
+			if n, err := strconv.Atoi(id); err == nil && n > highest {
+				highest = n
+			}
+		}
+	}
+	return highest + 1
+}
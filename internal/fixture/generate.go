@@ -0,0 +1,118 @@
+package fixture
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// Generator membuat data acak yang tampak nyata untuk load test. Seed yang
+// sama selalu menghasilkan data yang sama supaya hasil benchmark bisa
+// dibandingkan antar run.
+type Generator struct {
+	rng *rand.Rand
+}
+
+func NewGenerator(seed uint64) *Generator {
+	return &Generator{rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+// Intn mengembalikan angka acak di [0, n)
+func (g *Generator) Intn(n int) int {
+	return g.rng.IntN(n)
+}
+
+// Between mengembalikan angka acak di [min, max]
+func (g *Generator) Between(min, max int) int {
+	return min + g.rng.IntN(max-min+1)
+}
+
+func (g *Generator) pick(values []string) string {
+	return values[g.rng.IntN(len(values))]
+}
+
+// category adalah kelompok produk dengan kisaran harga dalam rupiah
+type category struct {
+	name     string
+	nouns    []string
+	minPrice float64
+	maxPrice float64
+}
+
+var categories = []category{
+	{name: "Elektronik", nouns: []string{"Laptop", "Smartphone", "Tablet", "Monitor", "Smartwatch", "Speaker Bluetooth"}, minPrice: 500000, maxPrice: 30000000},
+	{name: "Aksesoris Komputer", nouns: []string{"Mouse", "Keyboard", "Headset", "Webcam", "USB Hub", "Mousepad"}, minPrice: 25000, maxPrice: 2500000},
+	{name: "Rumah Tangga", nouns: []string{"Rice Cooker", "Blender", "Setrika", "Dispenser", "Kipas Angin", "Panci"}, minPrice: 50000, maxPrice: 3000000},
+	{name: "Fashion", nouns: []string{"Kaos", "Kemeja", "Jaket", "Sepatu", "Tas Ransel", "Topi"}, minPrice: 35000, maxPrice: 1500000},
+	{name: "Olahraga", nouns: []string{"Matras Yoga", "Dumbbell", "Raket", "Bola Sepak", "Botol Minum", "Sepeda Lipat"}, minPrice: 20000, maxPrice: 8000000},
+	{name: "Makanan", nouns: []string{"Kopi Bubuk", "Teh Hijau", "Keripik", "Madu", "Sambal", "Cokelat"}, minPrice: 10000, maxPrice: 250000},
+}
+
+var (
+	brands     = []string{"Nusantara", "Garuda", "Merapi", "Kencana", "Samudra", "Rinjani", "Bintang", "Cahaya", "Mahakam", "Borneo"}
+	adjectives = []string{"Pro", "Lite", "Max", "Mini", "Plus", "Ultra", "Classic", "Eco", "Sport", "Premium"}
+	features   = []string{"garansi 1 tahun", "bahan berkualitas", "desain ringkas", "hemat energi", "cocok untuk hadiah", "tahan lama", "mudah dibersihkan", "ringan dibawa"}
+
+	companyPrefixes = []string{"PT", "CV", "UD", "Toko"}
+	companyWords    = []string{"Sumber", "Makmur", "Jaya", "Abadi", "Sentosa", "Mandiri", "Sejahtera", "Karya", "Utama", "Lestari"}
+	cities          = []string{"Jakarta", "Bandung", "Surabaya", "Medan", "Semarang", "Makassar", "Yogyakarta", "Denpasar"}
+
+	firstNames = []string{"budi", "siti", "andi", "dewi", "rizky", "putri", "agus", "rina", "fajar", "maya", "eko", "lina"}
+	lastNames  = []string{"santoso", "wijaya", "pratama", "lestari", "saputra", "hidayat", "kusuma", "nugroho"}
+	postWords  = []string{"hari", "ini", "belajar", "Go", "seru", "kopi", "pagi", "kerja", "akhirnya", "deploy", "selesai", "weekend", "liburan", "makan", "enak", "coba", "fitur", "baru", "terima", "kasih"}
+)
+
+// Company membuat nama supplier seperti "PT Sumber Makmur Bandung"
+func (g *Generator) Company() string {
+	return fmt.Sprintf("%s %s %s %s", g.pick(companyPrefixes), g.pick(companyWords), g.pick(companyWords), g.pick(cities))
+}
+
+// Product membuat nama, deskripsi dan harga produk. Harga condong ke bawah
+// kisaran kategori dan dibulatkan ke ratusan rupiah seperti harga toko.
+func (g *Generator) Product() (name, description string, price float64) {
+	c := categories[g.rng.IntN(len(categories))]
+	noun := g.pick(c.nouns)
+	name = fmt.Sprintf("%s %s %s %d", g.pick(brands), noun, g.pick(adjectives), g.Between(1, 9)*100)
+
+	f1, f2 := g.pick(features), g.pick(features)
+	description = fmt.Sprintf("%s kategori %s, %s", noun, strings.ToLower(c.name), f1)
+	if f2 != f1 {
+		description += " dan " + f2
+	}
+
+	// Distribusi log-uniform: produk murah lebih banyak dari yang mahal
+	logMin, logMax := math.Log(c.minPrice), math.Log(c.maxPrice)
+	price = math.Exp(logMin + g.rng.Float64()*(logMax-logMin))
+	price = math.Max(100, math.Round(price/100)*100)
+	return name, description, price
+}
+
+// Stock membuat stok yang sebagian kecil habis atau hampir habis, supaya
+// load test juga melewati jalur stok tidak cukup dan stock_low
+func (g *Generator) Stock() int {
+	switch n := g.rng.IntN(100); {
+	case n < 5:
+		return 0
+	case n < 15:
+		return g.Between(1, 5)
+	default:
+		return g.Between(6, 500)
+	}
+}
+
+// Username membuat username unik berdasarkan urutan, seperti "siti_wijaya42"
+func (g *Generator) Username(i int) string {
+	return fmt.Sprintf("%s_%s%d", g.pick(firstNames), g.pick(lastNames), i)
+}
+
+// Sentence membuat kalimat pendek untuk konten post
+func (g *Generator) Sentence(min, max int) string {
+	n := g.Between(min, max)
+	words := make([]string, n)
+	for i := range words {
+		words[i] = g.pick(postWords)
+	}
+	s := strings.Join(words, " ")
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
@@ -0,0 +1,133 @@
+// Package health berisi handler /healthz (liveness) dan /readyz
+// (readiness) dengan check yang bisa didaftarkan per dependency.
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"e-commerce/internal/apierror"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+)
+
+// checkTimeout membatasi satu check supaya /readyz tidak menggantung
+const checkTimeout = 2 * time.Second
+
+// Check mengembalikan error kalau dependency belum siap
+type Check func(ctx context.Context) error
+
+// Report adalah isi field "data" dari /healthz dan /readyz
+type Report struct {
+	Status string            `json:"status" validate:"oneof=ok unavailable"`
+	Checks map[string]string `json:"checks,omitempty" doc:"Hasil per check: ok atau pesan error"`
+}
+
+type Checker struct {
+	mu     sync.Mutex
+	names  []string
+	checks map[string]Check
+}
+
+func New() *Checker {
+	return &Checker{checks: map[string]Check{}}
+}
+
+func (h *Checker) Add(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.checks[name]; !ok {
+		h.names = append(h.names, name)
+		sort.Strings(h.names)
+	}
+	h.checks[name] = check
+}
+
+// Live selalu 200 selama proses masih bisa melayani HTTP
+func (h *Checker) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Server is alive",
+		"data":    Report{Status: StatusOK},
+		"error":   nil,
+	})
+}
+
+// Ready menjalankan semua check secara paralel
+func (h *Checker) Ready(c *gin.Context) {
+	h.mu.Lock()
+	names := append([]string(nil), h.names...)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+	defer cancel()
+
+	results := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = check(ctx)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: map[string]string{}}
+	var failed []string
+	for i, name := range names {
+		report.Checks[name] = StatusOK
+		if results[i] != nil {
+			report.Checks[name] = results[i].Error()
+			failed = append(failed, name)
+		}
+	}
+
+	if len(failed) > 0 {
+		report.Status = StatusUnavailable
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"message": "Server is not ready",
+			"data":    report,
+			"error":   apierror.ForRequest(c, apierror.New(apierror.CodeUnavailable, "Failed checks: "+strings.Join(failed, ", "))),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Server is ready",
+		"data":    report,
+		"error":   nil,
+	})
+}
+
+// Writable membuat, menulis dan menghapus file sementara di dir. Dipakai
+// untuk memastikan folder storage masih bisa ditulis, misalnya disk belum
+// penuh dan tidak di-mount read-only.
+func Writable(dir string) error {
+	f, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write([]byte("ok"))
+	if err == nil {
+		err = f.Sync()
+	}
+	return errors.Join(err, f.Close())
+}
@@ -0,0 +1,28 @@
+package health
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := Writable(dir); err != nil {
+		t.Fatalf("Writable(%s) = %v", dir, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("probe file left behind: %v", entries)
+	}
+
+	// Folder yang hilang atau ternyata file juga dianggap tidak bisa ditulis
+	file := filepath.Join(dir, "journal.log")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(dir, "missing"), file} {
+		if err := Writable(path); err == nil {
+			t.Errorf("Writable(%s) = nil", path)
+		}
+	}
+}
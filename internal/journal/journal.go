@@ -0,0 +1,481 @@
+// Package journal adalah log permanen semua perubahan state untuk event
+// sourcing. Setiap baris berisi checksum CRC-32C (8 digit hex), spasi, lalu
+// satu entry JSON:
+//
+//	1c0ffee5 {"seq":1,"type":"product.created","occurred_at":"...","data":{...}}
+//
+// Seq naik satu per entry tanpa celah. Baris terakhir yang terpotong karena
+// crash dibuang saat Open; checksum yang salah di tengah file membuat Open
+// gagal supaya data yang rusak tidak pernah di-replay.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"e-commerce/internal/events"
+)
+
+var ErrClosed = errors.New("journal is closed")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Entry adalah satu event di journal. Seq milik journal sendiri, tidak
+// sama dengan Seq outbox.
+type Entry struct {
+	Seq        int64           `json:"seq"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Decode membaca Data ke v
+func (e Entry) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+type Options struct {
+	// Path file journal. Folder dibuat kalau belum ada.
+	Path string
+	// CompactThreshold adalah jumlah entry di file sebelum Compact
+	// dipanggil di background. 0 berarti tidak pernah otomatis.
+	CompactThreshold int
+	// Compact melipat entry lama ke snapshot lalu memanggil Truncate
+	Compact func() error
+	Logger  *slog.Logger
+}
+
+type Log struct {
+	opts Options
+
+	mu      sync.Mutex
+	file    *os.File
+	lastSeq int64
+	entries int
+	// Posisi sebelum Append terakhir untuk Rollback
+	undo *position
+
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+	started bool
+}
+
+type position struct {
+	size    int64
+	lastSeq int64
+	entries int
+}
+
+// Open membuka atau membuat journal dan memeriksa semua checksum. Replay
+// harus dipanggil sebelum Append.
+func Open(opts Options) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(opts.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Log{
+		opts: opts,
+		file: file,
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if err := l.scan(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) logger() *slog.Logger {
+	if l.opts.Logger != nil {
+		return l.opts.Logger
+	}
+	return slog.Default()
+}
+
+// scan memeriksa seluruh file dan memotong baris terakhir yang belum
+// selesai ditulis
+func (l *Log) scan() error {
+	var offset int64
+	err := l.each(func(line int, raw []byte, e Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		if l.lastSeq > 0 && e.Seq != l.lastSeq+1 {
+			return fmt.Errorf("%s line %d: seq %d follows %d", l.opts.Path, line, e.Seq, l.lastSeq)
+		}
+		l.lastSeq = e.Seq
+		l.entries++
+		offset += int64(len(raw))
+		return nil
+	})
+
+	var torn tornError
+	if errors.As(err, &torn) {
+		l.logger().Warn("Discarding incomplete journal entry", "path", l.opts.Path, "line", torn.line)
+		if err := l.file.Truncate(offset); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	_, err = l.file.Seek(0, io.SeekEnd)
+	return err
+}
+
+// tornError menandai baris terakhir yang rusak, artinya penulisan
+// terputus; baris itu boleh dibuang
+type tornError struct {
+	line int
+}
+
+func (e tornError) Error() string {
+	return fmt.Sprintf("incomplete entry at line %d", e.line)
+}
+
+// each membaca file dari awal dan memanggil f untuk setiap baris. Baris
+// rusak dikirim ke f sebagai err; kalau baris itu yang terakhir, err-nya
+// tornError.
+func (l *Log) each(f func(line int, raw []byte, e Entry, err error) error) error {
+	if _, err := l.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(l.file)
+	for line := 1; ; line++ {
+		raw, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(raw) > 0 {
+				// Baris terakhir tanpa newline belum selesai ditulis
+				return f(line, raw, Entry{}, tornError{line})
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		e, decodeErr := decode(raw)
+		if decodeErr != nil {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				decodeErr = tornError{line}
+			} else {
+				decodeErr = fmt.Errorf("%s line %d: %w", l.opts.Path, line, decodeErr)
+			}
+		}
+		if err := f(line, raw, e, decodeErr); err != nil {
+			return err
+		}
+	}
+}
+
+func encode(buf *bytes.Buffer, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "%08x ", crc32.Checksum(data, castagnoli))
+	buf.Write(data)
+	buf.WriteByte('\n')
+	return nil
+}
+
+func decode(raw []byte) (Entry, error) {
+	line := bytes.TrimSuffix(raw, []byte("\n"))
+	sum, data, ok := bytes.Cut(line, []byte(" "))
+	if !ok || len(sum) != 8 {
+		return Entry{}, errors.New("missing checksum")
+	}
+	want, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil {
+		return Entry{}, errors.New("missing checksum")
+	}
+	if got := crc32.Checksum(data, castagnoli); got != uint32(want) {
+		return Entry{}, fmt.Errorf("checksum mismatch: %08x != %08x", got, want)
+	}
+
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// Replay memanggil f untuk setiap entry dengan Seq lebih besar dari after,
+// berurutan. after biasanya Seq terakhir di snapshot; Append berikutnya
+// dimulai setelah yang terbesar di antara after dan isi file.
+func (l *Log) Replay(after int64, f func(Entry) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return ErrClosed
+	}
+
+	expected := after + 1
+	err := l.each(func(line int, raw []byte, e Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.Seq <= after {
+			return nil
+		}
+		// Entry setelah snapshot harus lengkap, kalau tidak ada perubahan
+		// yang hilang
+		if e.Seq != expected {
+			return fmt.Errorf("%s line %d: expected seq %d after snapshot, found %d", l.opts.Path, line, expected, e.Seq)
+		}
+		expected++
+		if err := f(e); err != nil {
+			return fmt.Errorf("replay %s seq %d (%s): %w", l.opts.Path, e.Seq, e.Type, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if after > l.lastSeq {
+		l.lastSeq = after
+	}
+	_, err = l.file.Seek(0, io.SeekEnd)
+	return err
+}
+
+// Append menulis events sebagai entry baru dan fsync sebelum kembali
+func (l *Log) Append(evs []events.Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return ErrClosed
+	}
+
+	var buf bytes.Buffer
+	seq := l.lastSeq
+	for _, e := range evs {
+		seq++
+		if err := encode(&buf, Entry{Seq: seq, Type: e.Type, OccurredAt: e.OccurredAt, RequestID: e.RequestID, Data: e.Data}); err != nil {
+			return err
+		}
+	}
+
+	size, err := l.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(buf.Bytes()); err != nil {
+		l.truncate(size)
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		l.truncate(size)
+		return err
+	}
+
+	l.undo = &position{size: size, lastSeq: l.lastSeq, entries: l.entries}
+	l.lastSeq = seq
+	l.entries += len(evs)
+
+	if l.opts.CompactThreshold > 0 && l.entries >= l.opts.CompactThreshold {
+		select {
+		case l.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (l *Log) truncate(size int64) error {
+	if err := l.file.Truncate(size); err != nil {
+		return err
+	}
+	_, err := l.file.Seek(size, io.SeekStart)
+	return err
+}
+
+// Rollback membuang Append terakhir
+func (l *Log) Rollback() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return ErrClosed
+	}
+	if l.undo == nil {
+		return errors.New("nothing to roll back")
+	}
+	if err := l.truncate(l.undo.size); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.lastSeq, l.entries = l.undo.lastSeq, l.undo.entries
+	l.undo = nil
+	return nil
+}
+
+// LastSeq mengembalikan Seq entry terakhir
+func (l *Log) LastSeq() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastSeq
+}
+
+// Len mengembalikan jumlah entry yang masih ada di file
+func (l *Log) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.entries
+}
+
+// Truncate menulis ulang journal tanpa entry sampai upTo. Dipanggil
+// setelah state sampai upTo tersimpan di snapshot.
+func (l *Log) Truncate(upTo int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return ErrClosed
+	}
+
+	var buf bytes.Buffer
+	kept := 0
+	err := l.each(func(line int, raw []byte, e Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		if e.Seq > upTo {
+			buf.Write(raw)
+			kept++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomic(l.opts.Path, buf.Bytes()); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(l.opts.Path, os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return err
+	}
+	l.file.Close()
+	l.file = file
+	l.entries = kept
+	l.undo = nil
+	return nil
+}
+
+// Start menjalankan compaction di background setiap kali jumlah entry
+// mencapai CompactThreshold
+func (l *Log) Start() {
+	l.mu.Lock()
+	l.started = true
+	l.mu.Unlock()
+
+	if l.opts.Compact == nil {
+		close(l.done)
+		return
+	}
+
+	go func() {
+		defer close(l.done)
+		for {
+			select {
+			case <-l.wake:
+				if err := l.opts.Compact(); err != nil {
+					l.logger().Error("Journal compaction failed", "path", l.opts.Path, "error", err)
+				}
+			case <-l.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close menunggu compaction yang sedang berjalan lalu menutup file.
+// Dipakai sebagai shutdown hook setelah event bus berhenti.
+func (l *Log) Close(ctx context.Context) error {
+	l.mu.Lock()
+	if l.file == nil {
+		l.mu.Unlock()
+		return nil
+	}
+	started := l.started
+	l.mu.Unlock()
+
+	close(l.stop)
+	if started {
+		select {
+		case <-l.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// writeFileAtomic menulis ke file sementara di folder yang sama lalu
+// me-rename-nya, dan fsync folder supaya rename tidak hilang saat crash
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
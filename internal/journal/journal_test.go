@@ -0,0 +1,107 @@
+package journal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"e-commerce/internal/events"
+)
+
+func open(t *testing.T, path string, after int64) (*Log, []int64) {
+	t.Helper()
+	l, err := Open(Options{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seqs []int64
+	if err := l.Replay(after, func(e Entry) error {
+		seqs = append(seqs, e.Seq)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return l, seqs
+}
+
+func appendTypes(t *testing.T, l *Log, types ...string) {
+	t.Helper()
+	evs := make([]events.Event, len(types))
+	for i, typ := range types {
+		evs[i] = events.Event{Type: typ, Data: []byte(`{}`)}
+	}
+	if err := l.Append(evs); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayAfterCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	l, _ := open(t, path, 0)
+	appendTypes(t, l, "a", "b")
+	appendTypes(t, l, "c")
+	l.Close(context.Background())
+
+	// Baris terakhir terpotong dibuang, sisanya di-replay
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString(`0badc0de {"seq":4,"ty`)
+	f.Close()
+
+	l, seqs := open(t, path, 0)
+	if len(seqs) != 3 || l.LastSeq() != 3 {
+		t.Fatalf("replayed %v, last seq %d", seqs, l.LastSeq())
+	}
+	appendTypes(t, l, "d")
+	l.Close(context.Background())
+
+	if _, seqs = open(t, path, 2); len(seqs) != 2 || seqs[0] != 3 || seqs[1] != 4 {
+		t.Errorf("replay after 2 = %v", seqs)
+	}
+}
+
+func TestCorruptEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	l, _ := open(t, path, 0)
+	appendTypes(t, l, "product.created", "source.created")
+	l.Close(context.Background())
+
+	raw, _ := os.ReadFile(path)
+	os.WriteFile(path, []byte(strings.Replace(string(raw), "product", "pr0duct", 1)), 0o644)
+
+	if _, err := Open(Options{Path: path}); err == nil || !strings.Contains(err.Error(), "line 1: checksum mismatch") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestRollbackAndTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.log")
+	l, _ := open(t, path, 0)
+	appendTypes(t, l, "a", "b", "c")
+	appendTypes(t, l, "d", "e")
+	if err := l.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if l.LastSeq() != 3 || l.Len() != 3 {
+		t.Fatalf("after rollback: last seq %d, len %d", l.LastSeq(), l.Len())
+	}
+
+	if err := l.Truncate(3); err != nil {
+		t.Fatal(err)
+	}
+	appendTypes(t, l, "f")
+	l.Close(context.Background())
+
+	// Seq lanjut dari snapshot walaupun entry lama sudah dibuang
+	l, seqs := open(t, path, 3)
+	defer l.Close(context.Background())
+	if len(seqs) != 1 || seqs[0] != 4 {
+		t.Errorf("replayed %v, want [4]", seqs)
+	}
+
+	// Snapshot yang lebih tua dari isi journal berarti ada entry yang hilang
+	if err := l.Replay(1, func(Entry) error { return nil }); err == nil {
+		t.Error("expected error for missing entries")
+	}
+}
@@ -0,0 +1,117 @@
+// Package logging menyiapkan logger log/slog, access log per request dan
+// recovery dari panic. Logger per request sudah membawa request_id.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/requestid"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Format log yang didukung
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// ParseLevel membaca debug, info, warn atau error
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", level)
+	}
+	return l, nil
+}
+
+// New membuat logger dengan level dan format dari konfigurasi. Level dan
+// format yang tidak valid sudah ditolak saat konfigurasi divalidasi.
+func New(w io.Writer, level, format string) *slog.Logger {
+	l, err := ParseLevel(level)
+	if err != nil {
+		l = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: l}
+	if strings.EqualFold(format, FormatText) {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+type contextKey struct{}
+
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext mengembalikan logger milik request, atau slog.Default()
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// From adalah FromContext untuk handler Gin
+func From(c *gin.Context) *slog.Logger {
+	return FromContext(c.Request.Context())
+}
+
+// Middleware menyimpan logger dengan request_id di context request dan
+// menulis satu access log setelah request selesai. Harus dipasang setelah
+// requestid.Middleware.
+func Middleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		reqLogger := logger
+		if id := requestid.Get(c); id != "" {
+			reqLogger = logger.With("request_id", id)
+		}
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), reqLogger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		reqLogger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery menangkap panic, mencatatnya di log dan membalas 500 dengan
+// format error yang sama dengan handler
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
+		From(c).Error("panic recovered", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+		apierror.Abort(c, http.StatusInternalServerError, "Internal server error",
+			apierror.New(apierror.CodeInternal, "An unexpected error occurred"))
+	})
+}
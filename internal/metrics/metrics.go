@@ -0,0 +1,231 @@
+// Package metrics berisi counter, gauge dan histogram sederhana yang
+// ditulis dalam format teks Prometheus, tanpa dependency client_golang.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets untuk latency dalam detik
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry menyimpan semua metric sesuai urutan pendaftaran. Mendaftarkan
+// nama yang sama dua kali mengembalikan metric yang sudah ada.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	byName  map[string]metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{byName: map[string]metric{}}
+}
+
+func (r *Registry) register(m metric) metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.byName[m.name()]; ok {
+		return existing
+	}
+	r.byName[m.name()] = m
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// Counter hanya bisa naik
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return r.register(&Counter{vec: newVec(name, help, labels)}).(*Counter)
+}
+
+// GaugeFunc menghitung nilainya saat /metrics dibaca
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(&gaugeFunc{vec: newVec(name, help, nil), fn: fn})
+}
+
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return r.register(&Histogram{vec: newVec(name, help, labels), buckets: buckets}).(*Histogram)
+}
+
+// Write menulis semua metric dalam format teks Prometheus 0.0.4
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// vec menyimpan satu nilai per kombinasi label
+type vec struct {
+	metricName string
+	help       string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]interface{}
+}
+
+func newVec(name, help string, labels []string) vec {
+	return vec{metricName: name, help: help, labels: labels, series: map[string]interface{}{}}
+}
+
+func (v *vec) name() string {
+	return v.metricName
+}
+
+// key mengubah nilai label menjadi `a="1",b="2"`
+func (v *vec) key(values []string) string {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.metricName, len(v.labels), len(values)))
+	}
+	pairs := make([]string, len(values))
+	for i, value := range values {
+		pairs[i] = v.labels[i] + `="` + escape(value) + `"`
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (v *vec) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.metricName, v.help, v.metricName, kind)
+}
+
+func (v *vec) sortedKeys() []string {
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type Counter struct {
+	vec
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, _ := c.series[key].(float64)
+	c.series[key] = value + delta
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.header(w, "counter")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.series) == 0 && len(c.labels) == 0 {
+		fmt.Fprintf(w, "%s 0\n", c.metricName)
+	}
+	for _, key := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s %s\n", series(c.metricName, key), formatFloat(c.series[key].(float64)))
+	}
+}
+
+type gaugeFunc struct {
+	vec
+	fn func() float64
+}
+
+func (g *gaugeFunc) write(w io.Writer) {
+	g.header(w, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.metricName, formatFloat(g.fn()))
+}
+
+type Histogram struct {
+	vec
+	buckets []float64
+}
+
+type histogramSeries struct {
+	counts []uint64 // per bucket, tidak kumulatif
+	count  uint64
+	sum    float64
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key].(*histogramSeries)
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.header(w, "histogram")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range h.sortedKeys() {
+		s := h.series[key].(*histogramSeries)
+		prefix := key
+		if prefix != "" {
+			prefix += ","
+		}
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%sle=\"%s\"} %d\n", h.metricName, prefix, formatFloat(upper), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", h.metricName, prefix, s.count)
+		fmt.Fprintf(w, "%s %s\n", series(h.metricName+"_sum", key), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s %d\n", series(h.metricName+"_count", key), s.count)
+	}
+}
+
+func series(name, labels string) string {
+	if labels == "" {
+		return name
+	}
+	return name + "{" + labels + "}"
+}
+
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
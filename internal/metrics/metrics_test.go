@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddlewareAndExposition(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	reg := NewRegistry()
+	orders := reg.Counter("orders_total", "Orders.")
+	reg.GaugeFunc("queue_depth", "Queue depth.", func() float64 { return 3 })
+
+	r := gin.New()
+	r.Use(reg.Middleware())
+	r.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.GET("/metrics", reg.Handler())
+
+	for _, path := range []string{"/items/1", "/items/2", "/missing"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	orders.Add(2.5)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/items/:id",status="204"} 2`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_bucket{method="GET",route="/items/:id",status="204",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/items/:id",status="204"} 2`,
+		"orders_total 2.5",
+		"# TYPE queue_depth gauge",
+		"queue_depth 3",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q\n%s", want, body)
+		}
+	}
+}
+
+func TestHistogramBucketsAreCumulative(t *testing.T) {
+	reg := NewRegistry()
+	h := reg.Histogram("latency_seconds", "Latency.", []float64{0.1, 1}, "op")
+	h.Observe(0.05, "read")
+	h.Observe(0.5, "read")
+	h.Observe(5, "read")
+
+	var sb strings.Builder
+	reg.Write(&sb)
+	for _, want := range []string{
+		`latency_seconds_bucket{op="read",le="0.1"} 1`,
+		`latency_seconds_bucket{op="read",le="1"} 2`,
+		`latency_seconds_bucket{op="read",le="+Inf"} 3`,
+		`latency_seconds_sum{op="read"} 5.55`,
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("output missing %q\n%s", want, sb.String())
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware mencatat jumlah request dan latency per method, route dan
+// status. Route memakai pola Gin (/products/:id) supaya jumlah series
+// tidak bertambah per ID; request ke route yang tidak ada dicatat sebagai
+// "unmatched".
+func (r *Registry) Middleware() gin.HandlerFunc {
+	requests := r.Counter("http_requests_total", "Total HTTP requests.", "method", "route", "status")
+	duration := r.Histogram("http_request_duration_seconds", "HTTP request latency in seconds.", nil, "method", "route", "status")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		requests.Inc(c.Request.Method, route, status)
+		duration.Observe(time.Since(start).Seconds(), c.Request.Method, route, status)
+	}
+}
+
+// Handler melayani /metrics
+func (r *Registry) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		r.Write(c.Writer)
+	}
+}
@@ -0,0 +1,28 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed docs/index.html
+var docsHTML string
+
+// Handler menyajikan dokumen OpenAPI sebagai JSON
+func (s *Spec) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, s.doc)
+	}
+}
+
+// DocsHandler menyajikan UI dokumentasi yang dibundel di binary, tanpa
+// CDN. UI membaca dokumen dari specURL.
+func DocsHandler(title, specURL string) gin.HandlerFunc {
+	page := strings.NewReplacer("{{TITLE}}", title, "{{SPEC_URL}}", specURL).Replace(docsHTML)
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+	}
+}
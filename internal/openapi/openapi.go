@@ -0,0 +1,333 @@
+// Package openapi membangun dokumen OpenAPI 3 dari daftar operasi dan
+// struct Go, lalu menyajikannya bersama UI dokumentasi lokal.
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const Version = "3.0.3"
+
+// Document adalah subset OpenAPI 3 yang dipakai API ini
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Operation adalah satu operasi (method + path) di dalam dokumen
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Security scheme yang dipakai di Route.Security
+const (
+	BearerAuth = "bearerAuth"
+	APIKeyAuth = "apiKeyAuth"
+)
+
+// Param adalah query parameter pada Route
+type Param struct {
+	Name        string
+	Description string
+	Type        string // string, integer, number atau boolean
+	Required    bool
+}
+
+// Route mendeskripsikan satu route. Request dan Response diisi dengan
+// nilai struct contoh, misalnya Product{} atau []Product{}. Response adalah
+// isi field "data" di dalam envelope APIResponse.
+type Route struct {
+	Method      string
+	Path        string // format Gin, contoh /products/:id
+	Summary     string
+	Description string
+	Tag         string
+	Security    []string
+	Query       []Param
+	Request     interface{}
+	Response    interface{}
+	Status      int   // status sukses, default 200
+	Errors      []int // status error yang mungkin dikembalikan
+	// Produces dipakai untuk route yang tidak mengembalikan JSON envelope,
+	// contoh "text/html" untuk halaman dokumentasi.
+	Produces string
+}
+
+// Spec membangun Document dari daftar route
+type Spec struct {
+	doc      *Document
+	schemas  *schemaRegistry
+	envelope func(data *Schema) *Schema
+	tags     map[string]bool
+}
+
+// New membuat spec kosong. errorType adalah tipe field "error" pada
+// envelope response, contoh apierror.Error{}.
+func New(info Info, errorType interface{}) *Spec {
+	s := &Spec{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   map[string]map[string]*Operation{},
+			Components: Components{
+				Schemas: map[string]*Schema{},
+			},
+		},
+		tags: map[string]bool{},
+	}
+	s.schemas = newSchemaRegistry(s.doc.Components.Schemas)
+
+	errorSchema := s.schemas.schemaFor(errorType)
+	s.envelope = func(data *Schema) *Schema {
+		if data == nil {
+			data = &Schema{Nullable: true, Description: "Always null"}
+		}
+		return &Schema{
+			Type:     "object",
+			Required: []string{"message", "data", "error"},
+			Properties: map[string]*Schema{
+				"message": {Type: "string"},
+				"data":    data,
+				"error":   {AllOf: []*Schema{errorSchema}, Nullable: true},
+			},
+		}
+	}
+	return s
+}
+
+// SecurityScheme mendaftarkan skema auth yang dipakai route
+func (s *Spec) SecurityScheme(name string, scheme *SecurityScheme) {
+	if s.doc.Components.SecuritySchemes == nil {
+		s.doc.Components.SecuritySchemes = map[string]*SecurityScheme{}
+	}
+	s.doc.Components.SecuritySchemes[name] = scheme
+}
+
+var pathParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// Add menambahkan route ke dokumen
+func (s *Spec) Add(route Route) {
+	path := pathParam.ReplaceAllString(route.Path, "{$1}")
+	method := strings.ToLower(route.Method)
+
+	item := &Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		OperationID: operationID(method, route.Path),
+		Responses:   map[string]*Response{},
+	}
+	if route.Tag != "" {
+		item.Tags = []string{route.Tag}
+		if !s.tags[route.Tag] {
+			s.tags[route.Tag] = true
+			s.doc.Tags = append(s.doc.Tags, Tag{Name: route.Tag})
+		}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		item.Parameters = append(item.Parameters, Parameter{
+			Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+		})
+	}
+	for _, q := range route.Query {
+		typ := q.Type
+		if typ == "" {
+			typ = "string"
+		}
+		item.Parameters = append(item.Parameters, Parameter{
+			Name: q.Name, In: "query", Required: q.Required, Description: q.Description, Schema: &Schema{Type: typ},
+		})
+	}
+
+	if route.Request != nil {
+		item.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: s.schemas.schemaFor(route.Request)}},
+		}
+	}
+
+	for _, name := range route.Security {
+		item.Security = append(item.Security, map[string][]string{name: {}})
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if route.Produces != "" {
+		item.Responses[strconv.Itoa(status)] = &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*MediaType{route.Produces: {Schema: &Schema{Type: "string"}}},
+		}
+	} else {
+		var data *Schema
+		if route.Response != nil {
+			data = s.schemas.schemaFor(route.Response)
+		}
+		item.Responses[strconv.Itoa(status)] = s.jsonResponse(http.StatusText(status), data)
+	}
+
+	errs := append([]int(nil), route.Errors...)
+	if len(route.Security) > 0 {
+		errs = append(errs, http.StatusUnauthorized, http.StatusForbidden)
+	}
+	for _, code := range errs {
+		item.Responses[strconv.Itoa(code)] = s.jsonResponse(http.StatusText(code), nil)
+	}
+
+	if s.doc.Paths[path] == nil {
+		s.doc.Paths[path] = map[string]*Operation{}
+	}
+	s.doc.Paths[path][method] = item
+}
+
+func (s *Spec) jsonResponse(description string, data *Schema) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]*MediaType{"application/json": {Schema: s.envelope(data)}},
+	}
+}
+
+// Has mengecek apakah route dengan method dan path Gin terdaftar
+func (s *Spec) Has(method, ginPath string) bool {
+	path := pathParam.ReplaceAllString(ginPath, "{$1}")
+	_, ok := s.doc.Paths[path][strings.ToLower(method)]
+	return ok
+}
+
+// Operations mengembalikan semua "METHOD path" yang terdaftar, terurut
+func (s *Spec) Operations() []string {
+	var ops []string
+	for path, methods := range s.doc.Paths {
+		for method := range methods {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// Coverage membandingkan route yang dilayani router dengan dokumen.
+// undocumented berisi route yang belum ada di spec, unserved berisi operasi
+// di spec yang tidak punya route. Keduanya dalam format "METHOD path".
+func (s *Spec) Coverage(routes gin.RoutesInfo) (undocumented, unserved []string) {
+	served := map[string]bool{}
+	for _, route := range routes {
+		if !s.Has(route.Method, route.Path) {
+			undocumented = append(undocumented, route.Method+" "+route.Path)
+		}
+		served[route.Method+" "+pathParam.ReplaceAllString(route.Path, "{$1}")] = true
+	}
+	for _, op := range s.Operations() {
+		if !served[op] {
+			unserved = append(unserved, op)
+		}
+	}
+	return undocumented, unserved
+}
+
+// CheckRoutes mengembalikan error untuk setiap selisih dari Coverage,
+// atau nil kalau router dan dokumen sama. Dipakai test setiap server.
+func (s *Spec) CheckRoutes(routes gin.RoutesInfo) error {
+	undocumented, unserved := s.Coverage(routes)
+	var errs []error
+	for _, route := range undocumented {
+		errs = append(errs, fmt.Errorf("route %s is missing from the spec", route))
+	}
+	for _, op := range unserved {
+		errs = append(errs, fmt.Errorf("spec documents %s but the router does not serve it", op))
+	}
+	return errors.Join(errs...)
+}
+
+// Document mengembalikan dokumen yang sudah dibangun
+func (s *Spec) Document() *Document {
+	return s.doc
+}
+
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(method)
+	for _, part := range strings.Split(path, "/") {
+		if part == "" {
+			continue
+		}
+		if part[0] == ':' || part[0] == '*' {
+			b.WriteString("By")
+			part = part[1:]
+		}
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCoverage(t *testing.T) {
+	spec := New(Info{Title: "test", Version: "1"}, struct{ Error string }{})
+	spec.Add(Route{Method: http.MethodGet, Path: "/products/:id"})
+	spec.Add(Route{Method: http.MethodDelete, Path: "/products/:id"})
+
+	undocumented, unserved := spec.Coverage(gin.RoutesInfo{
+		{Method: http.MethodGet, Path: "/products/:id"},
+		{Method: http.MethodPost, Path: "/products"},
+	})
+	if want := []string{"POST /products"}; !reflect.DeepEqual(undocumented, want) {
+		t.Errorf("undocumented = %v, want %v", undocumented, want)
+	}
+	if want := []string{"DELETE /products/{id}"}; !reflect.DeepEqual(unserved, want) {
+		t.Errorf("unserved = %v, want %v", unserved, want)
+	}
+
+	err := spec.CheckRoutes(gin.RoutesInfo{{Method: http.MethodGet, Path: "/products/:id"}})
+	if err == nil || err.Error() != "spec documents DELETE /products/{id} but the router does not serve it" {
+		t.Errorf("CheckRoutes() = %v", err)
+	}
+	spec.Add(Route{Method: http.MethodPost, Path: "/products"})
+	if err := spec.CheckRoutes(gin.RoutesInfo{
+		{Method: http.MethodGet, Path: "/products/:id"},
+		{Method: http.MethodDelete, Path: "/products/:id"},
+		{Method: http.MethodPost, Path: "/products"},
+	}); err != nil {
+		t.Errorf("CheckRoutes() = %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema adalah subset JSON Schema versi OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaRegistry membuat schema dari tipe Go. Struct bernama disimpan di
+// components/schemas dan direferensikan lewat $ref.
+type schemaRegistry struct {
+	schemas map[string]*Schema
+}
+
+func newSchemaRegistry(schemas map[string]*Schema) *schemaRegistry {
+	return &schemaRegistry{schemas: schemas}
+}
+
+func (r *schemaRegistry) schemaFor(v interface{}) *Schema {
+	return r.schemaForType(reflect.TypeOf(v))
+}
+
+func (r *schemaRegistry) schemaForType(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := r.baseSchema(t)
+	if nullable {
+		if schema.Ref != "" {
+			return &Schema{AllOf: []*Schema{schema}, Nullable: true}
+		}
+		schema.Nullable = true
+	}
+	return schema
+}
+
+func (r *schemaRegistry) baseSchema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		return &Schema{Description: "Any JSON value"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Uint, reflect.Int64, reflect.Uint64:
+		if t.PkgPath() == "time" && t.Name() == "Duration" {
+			return &Schema{Type: "integer", Format: "int64", Description: "Duration in nanoseconds"}
+		}
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaForType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := r.schemas[name]; !ok {
+			// Placeholder dulu supaya tipe rekursif tidak loop
+			r.schemas[name] = &Schema{}
+			*r.schemas[name] = *r.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	// interface{} dan tipe lain bisa berisi nilai apa saja
+	return &Schema{}
+}
+
+func (r *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	r.addFields(schema, t)
+	return schema
+}
+
+func (r *schemaRegistry) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		// Field embedded tanpa tag json digabung ke struct induk
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(schema, ft)
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := r.schemaForType(field.Type)
+		required := applyValidateTag(prop, field.Tag.Get("validate"))
+		if desc := field.Tag.Get("doc"); desc != "" {
+			if prop.Ref != "" {
+				prop = &Schema{AllOf: []*Schema{prop}}
+			}
+			prop.Description = desc
+		}
+
+		schema.Properties[name] = prop
+		if required && !strings.Contains(opts, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyValidateTag menerjemahkan aturan `validate` ke batasan schema dan
+// mengembalikan true kalau field wajib diisi.
+func applyValidateTag(schema *Schema, tag string) bool {
+	if tag == "" || schema.Ref != "" {
+		return strings.HasPrefix(tag, "required")
+	}
+
+	required := false
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			// Aturan setelah dive berlaku untuk item array
+			break
+		}
+
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url", "http_url":
+			schema.Format = "uri"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, value)
+			}
+		case "gt", "gte", "min":
+			applyLowerBound(schema, param, name == "gt")
+		case "lt", "lte", "max":
+			applyUpperBound(schema, param, name == "lt")
+		case "len":
+			applyLowerBound(schema, param, false)
+			applyUpperBound(schema, param, false)
+		}
+	}
+	return required
+}
+
+func applyLowerBound(schema *Schema, param string, exclusive bool) {
+	switch schema.Type {
+	case "integer", "number":
+		if v, err := strconv.ParseFloat(param, 64); err == nil {
+			schema.Minimum = &v
+			schema.ExclusiveMinimum = exclusive
+		}
+	case "string":
+		if n, err := strconv.Atoi(param); err == nil {
+			if exclusive {
+				n++
+			}
+			schema.MinLength = &n
+		}
+	case "array":
+		if n, err := strconv.Atoi(param); err == nil {
+			if exclusive {
+				n++
+			}
+			schema.MinItems = &n
+		}
+	}
+}
+
+func applyUpperBound(schema *Schema, param string, exclusive bool) {
+	switch schema.Type {
+	case "integer", "number":
+		if v, err := strconv.ParseFloat(param, 64); err == nil {
+			schema.Maximum = &v
+			schema.ExclusiveMaximum = exclusive
+		}
+	case "string":
+		if n, err := strconv.Atoi(param); err == nil {
+			if exclusive {
+				n--
+			}
+			schema.MaxLength = &n
+		}
+	case "array":
+		if n, err := strconv.Atoi(param); err == nil {
+			if exclusive {
+				n--
+			}
+			schema.MaxItems = &n
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"e-commerce/internal/webhook"
+)
+
+// Token untuk memilih hasil Fake. Token lain, termasuk kosong, berhasil.
+const (
+	TokenSuccess         = "tok_success"
+	TokenDeclined        = "tok_declined"
+	TokenTimeout         = "tok_timeout"
+	TokenCaptureDeclined = "tok_capture_declined"
+)
+
+// HeaderSignature berisi signature webhook Fake dengan format yang sama
+// dengan webhook keluar server: "t=<unix>,v1=<hex>"
+const HeaderSignature = "X-Payment-Signature"
+
+// Umur maksimal timestamp webhook Fake
+const signatureTolerance = 5 * time.Minute
+
+// Status payment di Fake
+const (
+	statusAuthorized = "authorized"
+	statusCaptured   = "captured"
+	statusVoided     = "voided"
+	statusRefunded   = "refunded"
+)
+
+type fakePayment struct {
+	amount   float64
+	refunded float64
+	status   string
+	token    string
+}
+
+// Fake adalah provider di memory untuk development dan test. Hasilnya
+// ditentukan oleh token, lihat konstanta Token*.
+type Fake struct {
+	secret string
+
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+	nextID   int
+}
+
+// NewFake membuat Fake dengan secret webhook. Secret kosong diganti
+// secret acak, jadi webhook hanya bisa dibuat lewat Fake.Webhook.
+func NewFake(secret string) *Fake {
+	if secret == "" {
+		buf := make([]byte, 16)
+		rand.Read(buf)
+		secret = hex.EncodeToString(buf)
+	}
+	return &Fake{secret: secret, payments: make(map[string]*fakePayment)}
+}
+
+func (f *Fake) Name() string {
+	return ProviderFake
+}
+
+func (f *Fake) Authorize(ctx context.Context, req Request) (Authorization, error) {
+	switch req.Token {
+	case TokenDeclined:
+		return Authorization{}, ErrDeclined
+	case TokenTimeout:
+		// Provider yang tidak menjawab, hanya berhenti karena ctx
+		<-ctx.Done()
+		return Authorization{}, ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		return Authorization{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	id := fmt.Sprintf("fake_pay_%d", f.nextID)
+	f.payments[id] = &fakePayment{amount: req.Amount, status: statusAuthorized, token: req.Token}
+	return Authorization{ID: id, Amount: req.Amount, Currency: req.Currency}, nil
+}
+
+func (f *Fake) Capture(ctx context.Context, paymentID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.payments[paymentID]
+	if !ok {
+		return ErrNotFound
+	}
+	if p.status != statusAuthorized {
+		return ErrInvalidState
+	}
+	if p.token == TokenCaptureDeclined {
+		return ErrDeclined
+	}
+	p.status = statusCaptured
+	return nil
+}
+
+func (f *Fake) Refund(ctx context.Context, paymentID string, amount float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.payments[paymentID]
+	if !ok {
+		return ErrNotFound
+	}
+	switch p.status {
+	case statusAuthorized:
+		p.status = statusVoided
+		return nil
+	case statusCaptured:
+		if amount <= 0 || p.refunded+amount > p.amount {
+			return fmt.Errorf("%w: refund %v exceeds captured %v", ErrInvalidState, amount, p.amount-p.refunded)
+		}
+		p.refunded += amount
+		if p.refunded == p.amount {
+			p.status = statusRefunded
+		}
+		return nil
+	}
+	return ErrInvalidState
+}
+
+func (f *Fake) VerifyWebhook(header http.Header, body []byte) (Event, error) {
+	if err := webhook.Verify(f.secret, header.Get(HeaderSignature), body, signatureTolerance, time.Now()); err != nil {
+		return Event{}, ErrInvalidSignature
+	}
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Event{}, fmt.Errorf("decode payment webhook: %w", err)
+	}
+	return event, nil
+}
+
+// Webhook membuat body dan header signature untuk event seolah dikirim
+// provider. Dipakai test dan simulasi lokal.
+func (f *Fake) Webhook(event Event) ([]byte, http.Header) {
+	body, _ := json.Marshal(event)
+	header := http.Header{}
+	header.Set(HeaderSignature, webhook.Sign(f.secret, time.Now(), body))
+	return body, header
+}
@@ -0,0 +1,73 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeLifecycle(t *testing.T) {
+	f := NewFake("secret")
+	ctx := context.Background()
+
+	auth, err := f.Authorize(ctx, Request{Reference: "1", Amount: 100, Currency: "IDR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Capture(ctx, auth.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Capture(ctx, auth.ID); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("second capture: %v", err)
+	}
+	if err := f.Refund(ctx, auth.ID, 150); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("refund above captured amount: %v", err)
+	}
+	if err := f.Refund(ctx, auth.ID, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	// Refund sebelum capture membatalkan authorization
+	auth, _ = f.Authorize(ctx, Request{Amount: 50, Token: TokenCaptureDeclined})
+	if err := f.Capture(ctx, auth.ID); !errors.Is(err, ErrDeclined) {
+		t.Errorf("capture: %v", err)
+	}
+	if err := f.Refund(ctx, auth.ID, 50); err != nil {
+		t.Errorf("void: %v", err)
+	}
+	if err := f.Capture(ctx, auth.ID); !errors.Is(err, ErrInvalidState) {
+		t.Errorf("capture after void: %v", err)
+	}
+}
+
+func TestFakeFailures(t *testing.T) {
+	f := NewFake("")
+	if _, err := f.Authorize(context.Background(), Request{Token: TokenDeclined}); !errors.Is(err, ErrDeclined) {
+		t.Errorf("declined: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Authorize(ctx, Request{Token: TokenTimeout}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("timeout: %v", err)
+	}
+}
+
+func TestFakeWebhook(t *testing.T) {
+	f := NewFake("secret")
+	body, header := f.Webhook(Event{ID: "evt_1", Type: EventRefunded, PaymentID: "fake_pay_1", Amount: 10})
+
+	event, err := f.VerifyWebhook(header, body)
+	if err != nil || event.PaymentID != "fake_pay_1" || event.Type != EventRefunded {
+		t.Fatalf("event %+v, error %v", event, err)
+	}
+
+	body[len(body)-2] = '9'
+	if _, err := f.VerifyWebhook(header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("tampered body: %v", err)
+	}
+	if _, err := NewFake("other").VerifyWebhook(header, body); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("other secret: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+// Package payment mendefinisikan interface ke payment provider. Server
+// hanya bicara ke Provider, jadi provider asli bisa dipasang tanpa
+// mengubah alur transaksi. Fake dipakai untuk development dan test.
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Nama provider yang didukung, lihat config payment.provider
+const ProviderFake = "fake"
+
+var Providers = []string{ProviderFake}
+
+// Event webhook dari provider yang diproses server
+const (
+	EventRefunded = "payment.refunded"
+)
+
+var (
+	// ErrDeclined berarti provider menolak pembayaran, misalnya saldo
+	// kurang. Tidak perlu dicoba ulang dengan data yang sama.
+	ErrDeclined         = errors.New("payment declined")
+	ErrNotFound         = errors.New("payment not found")
+	ErrInvalidState     = errors.New("payment is not in a state that allows this")
+	ErrInvalidSignature = errors.New("invalid payment webhook signature")
+)
+
+// Request adalah permintaan authorize. Token mewakili metode pembayaran
+// dari client (kartu, e-wallet) dan tidak pernah disimpan server.
+type Request struct {
+	Reference string
+	Amount    float64
+	Currency  string
+	Token     string
+}
+
+// Authorization adalah dana yang sudah ditahan provider dan belum ditarik
+type Authorization struct {
+	ID       string
+	Amount   float64
+	Currency string
+}
+
+// Event adalah notifikasi webhook yang sudah diverifikasi
+type Event struct {
+	ID        string  `json:"id"`
+	Type      string  `json:"type"`
+	PaymentID string  `json:"payment_id"`
+	Amount    float64 `json:"amount"`
+}
+
+// Provider adalah payment provider. Authorize menahan dana, Capture
+// menariknya. Refund sebelum Capture membatalkan authorization tanpa
+// biaya; setelah Capture dana dikembalikan ke pembeli. Semua method harus
+// berhenti kalau ctx selesai.
+type Provider interface {
+	Name() string
+	Authorize(ctx context.Context, req Request) (Authorization, error)
+	Capture(ctx context.Context, paymentID string) error
+	Refund(ctx context.Context, paymentID string, amount float64) error
+	// VerifyWebhook mengecek signature request webhook dari provider dan
+	// mengembalikan event-nya
+	VerifyWebhook(header http.Header, body []byte) (Event, error)
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUnauthenticatedRequestsShareIPBucket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware(New(Rule{Requests: 2, Period: time.Minute}), ClientKey))
+	r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// Header X-API-Key yang belum dicek tidak boleh membuat bucket baru
+	var codes []int
+	for _, key := range []string{"a", "b", "c"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("status codes = %v, want 200, 200, 429", codes)
+	}
+}
+
+func TestGroupsHeadersAndOverrides(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rules, err := Override(map[string]Rule{"default": {Requests: 100, Period: time.Minute}}, "auth=1/1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := NewGroups(rules, IPKey)
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	for _, limiter := range groups.limiters {
+		limiter.now = clock.Now
+	}
+
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/auth/login", groups.Middleware("auth"), ok)
+	r.GET("/products", groups.Middleware("default"), ok)
+	r.GET("/health", groups.Middleware("unconfigured"), ok)
+	request := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		method  string
+		path    string
+		code    int
+		headers map[string]string
+	}{
+		{"first login", 0, http.MethodPost, "/auth/login", http.StatusOK, map[string]string{
+			"RateLimit-Policy": "1;w=60", "RateLimit-Limit": "1", "RateLimit-Remaining": "0", "RateLimit-Reset": "60", "Retry-After": "",
+		}},
+		{"second login", 0, http.MethodPost, "/auth/login", http.StatusTooManyRequests, map[string]string{
+			"RateLimit-Remaining": "0", "RateLimit-Reset": "60", "Retry-After": "60",
+		}},
+		{"other group keeps its own bucket", 0, http.MethodGet, "/products", http.StatusOK, map[string]string{
+			"RateLimit-Policy": "100;w=60", "RateLimit-Limit": "100", "RateLimit-Remaining": "99", "RateLimit-Reset": "1",
+		}},
+		{"unconfigured group is not limited", 0, http.MethodGet, "/health", http.StatusOK, map[string]string{
+			"RateLimit-Limit": "",
+		}},
+		{"retry before refill", 20 * time.Second, http.MethodPost, "/auth/login", http.StatusTooManyRequests, map[string]string{
+			"RateLimit-Reset": "40", "Retry-After": "40",
+		}},
+		{"login after refill", 40 * time.Second, http.MethodPost, "/auth/login", http.StatusOK, map[string]string{
+			"RateLimit-Remaining": "0", "Retry-After": "",
+		}},
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		w := request(tt.method, tt.path)
+		if w.Code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.code)
+		}
+		for header, want := range tt.headers {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, header, got, want)
+			}
+		}
+	}
+}
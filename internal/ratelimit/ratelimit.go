@@ -0,0 +1,208 @@
+// Package ratelimit berisi token-bucket limiter dan middleware Gin yang
+// mengirim header RateLimit-* dan Retry-After.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rule berarti Requests request per Period. Bucket terisi ulang secara
+// bertahap dan maksimal menampung Requests token (burst).
+type Rule struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (r Rule) String() string {
+	period := r.Period.String()
+	// 1m0s -> 1m, 1h0m0s -> 1h
+	if strings.HasSuffix(period, "m0s") {
+		period = strings.TrimSuffix(period, "0s")
+	}
+	if strings.HasSuffix(period, "h0m") {
+		period = strings.TrimSuffix(period, "0m")
+	}
+	return fmt.Sprintf("%d/%s", r.Requests, period)
+}
+
+func (r Rule) Validate() error {
+	if r.Requests <= 0 {
+		return fmt.Errorf("requests must be greater than 0")
+	}
+	if r.Period <= 0 {
+		return fmt.Errorf("period must be greater than 0")
+	}
+	return nil
+}
+
+// MarshalText dan UnmarshalText dipakai saat rule dibaca dari atau
+// ditulis ke file konfigurasi
+func (r Rule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rule) UnmarshalText(text []byte) error {
+	rule, err := ParseRule(string(text))
+	if err != nil {
+		return err
+	}
+	*r = rule
+	return nil
+}
+
+// ParseRule membaca format "100/1m"
+func ParseRule(value string) (Rule, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rule %q, expected requests/period such as 100/1m", value)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: %w", value, err)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: %w", value, err)
+	}
+
+	rule := Rule{Requests: n, Period: d}
+	if err := rule.Validate(); err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q: %w", value, err)
+	}
+	return rule, nil
+}
+
+// ParseRules membaca format "default=100/1m,transactions=20/1m"
+func ParseRules(spec string) (map[string]Rule, error) {
+	rules := map[string]Rule{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		group, value, ok := strings.Cut(item, "=")
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid rate limit %q, expected group=requests/period", item)
+		}
+		rule, err := ParseRule(value)
+		if err != nil {
+			return nil, err
+		}
+		rules[strings.TrimSpace(group)] = rule
+	}
+	return rules, nil
+}
+
+// Decision adalah hasil pengecekan satu request
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // waktu sampai bucket penuh lagi
+	RetryAfter time.Duration // waktu sampai ada token lagi, kalau ditolak
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter menyimpan satu bucket per key (IP, API key atau user)
+type Limiter struct {
+	rule Rule
+	rate float64 // token per detik
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	calls   int
+	now     func() time.Time
+}
+
+func New(rule Rule) *Limiter {
+	return &Limiter{
+		rule:    rule,
+		rate:    float64(rule.Requests) / rule.Period.Seconds(),
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+func (l *Limiter) Rule() Rule {
+	return l.rule
+}
+
+// Allow mengambil satu token dari bucket milik key
+func (l *Limiter) Allow(key string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	capacity := float64(l.rule.Requests)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	} else {
+		elapsed := now.Sub(b.last).Seconds()
+		b.tokens = math.Min(capacity, b.tokens+elapsed*l.rate)
+		b.last = now
+	}
+
+	decision := Decision{Limit: l.rule.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = l.duration(1 - b.tokens)
+	}
+	decision.Remaining = int(math.Floor(b.tokens))
+	decision.Reset = l.duration(capacity - b.tokens)
+
+	l.calls++
+	if l.calls%1024 == 0 {
+		l.sweep(now)
+	}
+	return decision
+}
+
+// sweep menghapus bucket yang sudah penuh supaya map tidak terus membesar
+func (l *Limiter) sweep(now time.Time) {
+	capacity := float64(l.rule.Requests)
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *Limiter) duration(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// Override menggabungkan rules default dengan spec dari konfigurasi
+// (format sama dengan ParseRules). Spec kosong berarti pakai default.
+func Override(defaults map[string]Rule, spec string) (map[string]Rule, error) {
+	overrides, err := ParseRules(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := map[string]Rule{}
+	for group, rule := range defaults {
+		rules[group] = rule
+	}
+	for group, rule := range overrides {
+		rules[group] = rule
+	}
+	return rules, nil
+}
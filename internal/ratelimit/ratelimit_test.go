@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock dipasang ke Limiter.now supaya refill bisa diuji tanpa sleep
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestLimiter(rule Rule) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	limiter := New(rule)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestLimiterTokenBucket(t *testing.T) {
+	// 2 request per menit, satu token terisi setiap 30 detik
+	limiter, clock := newTestLimiter(Rule{Requests: 2, Period: time.Minute})
+
+	steps := []struct {
+		advance time.Duration
+		key     string
+		want    Decision
+	}{
+		{0, "a", Decision{Allowed: true, Limit: 2, Remaining: 1, Reset: 30 * time.Second}},
+		{0, "a", Decision{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Minute}},
+		{0, "a", Decision{Allowed: false, Limit: 2, Remaining: 0, Reset: time.Minute, RetryAfter: 30 * time.Second}},
+		// Bucket lain tidak terpengaruh
+		{0, "b", Decision{Allowed: true, Limit: 2, Remaining: 1, Reset: 30 * time.Second}},
+		{15 * time.Second, "a", Decision{Allowed: false, Limit: 2, Remaining: 0, Reset: 45 * time.Second, RetryAfter: 15 * time.Second}},
+		{15 * time.Second, "a", Decision{Allowed: true, Limit: 2, Remaining: 0, Reset: time.Minute}},
+		// Bucket tidak pernah melebihi kapasitas walaupun lama tidak dipakai
+		{time.Hour, "a", Decision{Allowed: true, Limit: 2, Remaining: 1, Reset: 30 * time.Second}},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		if got := limiter.Allow(step.key); got != step.want {
+			t.Errorf("step %d: Allow(%q) = %+v, want %+v", i, step.key, got, step.want)
+		}
+	}
+}
+
+func TestOverride(t *testing.T) {
+	defaults := map[string]Rule{
+		"default": {Requests: 300, Period: time.Minute},
+		"auth":    {Requests: 5, Period: time.Minute},
+	}
+	rules, err := Override(defaults, "auth=1/1m, uploads=10/1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]Rule{
+		"default": {Requests: 300, Period: time.Minute},
+		"auth":    {Requests: 1, Period: time.Minute},
+		"uploads": {Requests: 10, Period: time.Hour},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("rules = %v", rules)
+	}
+	for group, rule := range want {
+		if got := rules[group]; got != rule {
+			t.Errorf("%s = %v, want %v", group, got, rule)
+		}
+	}
+	if defaults["auth"].Requests != 5 {
+		t.Error("Override changed the defaults")
+	}
+
+	for _, spec := range []string{"auth", "auth=5", "auth=0/1m", "=5/1m", "auth=5/soon"} {
+		if _, err := Override(defaults, spec); err == nil {
+			t.Errorf("Override(%q) accepted an invalid rule", spec)
+		}
+	}
+}
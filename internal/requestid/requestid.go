@@ -0,0 +1,73 @@
+// Package requestid memberi setiap request ID yang dikirim balik lewat
+// header X-Request-ID, dicatat di log dan dimasukkan ke response error.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const Header = "X-Request-ID"
+
+// maxLength membatasi ID dari client supaya tidak membengkakkan log
+const maxLength = 128
+
+const ginKey = "request.id"
+
+type contextKey struct{}
+
+// Middleware memakai X-Request-ID dari client kalau valid, atau membuat
+// ID baru. ID disimpan di gin.Context dan context milik request.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = New()
+		}
+
+		c.Set(ginKey, id)
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), id))
+		c.Header(Header, id)
+		c.Next()
+	}
+}
+
+// New membuat ID acak 16 byte dalam hex
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Get mengembalikan request ID, kosong kalau middleware tidak dipakai
+func Get(c *gin.Context) string {
+	return c.GetString(ginKey)
+}
+
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// valid hanya menerima karakter yang aman ditulis ke log dan header
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
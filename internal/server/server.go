@@ -0,0 +1,136 @@
+// Package server menjalankan http.Server dengan timeout dari konfigurasi,
+// graceful shutdown dan shutdown hook.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"e-commerce/internal/config"
+)
+
+// Hook dijalankan setelah semua request selesai, misalnya untuk menyimpan
+// data ke disk. Hook mendapat context sendiri yang dibuat setelah drain
+// selesai dan berakhir setelah shutdown timeout, jadi drain yang lama
+// tidak menghabiskan waktu hook.
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	fn   Hook
+}
+
+type Server struct {
+	http            *http.Server
+	tls             config.TLS
+	shutdownTimeout time.Duration
+	hooks           []namedHook
+}
+
+func New(cfg *config.Config, handler http.Handler) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.Timeouts.Read.Duration,
+			ReadHeaderTimeout: cfg.Timeouts.ReadHeader.Duration,
+			WriteTimeout:      cfg.Timeouts.Write.Duration,
+			IdleTimeout:       cfg.Timeouts.Idle.Duration,
+		},
+		tls:             cfg.TLS,
+		shutdownTimeout: cfg.Timeouts.Shutdown.Duration,
+	}
+}
+
+// OnShutdown mendaftarkan hook. Hook dijalankan berurutan dari yang
+// terakhir didaftarkan, seperti defer.
+func (s *Server) OnShutdown(name string, hook Hook) {
+	s.hooks = append(s.hooks, namedHook{name: name, fn: hook})
+}
+
+// OnStop mendaftarkan fungsi yang dipanggil begitu shutdown dimulai,
+// sebelum menunggu request selesai. Dipakai untuk memutus koneksi yang
+// tidak pernah selesai sendiri, misalnya stream SSE.
+func (s *Server) OnStop(f func()) {
+	s.http.RegisterOnShutdown(f)
+}
+
+// Run mendengarkan di alamat dari konfigurasi sampai SIGINT atau SIGTERM
+func (s *Server) Run() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve melayani request dari ln sampai ctx selesai, lalu menunggu request
+// yang sedang berjalan dan menjalankan shutdown hook.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.tls.Enabled() {
+			slog.Info("Listening", "url", "https://"+ln.Addr().String())
+			serveErr <- s.http.ServeTLS(ln, s.tls.CertFile, s.tls.KeyFile)
+		} else {
+			slog.Info("Listening", "url", "http://"+ln.Addr().String())
+			serveErr <- s.http.Serve(ln)
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		// Server berhenti sendiri, misalnya cert TLS tidak valid
+		return errors.Join(err, s.runHooks())
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", s.shutdownTimeout.String())
+	shutdownCtx := context.Background()
+	if s.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, s.shutdownTimeout)
+		defer cancel()
+	}
+
+	var errs []error
+	if err := s.http.Shutdown(shutdownCtx); err != nil {
+		// Request yang belum selesai diputus paksa
+		s.http.Close()
+		errs = append(errs, fmt.Errorf("drain requests: %w", err))
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+	errs = append(errs, s.runHooks())
+	return errors.Join(errs...)
+}
+
+func (s *Server) runHooks() error {
+	ctx := context.Background()
+	if s.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.shutdownTimeout)
+		defer cancel()
+	}
+
+	var errs []error
+	for i := len(s.hooks) - 1; i >= 0; i-- {
+		hook := s.hooks[i]
+		if err := hook.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown hook %s: %w", hook.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
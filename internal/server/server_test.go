@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"e-commerce/internal/config"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	cfg := config.Defaults(nil)
+	srv := New(&cfg, handler)
+
+	var order []string
+	srv.OnShutdown("first", func(ctx context.Context) error { order = append(order, "first"); return nil })
+	srv.OnShutdown("second", func(ctx context.Context) error { order = append(order, "second"); return nil })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	got := <-response
+	if got.err != nil || got.body != "done" {
+		t.Fatalf("in-flight request = %q, %v; want it to complete", got.body, got.err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+	if len(order) != 2 || order[0] != "second" || order[1] != "first" {
+		t.Errorf("hooks ran in order %v, want [second first]", order)
+	}
+}
+
+func TestHooksGetFreshContextAfterSlowDrain(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+	})
+
+	cfg := config.Defaults(nil)
+	cfg.Timeouts.Shutdown = config.Duration{Duration: 100 * time.Millisecond}
+	srv := New(&cfg, handler)
+
+	// Drain melewati shutdown timeout, hook tetap mendapat waktu penuh
+	var hookErr error
+	srv.OnShutdown("save", func(ctx context.Context) error {
+		hookErr = ctx.Err()
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+	go http.Get("http://" + ln.Addr().String())
+
+	<-started
+	cancel()
+	if err := <-served; err == nil {
+		t.Error("Serve returned nil although the drain timed out")
+	}
+	if hookErr != nil {
+		t.Errorf("hook context already done: %v", hookErr)
+	}
+}
@@ -0,0 +1,80 @@
+package shipping
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Perjalanan paket di Fake. Checkpoint ke-n tercapai n*step setelah paket
+// diserahkan.
+var fakeRoute = []Checkpoint{
+	{Status: StatusShipped, Description: "Parcel received by carrier", Location: "Origin hub"},
+	{Status: StatusInTransit, Description: "Parcel in transit to destination city", Location: "Sorting center"},
+	{Status: StatusOutForDelivery, Description: "Parcel out for delivery"},
+	{Status: StatusDelivered, Description: "Parcel delivered to recipient"},
+}
+
+type fakeShipment struct {
+	parcel    Parcel
+	createdAt time.Time
+}
+
+// Fake adalah kurir di memory untuk development dan test. Paket bergerak
+// satu checkpoint setiap step sampai diterima.
+type Fake struct {
+	step time.Duration
+	now  func() time.Time
+
+	mu        sync.Mutex
+	shipments map[string]fakeShipment
+	nextID    int
+}
+
+func NewFake(step time.Duration) *Fake {
+	return &Fake{step: step, now: time.Now, shipments: make(map[string]fakeShipment)}
+}
+
+func (f *Fake) Name() string {
+	return CarrierFake
+}
+
+func (f *Fake) CreateShipment(ctx context.Context, parcel Parcel) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	tracking := fmt.Sprintf("FAKE%08d", f.nextID)
+	f.shipments[tracking] = fakeShipment{parcel: parcel, createdAt: f.now()}
+	return tracking, nil
+}
+
+func (f *Fake) Track(ctx context.Context, tracking string) ([]Checkpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	shipment, ok := f.shipments[tracking]
+	f.mu.Unlock()
+	if !ok {
+		return nil, ErrTrackingNotFound
+	}
+
+	elapsed := f.now().Sub(shipment.createdAt)
+	var checkpoints []Checkpoint
+	for i, checkpoint := range fakeRoute {
+		at := shipment.createdAt.Add(time.Duration(i) * f.step)
+		if i > 0 && elapsed < time.Duration(i)*f.step {
+			break
+		}
+		checkpoint.At = at
+		if checkpoint.Status == StatusOutForDelivery || checkpoint.Status == StatusDelivered {
+			checkpoint.Location = shipment.parcel.Address.City
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+	return checkpoints, nil
+}
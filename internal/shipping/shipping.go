@@ -0,0 +1,127 @@
+// Package shipping menghitung ongkos kirim dari tabel tarif per zona dan
+// berat, dan mendefinisikan interface ke kurir. Server hanya bicara ke
+// Carrier, jadi kurir asli bisa dipasang tanpa mengubah alur shipment.
+package shipping
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Nama kurir yang didukung, lihat config shipping.carrier
+const CarrierFake = "fake"
+
+var Carriers = []string{CarrierFake}
+
+// Status shipment, berurutan dari dibuat sampai diterima
+const (
+	StatusPending        = "pending"
+	StatusShipped        = "shipped"
+	StatusInTransit      = "in_transit"
+	StatusOutForDelivery = "out_for_delivery"
+	StatusDelivered      = "delivered"
+)
+
+var ErrTrackingNotFound = errors.New("tracking number not found")
+
+// Address adalah alamat pengiriman. Country adalah kode ISO 3166 dua
+// huruf dan menentukan zona tarif.
+type Address struct {
+	Name       string `json:"name" validate:"required,max=200"`
+	Phone      string `json:"phone" validate:"max=30"`
+	Line1      string `json:"line1" validate:"required,max=200"`
+	Line2      string `json:"line2" validate:"max=200"`
+	City       string `json:"city" validate:"required,max=100"`
+	PostalCode string `json:"postal_code" validate:"required,max=20"`
+	Country    string `json:"country" validate:"required,country_code"`
+}
+
+// Dimensions ukuran kemasan satu unit dalam cm
+type Dimensions struct {
+	LengthCM float64 `json:"length_cm" validate:"gt=0"`
+	WidthCM  float64 `json:"width_cm" validate:"gt=0"`
+	HeightCM float64 `json:"height_cm" validate:"gt=0"`
+}
+
+// Divisor berat volumetrik yang umum dipakai kurir: cm³ / 6000 = kg
+const volumetricDivisor = 6000
+
+// ChargeableGrams adalah berat yang ditagih untuk quantity unit: berat
+// asli atau berat volumetrik, mana yang lebih besar.
+func ChargeableGrams(weightGrams int, dimensions *Dimensions, quantity int) int {
+	grams := float64(weightGrams)
+	if dimensions != nil {
+		volume := dimensions.LengthCM * dimensions.WidthCM * dimensions.HeightCM
+		grams = math.Max(grams, volume/volumetricDivisor*1000)
+	}
+	return int(math.Ceil(grams)) * quantity
+}
+
+// Rate adalah ongkos kirim untuk paket sampai MaxWeightGrams
+type Rate struct {
+	MaxWeightGrams int     `json:"max_weight_grams" validate:"gt=0"`
+	Cost           float64 `json:"cost" validate:"gte=0"`
+}
+
+// Zone adalah kumpulan negara dengan tabel tarif yang sama. Cost dalam
+// mata uang dasar toko.
+type Zone struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name" validate:"required,max=100"`
+	Countries []string `json:"countries" validate:"required,min=1,dive,country_code"`
+	Rates     []Rate   `json:"rates" validate:"required,min=1,dive"`
+}
+
+// SortRates mengurutkan tarif dari berat terkecil, dipanggil sebelum
+// zona disimpan
+func (z *Zone) SortRates() {
+	sort.Slice(z.Rates, func(i, j int) bool { return z.Rates[i].MaxWeightGrams < z.Rates[j].MaxWeightGrams })
+}
+
+// Covers true kalau country termasuk zona ini
+func (z Zone) Covers(country string) bool {
+	for _, c := range z.Countries {
+		if c == country {
+			return true
+		}
+	}
+	return false
+}
+
+// Cost mengembalikan tarif pertama yang bisa mengangkut grams. Rates
+// harus sudah diurutkan dengan SortRates.
+func (z Zone) Cost(grams int) (float64, bool) {
+	for _, rate := range z.Rates {
+		if grams <= rate.MaxWeightGrams {
+			return rate.Cost, true
+		}
+	}
+	return 0, false
+}
+
+// Parcel adalah paket yang diserahkan ke kurir
+type Parcel struct {
+	Reference   string
+	Address     Address
+	WeightGrams int
+}
+
+// Checkpoint adalah satu status dari kurir
+type Checkpoint struct {
+	Status      string    `json:"status"`
+	Description string    `json:"description"`
+	Location    string    `json:"location,omitempty"`
+	At          time.Time `json:"at"`
+}
+
+// Carrier adalah kurir. CreateShipment menyerahkan paket dan
+// mengembalikan nomor resi; Track mengembalikan semua checkpoint sejauh
+// ini, terlama lebih dulu. Semua method harus berhenti kalau ctx selesai.
+type Carrier interface {
+	Name() string
+	CreateShipment(ctx context.Context, parcel Parcel) (tracking string, err error)
+	Track(ctx context.Context, tracking string) ([]Checkpoint, error)
+}
@@ -0,0 +1,71 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestZoneCost(t *testing.T) {
+	zone := Zone{Countries: []string{"ID"}, Rates: []Rate{
+		{MaxWeightGrams: 5000, Cost: 40000},
+		{MaxWeightGrams: 1000, Cost: 15000},
+	}}
+	zone.SortRates()
+
+	for _, tc := range []struct {
+		weight     int
+		dimensions *Dimensions
+		quantity   int
+		cost       float64
+		ok         bool
+	}{
+		{300, nil, 3, 15000, true},
+		{300, nil, 4, 40000, true},
+		// 30x20x10 cm = 1000 g volumetrik, lebih berat dari 200 g
+		{200, &Dimensions{LengthCM: 30, WidthCM: 20, HeightCM: 10}, 1, 15000, true},
+		{200, &Dimensions{LengthCM: 30, WidthCM: 20, HeightCM: 10}, 2, 40000, true},
+		{6000, nil, 1, 0, false},
+	} {
+		grams := ChargeableGrams(tc.weight, tc.dimensions, tc.quantity)
+		cost, ok := zone.Cost(grams)
+		if cost != tc.cost || ok != tc.ok {
+			t.Errorf("%d g x %d (%v): cost %v, %v; want %v, %v", tc.weight, tc.quantity, tc.dimensions, cost, ok, tc.cost, tc.ok)
+		}
+	}
+}
+
+func TestFakeTracking(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(time.Hour)
+	f.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	tracking, err := f.CreateShipment(ctx, Parcel{Reference: "1", Address: Address{City: "Bandung"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		after time.Duration
+		want  string
+		count int
+	}{
+		{0, StatusShipped, 1},
+		{90 * time.Minute, StatusInTransit, 2},
+		{10 * time.Hour, StatusDelivered, 4},
+	} {
+		now = now.Add(tc.after)
+		checkpoints, err := f.Track(ctx, tracking)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(checkpoints) != tc.count || checkpoints[len(checkpoints)-1].Status != tc.want {
+			t.Errorf("after %s: %+v", tc.after, checkpoints)
+		}
+	}
+
+	if _, err := f.Track(ctx, "FAKE0"); !errors.Is(err, ErrTrackingNotFound) {
+		t.Errorf("unknown tracking: %v", err)
+	}
+}
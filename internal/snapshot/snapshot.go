@@ -0,0 +1,314 @@
+// Package snapshot menyimpan seluruh isi storage memory ke satu file JSON
+// berversi. File ditulis ke file sementara lalu di-rename, jadi snapshot
+// lama tetap utuh kalau proses mati di tengah penulisan.
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("snapshot not found")
+	ErrClosed   = errors.New("snapshot store is closed")
+)
+
+// Info adalah metadata snapshot; Size adalah ukuran file dalam byte
+type Info struct {
+	App       string    `json:"app"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// file adalah isi file snapshot. Data ditulis terakhir supaya header
+// terbaca di awal file.
+type file struct {
+	App       string          `json:"app"`
+	Version   int             `json:"version"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Migration mengubah data dari satu versi ke versi berikutnya
+type Migration func(data json.RawMessage) (json.RawMessage, error)
+
+type Options struct {
+	// Path file snapshot. Folder dibuat kalau belum ada.
+	Path string
+	// App ditulis di file dan dicek saat load supaya snapshot server lain
+	// tidak termuat.
+	App string
+	// Version adalah versi format Data. Snapshot dengan versi lebih baru
+	// ditolak; versi lama diubah lewat Migrations[versi lama].
+	Version    int
+	Migrations map[int]Migration
+	// Interval snapshot berkala. 0 berarti hanya saat diminta dan saat
+	// Close.
+	Interval time.Duration
+	// Capture mengembalikan state yang disimpan. Pemanggil bertanggung
+	// jawab mengunci state supaya hasilnya konsisten.
+	Capture func() interface{}
+	Logger  *slog.Logger
+}
+
+type Store struct {
+	opts Options
+
+	// mu menjaga agar hanya satu snapshot ditulis pada satu waktu
+	mu       sync.Mutex
+	lastHash [sha256.Size]byte
+	started  bool
+	closed   bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func New(opts Options) *Store {
+	if opts.Version <= 0 {
+		opts.Version = 1
+	}
+	return &Store{opts: opts, stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (s *Store) logger() *slog.Logger {
+	if s.opts.Logger != nil {
+		return s.opts.Logger
+	}
+	return slog.Default()
+}
+
+// Path mengembalikan lokasi file snapshot
+func (s *Store) Path() string {
+	return s.opts.Path
+}
+
+// Load membaca snapshot ke v. ErrNotFound kalau file belum ada.
+func (s *Store) Load(v interface{}) (Info, error) {
+	raw, err := os.ReadFile(s.opts.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Info{}, ErrNotFound
+	}
+	if err != nil {
+		return Info{}, err
+	}
+
+	var f file
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return Info{}, fmt.Errorf("parse snapshot %s: %w", s.opts.Path, err)
+	}
+	if f.App != s.opts.App {
+		return Info{}, fmt.Errorf("snapshot %s belongs to %q, not %q", s.opts.Path, f.App, s.opts.App)
+	}
+	if f.Version > s.opts.Version {
+		return Info{}, fmt.Errorf("snapshot %s has version %d, this server supports up to %d", s.opts.Path, f.Version, s.opts.Version)
+	}
+	info := Info{App: f.App, Version: f.Version, CreatedAt: f.CreatedAt, Size: int64(len(raw))}
+	for version := f.Version; version < s.opts.Version; version++ {
+		migrate, ok := s.opts.Migrations[version]
+		if !ok {
+			return Info{}, fmt.Errorf("snapshot %s: no migration from version %d", s.opts.Path, version)
+		}
+		if f.Data, err = migrate(f.Data); err != nil {
+			return Info{}, fmt.Errorf("snapshot %s: migrate version %d: %w", s.opts.Path, version, err)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(f.Data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return Info{}, fmt.Errorf("parse snapshot %s: %w", s.opts.Path, err)
+	}
+
+	// Snapshot berkala berikutnya tidak perlu ditulis kalau tidak ada perubahan
+	s.mu.Lock()
+	if data, err := json.Marshal(v); err == nil {
+		s.lastHash = sha256.Sum256(data)
+	}
+	s.mu.Unlock()
+
+	return info, nil
+}
+
+// Save menulis snapshot sekarang, walaupun state tidak berubah
+func (s *Store) Save() (Info, error) {
+	info, _, err := s.save(true)
+	return info, err
+}
+
+// Write menulis v sebagai snapshot tanpa memanggil Capture. Dipakai kalau
+// pemanggil perlu tahu persis state mana yang tersimpan, misalnya saat
+// compaction journal.
+func (s *Store) Write(v interface{}) (Info, error) {
+	info, _, err := s.write(v, true)
+	return info, err
+}
+
+// save mengembalikan written false kalau state sama dengan snapshot
+// terakhir dan force tidak diset
+func (s *Store) save(force bool) (Info, bool, error) {
+	return s.write(s.opts.Capture(), force)
+}
+
+func (s *Store) write(v interface{}, force bool) (Info, bool, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Info{}, false, err
+	}
+	hash := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !force && hash == s.lastHash {
+		return Info{}, false, nil
+	}
+
+	f := file{App: s.opts.App, Version: s.opts.Version, CreatedAt: time.Now().UTC(), Data: data}
+	raw, err := json.Marshal(f)
+	if err != nil {
+		return Info{}, false, err
+	}
+	if err := writeFileAtomic(s.opts.Path, raw); err != nil {
+		return Info{}, false, fmt.Errorf("write snapshot: %w", err)
+	}
+	s.lastHash = hash
+	return Info{App: f.App, Version: f.Version, CreatedAt: f.CreatedAt, Size: int64(len(raw))}, true, nil
+}
+
+// Open membuka file snapshot terakhir untuk di-download. File tetap bisa
+// dibaca walaupun snapshot baru menggantikannya lewat rename.
+func (s *Store) Open() (*os.File, Info, error) {
+	f, err := os.Open(s.opts.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, Info{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Info{}, err
+	}
+
+	var header file
+	dec := json.NewDecoder(f)
+	if err := dec.Decode(&header); err != nil {
+		f.Close()
+		return nil, Info{}, fmt.Errorf("parse snapshot %s: %w", s.opts.Path, err)
+	}
+	stat, err := f.Stat()
+	if err == nil {
+		_, err = f.Seek(0, 0)
+	}
+	if err != nil {
+		f.Close()
+		return nil, Info{}, err
+	}
+	return f, Info{App: header.App, Version: header.Version, CreatedAt: header.CreatedAt, Size: stat.Size()}, nil
+}
+
+// Start menjalankan snapshot berkala sampai Close
+func (s *Store) Start() {
+	s.mu.Lock()
+	s.started = true
+	s.mu.Unlock()
+
+	if s.opts.Interval <= 0 {
+		close(s.done)
+		return
+	}
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				info, written, err := s.save(false)
+				if err != nil {
+					s.logger().Error("Periodic snapshot failed", "path", s.opts.Path, "error", err)
+				} else if written {
+					s.logger().Debug("Snapshot written", "path", s.opts.Path, "size", info.Size)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close menghentikan snapshot berkala lalu menulis snapshot terakhir.
+// Dipakai sebagai shutdown hook setelah semua request selesai.
+func (s *Store) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	s.closed = true
+	started := s.started
+	s.mu.Unlock()
+
+	close(s.stop)
+	if started {
+		select {
+		case <-s.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	info, written, err := s.save(false)
+	if err != nil {
+		return err
+	}
+	if written {
+		s.logger().Info("Snapshot written", "path", s.opts.Path, "size", info.Size)
+	}
+	return nil
+}
+
+// writeFileAtomic menulis ke file sementara di folder yang sama lalu
+// me-rename-nya, dan fsync folder supaya rename tidak hilang saat crash
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type state struct {
+	Items  []string `json:"items"`
+	NextID int      `json:"next_id"`
+}
+
+// memory meniru storage server yang dibaca Capture
+type memory struct {
+	mu    sync.Mutex
+	state state
+}
+
+func (m *memory) capture() interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return state{Items: append([]string{}, m.state.Items...), NextID: m.state.NextID}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "app.json")
+	m := &memory{state: state{Items: []string{"a", "b"}, NextID: 3}}
+	store := New(Options{Path: path, App: "test", Capture: m.capture})
+
+	if _, err := store.Load(&state{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("load before save: %v", err)
+	}
+
+	info, err := store.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != 1 || info.Size == 0 {
+		t.Errorf("info = %+v", info)
+	}
+	// Tidak ada file sementara yang tertinggal
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("files in snapshot dir: %v", entries)
+	}
+
+	var loaded state
+	if _, err := New(Options{Path: path, App: "test"}).Load(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(loaded.Items, ",") != "a,b" || loaded.NextID != 3 {
+		t.Errorf("loaded %+v", loaded)
+	}
+
+	// Snapshot server lain ditolak
+	if _, err := New(Options{Path: path, App: "other"}).Load(&loaded); err == nil {
+		t.Error("expected error for snapshot of another app")
+	}
+}
+
+func TestVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	old := `{"app":"test","version":1,"created_at":"2024-01-01T00:00:00Z","data":{"names":["a"],"next_id":2}}`
+	if err := os.WriteFile(path, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Versi 1 memakai key "names", versi 2 "items"
+	migrations := map[int]Migration{
+		1: func(data json.RawMessage) (json.RawMessage, error) {
+			var v1 struct {
+				Names  []string `json:"names"`
+				NextID int      `json:"next_id"`
+			}
+			if err := json.Unmarshal(data, &v1); err != nil {
+				return nil, err
+			}
+			return json.Marshal(state{Items: v1.Names, NextID: v1.NextID})
+		},
+	}
+
+	var loaded state
+	info, err := New(Options{Path: path, App: "test", Version: 2, Migrations: migrations}).Load(&loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != 1 || len(loaded.Items) != 1 || loaded.NextID != 2 {
+		t.Errorf("info %+v, loaded %+v", info, loaded)
+	}
+
+	if _, err := New(Options{Path: path, App: "test", Version: 2}).Load(&loaded); err == nil || !strings.Contains(err.Error(), "no migration") {
+		t.Errorf("missing migration: %v", err)
+	}
+
+	newer := strings.Replace(old, `"version":1`, `"version":3`, 1)
+	os.WriteFile(path, []byte(newer), 0o600)
+	if _, err := New(Options{Path: path, App: "test", Version: 2}).Load(&loaded); err == nil || !strings.Contains(err.Error(), "supports up to 2") {
+		t.Errorf("newer version: %v", err)
+	}
+}
+
+func TestCloseWritesOnlyChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	m := &memory{state: state{Items: []string{"a"}, NextID: 2}}
+	os.WriteFile(path, []byte(`{"app":"test","version":1,"created_at":"2024-01-01T00:00:00Z","data":{"items":["a"],"next_id":2}}`), 0o600)
+
+	store := New(Options{Path: path, App: "test", Capture: m.capture})
+	var loaded state
+	if _, err := store.Load(&loaded); err != nil {
+		t.Fatal(err)
+	}
+	store.Start()
+
+	m.mu.Lock()
+	m.state.Items = append(m.state.Items, "b")
+	m.mu.Unlock()
+
+	if err := store.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("second close: %v", err)
+	}
+
+	f, info, err := store.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if info.CreatedAt.Year() == 2024 {
+		t.Error("snapshot was not rewritten after a change")
+	}
+
+	// Tanpa perubahan, snapshot yang sama tidak ditulis ulang
+	unchanged := New(Options{Path: path, App: "test", Capture: m.capture})
+	unchanged.Load(&loaded)
+	if _, written, err := unchanged.save(false); err != nil || written {
+		t.Errorf("written = %v, err = %v", written, err)
+	}
+}
@@ -0,0 +1,245 @@
+// Package stream mengirim event ke client lewat Server-Sent Events.
+// Broker menyimpan event terakhir di buffer supaya client yang reconnect
+// dengan Last-Event-ID bisa melanjutkan tanpa kehilangan event.
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"e-commerce/internal/apierror"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderLastEventID dikirim browser otomatis saat EventSource reconnect
+const HeaderLastEventID = "Last-Event-ID"
+
+var ErrClosed = errors.New("stream is closed")
+
+// Message adalah satu event di stream. ID menentukan urutan resume; Attrs dipakai
+// untuk filter dan tidak dikirim ke client.
+type Message struct {
+	ID    int64
+	Event string
+	Data  json.RawMessage
+	Attrs map[string]string
+}
+
+// Filter menentukan apakah message dikirim ke client
+type Filter func(Message) bool
+
+// Match membuat filter yang cocok kalau semua attr bernilai sama. Nilai
+// kosong berarti tidak difilter.
+func Match(attrs map[string]string) Filter {
+	return func(m Message) bool {
+		for key, value := range attrs {
+			if value != "" && m.Attrs[key] != value {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+type Options struct {
+	// BufferSize adalah jumlah event terakhir yang bisa di-resume.
+	// Default 1000.
+	BufferSize int
+	// Heartbeat adalah interval komentar kosong supaya proxy tidak menutup
+	// koneksi yang diam. Default 15 detik.
+	Heartbeat time.Duration
+	// Retry adalah jeda reconnect yang disarankan ke client. Default 3 detik.
+	Retry time.Duration
+}
+
+type subscriber struct {
+	ch     chan Message
+	filter Filter
+}
+
+type Broker struct {
+	opts Options
+
+	mu     sync.Mutex
+	buffer []Message
+	subs   map[*subscriber]struct{}
+	closed bool
+}
+
+func NewBroker(opts Options) *Broker {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 1000
+	}
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = 15 * time.Second
+	}
+	if opts.Retry <= 0 {
+		opts.Retry = 3 * time.Second
+	}
+	return &Broker{opts: opts, subs: make(map[*subscriber]struct{})}
+}
+
+// Publish menyimpan message di buffer dan mengirimnya ke semua client yang
+// cocok. Client yang terlalu lambat diputus; client bisa reconnect dan
+// melanjutkan dari buffer.
+func (b *Broker) Publish(m Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	// Subscriber event bus bisa dipanggil dari beberapa request sekaligus,
+	// jadi buffer dijaga tetap urut berdasarkan ID untuk resume
+	i := sort.Search(len(b.buffer), func(i int) bool { return b.buffer[i].ID > m.ID })
+	b.buffer = append(b.buffer, Message{})
+	copy(b.buffer[i+1:], b.buffer[i:])
+	b.buffer[i] = m
+	if len(b.buffer) > b.opts.BufferSize {
+		b.buffer = append([]Message(nil), b.buffer[len(b.buffer)-b.opts.BufferSize:]...)
+	}
+
+	for sub := range b.subs {
+		if !sub.filter(m) {
+			continue
+		}
+		select {
+		case sub.ch <- m:
+		default:
+			close(sub.ch)
+			delete(b.subs, sub)
+		}
+	}
+}
+
+// subscribe mengembalikan event di buffer setelah lastID beserta
+// subscriber untuk event berikutnya, di bawah lock yang sama supaya tidak
+// ada event yang terlewat atau terkirim dua kali
+func (b *Broker) subscribe(lastID int64, filter Filter) ([]Message, *subscriber, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, ErrClosed
+	}
+
+	var backlog []Message
+	if lastID > 0 {
+		for _, m := range b.buffer {
+			if m.ID > lastID && filter(m) {
+				backlog = append(backlog, m)
+			}
+		}
+	}
+
+	sub := &subscriber{ch: make(chan Message, 64), filter: filter}
+	b.subs[sub] = struct{}{}
+	return backlog, sub, nil
+}
+
+func (b *Broker) unsubscribe(sub *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subs[sub]; ok {
+		close(sub.ch)
+		delete(b.subs, sub)
+	}
+}
+
+// Clients mengembalikan jumlah client yang sedang terhubung
+func (b *Broker) Clients() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Close memutus semua client. Dipanggil saat shutdown dimulai karena
+// stream tidak pernah selesai sendiri.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subs {
+		close(sub.ch)
+		delete(b.subs, sub)
+	}
+}
+
+// LastEventID membaca header Last-Event-ID, atau query last_event_id untuk
+// client yang tidak bisa mengatur header. Kosong berarti 0.
+func LastEventID(c *gin.Context) (int64, error) {
+	value := c.GetHeader(HeaderLastEventID)
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("Last-Event-ID must be a non-negative integer")
+	}
+	return id, nil
+}
+
+// Serve mengirim event yang cocok dengan filter sampai client menutup
+// koneksi atau broker ditutup. Event setelah lastID yang masih ada di
+// buffer dikirim lebih dulu.
+func (b *Broker) Serve(c *gin.Context, lastID int64, filter Filter) {
+	backlog, sub, err := b.subscribe(lastID, filter)
+	if err != nil {
+		apierror.Abort(c, http.StatusServiceUnavailable, "Server is shutting down", apierror.New(apierror.CodeUnavailable, err.Error()))
+		return
+	}
+	defer b.unsubscribe(sub)
+
+	// Stream boleh berjalan lebih lama dari write timeout server
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", sse.ContentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	c.Writer.WriteString("retry: " + strconv.FormatInt(b.opts.Retry.Milliseconds(), 10) + "\n\n")
+	for _, m := range backlog {
+		write(c, m)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(b.opts.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case m, ok := <-sub.ch:
+			if !ok {
+				return
+			}
+			write(c, m)
+		case <-heartbeat.C:
+			c.Writer.WriteString(": heartbeat\n\n")
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+func write(c *gin.Context, m Message) {
+	sse.Encode(c.Writer, sse.Event{Id: strconv.FormatInt(m.ID, 10), Event: m.Event, Data: m.Data})
+}
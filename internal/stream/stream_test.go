@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestServer(t *testing.T, b *Broker) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/events", func(c *gin.Context) {
+		lastID, err := LastEventID(c)
+		if err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		b.Serve(c, lastID, Match(map[string]string{"product_id": c.Query("product_id")}))
+	})
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func message(id int64, productID string) Message {
+	data, _ := json.Marshal(map[string]string{"product_id": productID})
+	return Message{ID: id, Event: "product.stock_changed", Data: data, Attrs: map[string]string{"product_id": productID}}
+}
+
+// readIDs membaca stream sampai mendapat n baris "id:" atau stream selesai
+func readIDs(t *testing.T, scanner *bufio.Scanner, n int) []string {
+	t.Helper()
+	var ids []string
+	for len(ids) < n && scanner.Scan() {
+		if id, ok := strings.CutPrefix(scanner.Text(), "id:"); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func waitForClients(t *testing.T, b *Broker, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Clients() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("clients = %d, want %d", b.Clients(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestResumeFromLastEventIDWithFilter(t *testing.T) {
+	b := NewBroker(Options{BufferSize: 3, Heartbeat: time.Hour})
+	srv := newTestServer(t, b)
+
+	// ID 1 sudah keluar dari buffer
+	for i, product := range []string{"1", "1", "2", "1"} {
+		b.Publish(message(int64(i+1), product))
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/events?product_id=1", nil)
+	req.Header.Set(HeaderLastEventID, "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	if ids := readIDs(t, scanner, 2); strings.Join(ids, ",") != "2,4" {
+		t.Fatalf("backlog ids = %v, want [2 4]", ids)
+	}
+
+	waitForClients(t, b, 1)
+	b.Publish(message(5, "2"))
+	b.Publish(message(6, "1"))
+	if ids := readIDs(t, scanner, 1); len(ids) != 1 || ids[0] != "6" {
+		t.Fatalf("live ids = %v, want [6]", ids)
+	}
+
+	// Close memutus stream supaya shutdown tidak menunggu
+	b.Close()
+	if ids := readIDs(t, scanner, 1); len(ids) != 0 {
+		t.Errorf("unexpected event after close: %v", ids)
+	}
+	if b.Clients() != 0 {
+		t.Errorf("clients after close = %d", b.Clients())
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	b := NewBroker(Options{Heartbeat: 10 * time.Millisecond})
+	srv := newTestServer(t, b)
+	defer b.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if scanner.Text() == ": heartbeat" {
+			return
+		}
+	}
+	t.Fatal("stream ended without heartbeat")
+}
@@ -0,0 +1,224 @@
+// Package validation menjalankan aturan validasi yang ditulis di struct tag
+// `validate:"..."` dan mengubah hasilnya menjadi apierror.FieldError.
+//
+// Aturan bawaan yang sering dipakai: required, gt, gte, lt, lte, min, max,
+// len, email, oneof, dan aturan antar field seperti gtfield atau
+// required_unless. Aturan regex dan cek custom (contoh "source_exists")
+// didaftarkan oleh masing-masing server.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"e-commerce/internal/apierror"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldLevel dipakai oleh aturan custom untuk membaca nilai field dan
+// struct induknya.
+type FieldLevel = validator.FieldLevel
+
+// StructLevel dipakai oleh aturan yang melibatkan beberapa field sekaligus
+type StructLevel = validator.StructLevel
+
+// Validator membungkus go-playground/validator dengan pesan error yang
+// konsisten dengan response API.
+type Validator struct {
+	v        *validator.Validate
+	messages map[string]string
+}
+
+func New() *Validator {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Pakai nama field dari tag json supaya sama dengan body request
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	return &Validator{v: v, messages: map[string]string{}}
+}
+
+// RegisterRule mendaftarkan aturan custom. Message adalah format dengan
+// satu %s untuk label field, contoh "%s not found".
+func (v *Validator) RegisterRule(tag, message string, fn func(fl FieldLevel) bool) {
+	if err := v.v.RegisterValidation(tag, fn); err != nil {
+		panic(err)
+	}
+	v.messages[tag] = message
+}
+
+// RegisterPattern mendaftarkan aturan regex dengan nama tag tertentu.
+// Field kosong dianggap valid, gabungkan dengan required kalau wajib.
+func (v *Validator) RegisterPattern(tag, pattern, message string) {
+	re := regexp.MustCompile(pattern)
+	v.RegisterRule(tag, message, func(fl FieldLevel) bool {
+		value := fl.Field().String()
+		return value == "" || re.MatchString(value)
+	})
+}
+
+// RegisterStructRule mendaftarkan cek yang melibatkan beberapa field.
+// Fungsi melaporkan error dengan sl.ReportError memakai tag yang pesannya
+// didaftarkan lewat RegisterMessage.
+func (v *Validator) RegisterStructRule(fn func(sl StructLevel), types ...interface{}) {
+	v.v.RegisterStructValidation(fn, types...)
+}
+
+// RegisterMessage mengatur pesan untuk tag yang dilaporkan struct rule
+func (v *Validator) RegisterMessage(tag, message string) {
+	v.messages[tag] = message
+}
+
+// Struct memvalidasi semua field dan mengembalikan semua pelanggaran
+// sekaligus, nil kalau valid.
+func (v *Validator) Struct(s interface{}) []apierror.FieldError {
+	err := v.v.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []apierror.FieldError{apierror.Field("", "invalid", err.Error())}
+	}
+
+	fields := make([]apierror.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, apierror.Field(fieldPath(fe), fe.Tag(), v.message(fe)))
+	}
+	return fields
+}
+
+// fieldPath membuang nama struct paling luar, contoh
+// "BulkRequest.operations[0].id" menjadi "operations[0].id"
+func fieldPath(fe validator.FieldError) string {
+	_, path, ok := strings.Cut(fe.Namespace(), ".")
+	if !ok {
+		return fe.Field()
+	}
+	return path
+}
+
+func (v *Validator) message(fe validator.FieldError) string {
+	label := Label(fe.StructField())
+	if format, ok := v.messages[fe.Tag()]; ok {
+		if !strings.Contains(format, "%s") {
+			return format
+		}
+		return fmt.Sprintf(format, label)
+	}
+
+	param := fe.Param()
+	kind := fe.Kind()
+	if kind == reflect.Ptr {
+		kind = fe.Type().Elem().Kind()
+	}
+	isNumber := kind >= reflect.Int && kind <= reflect.Float64
+	isString := kind == reflect.String
+
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return label + " is required"
+	case "gt":
+		if isString {
+			return fmt.Sprintf("%s must be longer than %s characters", label, param)
+		}
+		return fmt.Sprintf("%s must be greater than %s", label, param)
+	case "gte":
+		return fmt.Sprintf("%s must be greater than or equal to %s", label, param)
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", label, param)
+	case "lte":
+		return fmt.Sprintf("%s must be less than or equal to %s", label, param)
+	case "min":
+		switch {
+		case isNumber:
+			return fmt.Sprintf("%s must be at least %s", label, param)
+		case isString:
+			return fmt.Sprintf("%s must be at least %s characters long", label, param)
+		}
+		return fmt.Sprintf("%s must contain at least %s %s", label, param, items(param))
+	case "max":
+		switch {
+		case isNumber:
+			return fmt.Sprintf("%s must be at most %s", label, param)
+		case isString:
+			return fmt.Sprintf("%s must be at most %s characters long", label, param)
+		}
+		return fmt.Sprintf("%s must contain at most %s %s", label, param, items(param))
+	case "len":
+		return fmt.Sprintf("%s must be exactly %s characters long", label, param)
+	case "email":
+		return label + " must be a valid email address"
+	case "url", "http_url":
+		return label + " must be a valid URL"
+	case "oneof":
+		return fmt.Sprintf("%s must be one of %s", label, strings.ReplaceAll(param, " ", ", "))
+	case "eqfield":
+		return fmt.Sprintf("%s must be equal to %s", label, Label(param))
+	case "nefield":
+		return fmt.Sprintf("%s must be different from %s", label, Label(param))
+	case "gtfield":
+		return fmt.Sprintf("%s must be greater than %s", label, Label(param))
+	case "gtefield":
+		return fmt.Sprintf("%s must be greater than or equal to %s", label, Label(param))
+	case "ltfield":
+		return fmt.Sprintf("%s must be less than %s", label, Label(param))
+	case "ltefield":
+		return fmt.Sprintf("%s must be less than or equal to %s", label, Label(param))
+	}
+	return fmt.Sprintf("%s failed the %s rule", label, fe.Tag())
+}
+
+func items(count string) string {
+	if count == "1" {
+		return "item"
+	}
+	return "items"
+}
+
+// Label mengubah nama field Go menjadi label untuk pesan error,
+// contoh "SourceID" menjadi "Source ID" dan "GracePeriod" menjadi
+// "Grace period".
+func Label(name string) string {
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		prev := runes[i-1]
+		nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+		if unicode.IsLower(prev) || (unicode.IsUpper(prev) && nextLower) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(runes[start:]))
+
+	// Kata selain yang pertama dibuat huruf kecil, kecuali singkatan seperti ID
+	for i := 1; i < len(words); i++ {
+		if strings.ToUpper(words[i]) != words[i] {
+			words[i] = strings.ToLower(words[i])
+		}
+	}
+	return strings.Join(words, " ")
+}
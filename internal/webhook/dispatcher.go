@@ -0,0 +1,620 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Options mengatur pengiriman. Nilai 0 diganti default.
+type Options struct {
+	MaxAttempts   int           // default 6
+	BaseBackoff   time.Duration // jeda sebelum retry pertama, default 1s
+	MaxBackoff    time.Duration // default 5m
+	Timeout       time.Duration // per percobaan, default 10s
+	Workers       int           // default 4
+	MaxDeliveries int           // delivery log per subscription, default 500
+	Client        *http.Client
+	Logger        *slog.Logger // default slog.Default() saat log ditulis
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 6
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Minute
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+	if o.MaxDeliveries <= 0 {
+		o.MaxDeliveries = 500
+	}
+	if o.Client == nil {
+		o.Client = &http.Client{}
+	}
+	return o
+}
+
+// Dispatcher menyimpan subscription dan delivery log di memory dan
+// mengirim delivery lewat beberapa worker
+type Dispatcher struct {
+	opts Options
+
+	mu             sync.Mutex
+	subs           map[string]*Subscription
+	deliveries     map[string]*Delivery
+	bySub          map[string][]string // ID delivery per subscription, terlama dulu
+	timers         map[string]*time.Timer
+	nextSubID      int
+	nextDeliveryID int
+	nextEventID    int
+	closed         bool
+	now            func() time.Time
+
+	queue chan string
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func New(opts Options) *Dispatcher {
+	d := &Dispatcher{
+		opts:           opts.withDefaults(),
+		subs:           map[string]*Subscription{},
+		deliveries:     map[string]*Delivery{},
+		bySub:          map[string][]string{},
+		timers:         map[string]*time.Timer{},
+		nextSubID:      1,
+		nextDeliveryID: 1,
+		nextEventID:    1,
+		now:            time.Now,
+		queue:          make(chan string, 1024),
+		stop:           make(chan struct{}),
+	}
+
+	for i := 0; i < d.opts.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+// Subscribe mendaftarkan URL baru dan mengembalikan secret untuk
+// memverifikasi signature
+func (d *Dispatcher) Subscribe(url string, events []string, description string, active bool) (Subscription, error) {
+	sub, err := d.NewSubscription(url, events, description, active)
+	if err != nil {
+		return Subscription{}, err
+	}
+	d.Put(sub)
+	return sub, nil
+}
+
+// NewSubscription seperti Subscribe tapi belum menyimpan subscription-nya;
+// simpan dengan Put. Hasilnya berisi secret.
+func (d *Dispatcher) NewSubscription(url string, events []string, description string, active bool) (Subscription, error) {
+	if err := checkEvents(events); err != nil {
+		return Subscription{}, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now().UTC()
+	sub := Subscription{
+		ID:           strconv.Itoa(d.nextSubID),
+		URL:          url,
+		Events:       append([]string(nil), events...),
+		Description:  description,
+		Active:       active,
+		Secret:       secret,
+		SecretPrefix: secret[:len(secretPrefix)+6],
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	d.nextSubID++
+	return sub, nil
+}
+
+// Update mengganti URL, event, deskripsi dan status aktif. Secret tetap.
+func (d *Dispatcher) Update(id, url string, events []string, description string, active bool) (Subscription, error) {
+	sub, err := d.Updated(id, url, events, description, active)
+	if err != nil {
+		return Subscription{}, err
+	}
+	d.Put(sub)
+	return sub.Redacted(), nil
+}
+
+// Updated seperti Update tapi belum menyimpan perubahannya; simpan dengan
+// Put. Hasilnya berisi secret.
+func (d *Dispatcher) Updated(id, url string, events []string, description string, active bool) (Subscription, error) {
+	if err := checkEvents(events); err != nil {
+		return Subscription{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stored, ok := d.subs[id]
+	if !ok {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+	sub := *stored
+	sub.URL = url
+	sub.Events = append([]string(nil), events...)
+	sub.Description = description
+	sub.Active = active
+	sub.UpdatedAt = d.now().UTC()
+	return sub, nil
+}
+
+// Put menyimpan hasil NewSubscription atau Updated, juga subscription dari
+// replay journal. Subscription dengan ID yang sama diganti, delivery
+// log-nya tetap.
+func (d *Dispatcher) Put(sub Subscription) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sub.Events = append([]string(nil), sub.Events...)
+	d.subs[sub.ID] = &sub
+	d.nextSubID = nextAfter(sub.ID, d.nextSubID)
+}
+
+// Delete menghapus subscription beserta delivery log-nya. Retry yang
+// masih dijadwalkan dibatalkan.
+func (d *Dispatcher) Delete(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.subs[id]; !ok {
+		return ErrSubscriptionNotFound
+	}
+	for _, deliveryID := range d.bySub[id] {
+		if timer, ok := d.timers[deliveryID]; ok {
+			timer.Stop()
+			delete(d.timers, deliveryID)
+		}
+		delete(d.deliveries, deliveryID)
+	}
+	delete(d.bySub, id)
+	delete(d.subs, id)
+	return nil
+}
+
+func (d *Dispatcher) Get(id string) (Subscription, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sub, ok := d.subs[id]
+	if !ok {
+		return Subscription{}, ErrSubscriptionNotFound
+	}
+	return sub.Redacted(), nil
+}
+
+func (d *Dispatcher) List() []Subscription {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ids := make([]string, 0, len(d.subs))
+	for id := range d.subs {
+		ids = append(ids, id)
+	}
+	sortByID(ids)
+
+	subs := make([]Subscription, 0, len(ids))
+	for _, id := range ids {
+		subs = append(subs, d.subs[id].Redacted())
+	}
+	return subs
+}
+
+// Publish membuat event baru dan mengirimnya lewat Send
+func (d *Dispatcher) Publish(eventType string, data interface{}) (Event, error) {
+	d.mu.Lock()
+	event := Event{
+		ID:        "evt_" + strconv.Itoa(d.nextEventID),
+		Type:      eventType,
+		CreatedAt: d.now().UTC(),
+		Data:      data,
+	}
+	d.nextEventID++
+	d.mu.Unlock()
+
+	return event, d.Send(event)
+}
+
+// Send membuat delivery untuk setiap subscription aktif yang mendengarkan
+// tipe event. Data di-marshal saat itu juga, jadi perubahan berikutnya
+// tidak ikut terkirim. ID event dipakai apa adanya supaya penerima bisa
+// membuang event yang terkirim lebih dari sekali.
+func (d *Dispatcher) Send(event Event) error {
+	if !ValidEvent(event.Type) {
+		return fmt.Errorf("unknown event %q", event.Type)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return ErrClosed
+	}
+	for _, sub := range d.subs {
+		if sub.wants(event.Type) {
+			d.enqueueLocked(d.newDeliveryLocked(sub.ID, event.ID, event.Type, payload))
+		}
+	}
+	return nil
+}
+
+// Deliveries mengembalikan delivery log subscription, terbaru dulu.
+// Status kosong berarti semua status.
+func (d *Dispatcher) Deliveries(subscriptionID, status string) ([]Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.subs[subscriptionID]; !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	ids := d.bySub[subscriptionID]
+	deliveries := make([]Delivery, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		delivery := d.deliveries[ids[i]]
+		if status == "" || delivery.Status == status {
+			deliveries = append(deliveries, delivery.copy())
+		}
+	}
+	return deliveries, nil
+}
+
+func (d *Dispatcher) Delivery(subscriptionID, id string) (Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.subs[subscriptionID]; !ok {
+		return Delivery{}, ErrSubscriptionNotFound
+	}
+	delivery, ok := d.deliveries[id]
+	if !ok || delivery.SubscriptionID != subscriptionID {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+	return delivery.copy(), nil
+}
+
+// Redeliver mengirim ulang payload yang sama sebagai delivery baru
+func (d *Dispatcher) Redeliver(subscriptionID, id string) (Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return Delivery{}, ErrClosed
+	}
+	if _, ok := d.subs[subscriptionID]; !ok {
+		return Delivery{}, ErrSubscriptionNotFound
+	}
+	original, ok := d.deliveries[id]
+	if !ok || original.SubscriptionID != subscriptionID {
+		return Delivery{}, ErrDeliveryNotFound
+	}
+
+	delivery := d.newDeliveryLocked(subscriptionID, original.EventID, original.Event, original.Payload)
+	d.enqueueLocked(delivery)
+	return delivery.copy(), nil
+}
+
+// Export mengembalikan semua subscription beserta secret-nya dan delivery
+// log, urut berdasarkan ID. Hasilnya berisi secret, jadi hanya untuk
+// disimpan di storage.
+func (d *Dispatcher) Export() ([]Subscription, []Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	subIDs := make([]string, 0, len(d.subs))
+	for id := range d.subs {
+		subIDs = append(subIDs, id)
+	}
+	sortByID(subIDs)
+	subs := make([]Subscription, 0, len(subIDs))
+	for _, id := range subIDs {
+		sub := *d.subs[id]
+		sub.Events = append([]string(nil), sub.Events...)
+		subs = append(subs, sub)
+	}
+
+	deliveryIDs := make([]string, 0, len(d.deliveries))
+	for id := range d.deliveries {
+		deliveryIDs = append(deliveryIDs, id)
+	}
+	sortByID(deliveryIDs)
+	deliveries := make([]Delivery, 0, len(deliveryIDs))
+	for _, id := range deliveryIDs {
+		deliveries = append(deliveries, d.deliveries[id].copy())
+	}
+	return subs, deliveries
+}
+
+// Restore mengganti semua subscription dan delivery dengan hasil Export.
+// Delivery pending dikirim lagi dan delivery retrying dijadwalkan ulang
+// sesuai NextAttemptAt.
+func (d *Dispatcher) Restore(subs []Subscription, deliveries []Delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for id, timer := range d.timers {
+		timer.Stop()
+		delete(d.timers, id)
+	}
+	d.subs = map[string]*Subscription{}
+	d.deliveries = map[string]*Delivery{}
+	d.bySub = map[string][]string{}
+	d.nextSubID, d.nextDeliveryID = 1, 1
+
+	for _, sub := range subs {
+		sub := sub
+		sub.Events = append([]string(nil), sub.Events...)
+		d.subs[sub.ID] = &sub
+		d.nextSubID = nextAfter(sub.ID, d.nextSubID)
+	}
+	for _, delivery := range deliveries {
+		if _, ok := d.subs[delivery.SubscriptionID]; !ok {
+			continue
+		}
+		delivery := delivery.copy()
+		d.deliveries[delivery.ID] = &delivery
+		d.bySub[delivery.SubscriptionID] = append(d.bySub[delivery.SubscriptionID], delivery.ID)
+		d.nextDeliveryID = nextAfter(delivery.ID, d.nextDeliveryID)
+
+		switch delivery.Status {
+		case StatusPending:
+			d.enqueueLocked(&delivery)
+		case StatusRetrying:
+			var wait time.Duration
+			if delivery.NextAttemptAt != nil {
+				wait = max(delivery.NextAttemptAt.Sub(d.now()), 0)
+			}
+			d.scheduleLocked(delivery.ID, wait)
+		}
+	}
+}
+
+// Close menghentikan worker dan retry yang dijadwalkan. Delivery yang
+// belum selesai tetap tercatat dengan status pending atau retrying.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	for id, timer := range d.timers {
+		timer.Stop()
+		delete(d.timers, id)
+	}
+	d.mu.Unlock()
+
+	close(d.stop)
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Dispatcher) newDeliveryLocked(subscriptionID, eventID, eventType string, payload []byte) *Delivery {
+	delivery := &Delivery{
+		ID:             strconv.Itoa(d.nextDeliveryID),
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		Event:          eventType,
+		Status:         StatusPending,
+		Attempts:       []Attempt{},
+		CreatedAt:      d.now().UTC(),
+		Payload:        payload,
+	}
+	d.nextDeliveryID++
+	d.deliveries[delivery.ID] = delivery
+	d.bySub[subscriptionID] = append(d.bySub[subscriptionID], delivery.ID)
+	d.pruneLocked(subscriptionID)
+	return delivery
+}
+
+// pruneLocked membuang delivery terlama yang sudah selesai kalau log
+// melebihi MaxDeliveries
+func (d *Dispatcher) pruneLocked(subscriptionID string) {
+	ids := d.bySub[subscriptionID]
+	for i := 0; len(ids) > d.opts.MaxDeliveries && i < len(ids); {
+		delivery := d.deliveries[ids[i]]
+		if delivery.Status == StatusSucceeded || delivery.Status == StatusFailed {
+			delete(d.deliveries, ids[i])
+			ids = append(ids[:i], ids[i+1:]...)
+			continue
+		}
+		i++
+	}
+	d.bySub[subscriptionID] = ids
+}
+
+func (d *Dispatcher) enqueueLocked(delivery *Delivery) {
+	select {
+	case d.queue <- delivery.ID:
+	default:
+		// Antrian penuh, coba lagi sebentar lagi
+		d.scheduleLocked(delivery.ID, d.opts.BaseBackoff)
+	}
+}
+
+func (d *Dispatcher) scheduleLocked(id string, delay time.Duration) {
+	if d.closed {
+		return
+	}
+	d.timers[id] = time.AfterFunc(delay, func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if d.closed {
+			return
+		}
+		delete(d.timers, id)
+		if delivery, ok := d.deliveries[id]; ok {
+			d.enqueueLocked(delivery)
+		}
+	})
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case id := <-d.queue:
+			d.attempt(id)
+		}
+	}
+}
+
+func (d *Dispatcher) attempt(id string) {
+	d.mu.Lock()
+	delivery, ok := d.deliveries[id]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+	sub := d.subs[delivery.SubscriptionID]
+	url, secret, payload := sub.URL, sub.Secret, delivery.Payload
+	number := len(delivery.Attempts) + 1
+	d.mu.Unlock()
+
+	start := d.now()
+	statusCode, err := d.send(url, secret, delivery.ID, delivery.Event, payload)
+	result := Attempt{
+		Number:     number,
+		At:         start.UTC(),
+		StatusCode: statusCode,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Subscription bisa saja dihapus selama request berjalan
+	if _, ok := d.deliveries[id]; !ok {
+		return
+	}
+	delivery.Attempts = append(delivery.Attempts, result)
+	log := d.logger().With("webhook_id", delivery.SubscriptionID, "delivery_id", id, "event", delivery.Event, "attempt", number)
+
+	now := d.now().UTC()
+	switch {
+	case err == nil:
+		delivery.Status = StatusSucceeded
+		delivery.NextAttemptAt = nil
+		delivery.CompletedAt = &now
+		log.Info("Webhook delivered", "status_code", statusCode)
+	case number >= d.opts.MaxAttempts:
+		delivery.Status = StatusFailed
+		delivery.NextAttemptAt = nil
+		delivery.CompletedAt = &now
+		log.Warn("Webhook delivery failed, giving up", "error", err)
+	default:
+		wait := d.backoff(number)
+		next := now.Add(wait)
+		delivery.Status = StatusRetrying
+		delivery.NextAttemptAt = &next
+		d.scheduleLocked(id, wait)
+		log.Info("Webhook delivery failed, retrying", "error", err, "retry_in", wait.String())
+	}
+}
+
+func (d *Dispatcher) logger() *slog.Logger {
+	if d.opts.Logger != nil {
+		return d.opts.Logger
+	}
+	return slog.Default()
+}
+
+// send mengembalikan error untuk status di luar 2xx
+func (d *Dispatcher) send(url, secret, deliveryID, event string, payload []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "e-commerce-webhooks/1.0")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, deliveryID)
+	req.Header.Set(HeaderSignature, Sign(secret, d.now(), payload))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff menggandakan jeda setiap percobaan dengan jitter sampai 10%
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.opts.BaseBackoff << (attempt - 1)
+	if wait > d.opts.MaxBackoff || wait <= 0 {
+		wait = d.opts.MaxBackoff
+	}
+	return wait + time.Duration(rand.Int64N(int64(wait)/10+1))
+}
+
+func checkEvents(events []string) error {
+	if len(events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	for _, event := range events {
+		if !ValidEvent(event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package webhook mengirim event ke URL yang didaftarkan admin. Setiap
+// request ditandatangani HMAC-SHA256, dikirim secara async dan diulang
+// dengan exponential backoff kalau gagal. Semua percobaan dicatat di
+// delivery log.
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Event yang bisa di-subscribe
+const (
+	EventTransactionCreated  = "transaction.created"
+	EventTransactionRefunded = "transaction.refunded"
+	EventProductStockLow     = "product.stock_low"
+	EventProductUpdated      = "product.updated"
+	EventSourceDeleted       = "source.deleted"
+)
+
+var knownEvents = map[string]bool{
+	EventTransactionCreated:  true,
+	EventTransactionRefunded: true,
+	EventProductStockLow:     true,
+	EventProductUpdated:      true,
+	EventSourceDeleted:       true,
+}
+
+// ValidEvent mengecek apakah nama event dikenal
+func ValidEvent(event string) bool {
+	return knownEvents[event]
+}
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook not found")
+	ErrDeliveryNotFound     = errors.New("delivery not found")
+	ErrClosed               = errors.New("webhook dispatcher is closed")
+)
+
+const secretPrefix = "whsec_"
+
+// Subscription adalah satu URL penerima. Secret hanya ditampilkan saat
+// dibuat; setelah itu hanya prefix-nya.
+type Subscription struct {
+	ID           string    `json:"id"`
+	URL          string    `json:"url"`
+	Events       []string  `json:"events"`
+	Description  string    `json:"description"`
+	Active       bool      `json:"active"`
+	Secret       string    `json:"secret,omitempty"`
+	SecretPrefix string    `json:"secret_prefix"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (s Subscription) wants(event string) bool {
+	if !s.Active {
+		return false
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Redacted menyembunyikan secret untuk response list dan get
+func (s Subscription) Redacted() Subscription {
+	s.Secret = ""
+	s.Events = append([]string(nil), s.Events...)
+	return s
+}
+
+// Event adalah body yang dikirim ke penerima
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Status delivery
+const (
+	StatusPending   = "pending"
+	StatusRetrying  = "retrying"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Attempt adalah satu percobaan pengiriman
+type Attempt struct {
+	Number     int       `json:"number"`
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+}
+
+// Delivery adalah satu event untuk satu subscription beserta riwayat
+// percobaannya
+type Delivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status" validate:"oneof=pending retrying succeeded failed"`
+	Attempts       []Attempt       `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	CompletedAt    *time.Time      `json:"completed_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+func (d Delivery) copy() Delivery {
+	d.Attempts = append([]Attempt(nil), d.Attempts...)
+	return d
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+// nextAfter mengembalikan next, atau ID numerik sesudah id kalau lebih besar
+func nextAfter(id string, next int) int {
+	if n, err := strconv.Atoi(id); err == nil && n >= next {
+		return n + 1
+	}
+	return next
+}
+
+// sortByID mengurutkan ID numerik dari yang terlama
+func sortByID(ids []string) {
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+}
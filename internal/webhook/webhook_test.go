@@ -0,0 +1,216 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver adalah server penerima yang menolak failures request pertama
+type receiver struct {
+	t        *testing.T
+	secret   string
+	failures int
+
+	mu     sync.Mutex
+	calls  int
+	events []Event
+	done   chan struct{}
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.calls++
+
+	if err := Verify(rc.secret, r.Header.Get(HeaderSignature), body, 5*time.Minute, time.Now()); err != nil {
+		rc.t.Errorf("call %d: %v", rc.calls, err)
+	}
+	if r.Header.Get(HeaderEvent) == "" || r.Header.Get(HeaderDelivery) == "" {
+		rc.t.Errorf("call %d: missing webhook headers", rc.calls)
+	}
+
+	if rc.calls <= rc.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		rc.t.Errorf("invalid payload: %v", err)
+	}
+	rc.events = append(rc.events, event)
+	w.WriteHeader(http.StatusNoContent)
+	close(rc.done)
+}
+
+func newTestDispatcher(t *testing.T, maxAttempts int) *Dispatcher {
+	d := New(Options{MaxAttempts: maxAttempts, BaseBackoff: 10 * time.Millisecond, Workers: 2})
+	t.Cleanup(func() { d.Close(context.Background()) })
+	return d
+}
+
+// waitForStatus menunggu delivery mencapai status akhir
+func waitForStatus(t *testing.T, d *Dispatcher, subID, status string) Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, err := d.Deliveries(subID, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) == 1 && deliveries[0].Status == status {
+			return deliveries[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	deliveries, _ := d.Deliveries(subID, "")
+	t.Fatalf("delivery did not reach %s: %+v", status, deliveries)
+	return Delivery{}
+}
+
+func TestDeliveryRetriesUntilSuccess(t *testing.T) {
+	d := newTestDispatcher(t, 5)
+	rc := &receiver{t: t, failures: 2, done: make(chan struct{})}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	sub, err := d.Subscribe(srv.URL, []string{EventTransactionCreated}, "test", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.secret = sub.Secret
+
+	// Event lain tidak boleh dikirim ke subscription ini
+	if _, err := d.Publish(EventSourceDeleted, map[string]string{"id": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	event, err := d.Publish(EventTransactionCreated, map[string]string{"id": "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-rc.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	delivery := waitForStatus(t, d, sub.ID, StatusSucceeded)
+	if len(delivery.Attempts) != 3 {
+		t.Fatalf("attempts = %d, want 3", len(delivery.Attempts))
+	}
+	if delivery.Attempts[0].StatusCode != http.StatusServiceUnavailable || delivery.Attempts[2].StatusCode != http.StatusNoContent {
+		t.Errorf("unexpected attempt log %+v", delivery.Attempts)
+	}
+	if delivery.EventID != event.ID || delivery.CompletedAt == nil {
+		t.Errorf("unexpected delivery %+v", delivery)
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.events) != 1 || rc.events[0].Type != EventTransactionCreated || rc.events[0].ID != event.ID {
+		t.Errorf("received events %+v", rc.events)
+	}
+}
+
+func TestDeliveryGivesUpAfterMaxAttempts(t *testing.T) {
+	d := newTestDispatcher(t, 3)
+	rc := &receiver{t: t, failures: 100, done: make(chan struct{})}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	sub, err := d.Subscribe(srv.URL, []string{EventProductStockLow}, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.secret = sub.Secret
+
+	if _, err := d.Publish(EventProductStockLow, map[string]int{"stock": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := waitForStatus(t, d, sub.ID, StatusFailed)
+	if len(delivery.Attempts) != 3 {
+		t.Errorf("attempts = %d, want 3", len(delivery.Attempts))
+	}
+
+	failed, _ := d.Deliveries(sub.ID, StatusFailed)
+	succeeded, _ := d.Deliveries(sub.ID, StatusSucceeded)
+	if len(failed) != 1 || len(succeeded) != 0 {
+		t.Errorf("status filter returned %d failed, %d succeeded", len(failed), len(succeeded))
+	}
+
+	// Redeliver membuat delivery baru dengan event yang sama
+	again, err := d.Redeliver(sub.ID, delivery.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID == delivery.ID || again.EventID != delivery.EventID {
+		t.Errorf("redelivery %+v does not reuse event %s", again, delivery.EventID)
+	}
+}
+
+func TestVerifyRejectsTamperedPayload(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"id":"evt_1"}`)
+	header := Sign("whsec_test", now, body)
+
+	if err := Verify("whsec_test", header, body, time.Minute, now); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := Verify("whsec_test", header, []byte(`{"id":"evt_2"}`), time.Minute, now); err == nil {
+		t.Error("tampered body accepted")
+	}
+	if err := Verify("whsec_other", header, body, time.Minute, now); err == nil {
+		t.Error("wrong secret accepted")
+	}
+	if err := Verify("whsec_test", header, body, time.Minute, now.Add(time.Hour)); err == nil {
+		t.Error("expired timestamp accepted")
+	}
+}
+
+func TestRestoreResumesPendingDeliveries(t *testing.T) {
+	rc := &receiver{t: t, done: make(chan struct{})}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	before := newTestDispatcher(t, 3)
+	sub, err := before.Subscribe(srv.URL, []string{EventTransactionCreated}, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.secret = sub.Secret
+
+	// Secret ikut di-export supaya delivery setelah restart tetap
+	// ditandatangani dengan secret yang sama
+	subs, _ := before.Export()
+	if len(subs) != 1 || subs[0].Secret != sub.Secret {
+		t.Fatalf("exported subscriptions %+v", subs)
+	}
+	payload, _ := json.Marshal(Event{ID: "evt_9", Type: EventTransactionCreated, Data: map[string]string{"id": "42"}})
+	pending := Delivery{ID: "7", SubscriptionID: sub.ID, EventID: "evt_9", Event: EventTransactionCreated, Status: StatusPending, Attempts: []Attempt{}, Payload: payload}
+
+	after := newTestDispatcher(t, 3)
+	after.Restore(subs, []Delivery{pending})
+	delivery := waitForStatus(t, after, sub.ID, StatusSucceeded)
+	if delivery.ID != "7" || len(delivery.Attempts) != 1 {
+		t.Errorf("restored delivery %+v", delivery)
+	}
+
+	// ID baru dilanjutkan dari data yang di-restore
+	again, err := after.Redeliver(sub.ID, "7")
+	if err != nil || again.ID != "8" {
+		t.Errorf("Redeliver() = %+v, %v", again, err)
+	}
+	if next, err := after.Subscribe(srv.URL, []string{EventSourceDeleted}, "", false); err != nil || next.ID != "2" {
+		t.Errorf("Subscribe() = %+v, %v", next, err)
+	}
+}
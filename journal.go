@@ -0,0 +1,431 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+	"e-commerce/internal/journal"
+	"e-commerce/internal/shipping"
+	"e-commerce/internal/snapshot"
+	"e-commerce/internal/webhook"
+)
+
+// eventLog nil kalau storage backend bukan journal. Kalau aktif, setiap
+// event yang di-commit lewat bus juga dicatat di sini sebelum outbox.
+var eventLog *journal.Log
+
+// compactMu memastikan hanya satu compaction berjalan. Tanpa ini,
+// compaction lama bisa menulis snapshot yang lebih tua setelah compaction
+// baru membuang entry-nya dari journal.
+var compactMu sync.Mutex
+
+// openJournal memuat snapshot hasil compaction terakhir di dir lalu
+// me-replay entry journal setelahnya. Fixture hanya dipakai kalau keduanya
+// belum ada, dan langsung dilipat ke snapshot supaya ikut tersimpan.
+func openJournal(cfg *config.Config, dir string) error {
+	snapshots = snapshot.New(snapshot.Options{
+		Path:    filepath.Join(dir, "snapshot.json"),
+		App:     "e-commerce",
+		Version: snapshotVersion,
+		Capture: captureState,
+	})
+
+	var state State
+	_, err := snapshots.Load(&state)
+	if err != nil && !errors.Is(err, snapshot.ErrNotFound) {
+		return err
+	}
+	hasSnapshot := err == nil
+	if hasSnapshot {
+		restoreState(state)
+	}
+
+	eventLog, err = journal.Open(journal.Options{
+		Path:             filepath.Join(dir, "journal.log"),
+		CompactThreshold: cfg.Storage.CompactThreshold,
+		Compact: func() error {
+			_, err := compactJournal()
+			return err
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	replayed := 0
+	err = eventLog.Replay(state.JournalSeq, func(e journal.Entry) error {
+		replayed++
+		return replayEntry(e)
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("Journal replayed", "dir", dir, "snapshot_seq", state.JournalSeq, "replayed", replayed, "sources", len(sources), "products", len(products), "transactions", len(transactions))
+
+	if !hasSnapshot && eventLog.LastSeq() == 0 && cfg.Seed {
+		if err := loadFixtures(cfg.Fixtures); err != nil {
+			return err
+		}
+		if _, err := compactJournal(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compactJournal menyimpan state dan Seq journal terakhir ke snapshot,
+// lalu membuang entry yang sudah masuk snapshot. Kalau proses mati di
+// antaranya, replay melewati entry yang Seq-nya tidak lebih dari snapshot.
+func compactJournal() (snapshot.Info, error) {
+	compactMu.Lock()
+	defer compactMu.Unlock()
+
+	state := captureState().(State)
+	info, err := snapshots.Write(state)
+	if err != nil {
+		return snapshot.Info{}, err
+	}
+	if err := eventLog.Truncate(state.JournalSeq); err != nil {
+		return snapshot.Info{}, err
+	}
+	slog.Info("Journal compacted", "seq", state.JournalSeq, "size", info.Size)
+	return info, nil
+}
+
+// replayEntry menerapkan satu entry journal ke storage. Hasilnya harus
+// sama dengan perubahan yang dilakukan handler saat event di-commit.
+// Event tanpa perubahan state (misalnya product.stock_low) dilewati.
+func replayEntry(e journal.Entry) error {
+	switch e.Type {
+	case EventProductCreated:
+		var product Product
+		if err := e.Decode(&product); err != nil {
+			return err
+		}
+		normalizeCurrency(&product.Currency)
+		products = append(products, product)
+		reserveID(product.ID)
+
+	case EventProductUpdated:
+		var product Product
+		if err := e.Decode(&product); err != nil {
+			return err
+		}
+		i, err := productIndex(product.ID)
+		if err != nil {
+			return err
+		}
+		normalizeCurrency(&product.Currency)
+		products[i] = product
+
+	case EventPriceChanged:
+		var change PriceChangedEvent
+		if err := e.Decode(&change); err != nil {
+			return err
+		}
+		i, err := productIndex(change.ProductID)
+		if err != nil {
+			return err
+		}
+		products[i].Price = change.Price
+
+	case EventStockChanged:
+		var change StockChangedEvent
+		if err := e.Decode(&change); err != nil {
+			return err
+		}
+		i, err := productIndex(change.ProductID)
+		if err != nil {
+			return err
+		}
+		products[i].Stock = change.Stock
+
+	case EventProductDeleted:
+		var product Product
+		if err := e.Decode(&product); err != nil {
+			return err
+		}
+		i, err := productIndex(product.ID)
+		if err != nil {
+			return err
+		}
+		products = append(products[:i], products[i+1:]...)
+		removeProductStock(product.ID)
+
+	case EventSourceCreated:
+		var source Source
+		if err := e.Decode(&source); err != nil {
+			return err
+		}
+		sources = append(sources, source)
+		reserveID(source.ID)
+
+	case EventSourceUpdated, EventSourceDeleted:
+		var source Source
+		if err := e.Decode(&source); err != nil {
+			return err
+		}
+		i := -1
+		for j := range sources {
+			if sources[j].ID == source.ID {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return fmt.Errorf("source %q not found", source.ID)
+		}
+		if e.Type == EventSourceUpdated {
+			sources[i] = source
+		} else {
+			sources = append(sources[:i], sources[i+1:]...)
+		}
+
+	case EventTransactionCreated:
+		// Stock dikurangi oleh product.stock_changed yang dicatat bersama
+		var transaction Transaction
+		if err := e.Decode(&transaction); err != nil {
+			return err
+		}
+		legacyTransaction(&transaction)
+		transactions = append(transactions, transaction)
+		reserveID(transaction.ID)
+
+	case EventTransactionRefunded:
+		var transaction Transaction
+		if err := e.Decode(&transaction); err != nil {
+			return err
+		}
+		for i := range transactions {
+			if transactions[i].ID == transaction.ID {
+				transactions[i] = transaction
+				return nil
+			}
+		}
+		return fmt.Errorf("transaction %q not found", transaction.ID)
+
+	case EventShippingZoneCreated, EventShippingZoneUpdated, EventShippingZoneDeleted:
+		var zone shipping.Zone
+		if err := e.Decode(&zone); err != nil {
+			return err
+		}
+		if e.Type == EventShippingZoneCreated {
+			shippingZones = append(shippingZones, zone)
+			reserveID(zone.ID)
+			return nil
+		}
+		i := zoneIndex(zone.ID)
+		if i < 0 {
+			return fmt.Errorf("shipping zone %q not found", zone.ID)
+		}
+		if e.Type == EventShippingZoneUpdated {
+			shippingZones[i] = zone
+		} else {
+			shippingZones = append(shippingZones[:i], shippingZones[i+1:]...)
+		}
+
+	case EventShipmentCreated:
+		var shipment Shipment
+		if err := e.Decode(&shipment); err != nil {
+			return err
+		}
+		shipments = append(shipments, shipment)
+		reserveID(shipment.ID)
+
+	case EventShipmentUpdated:
+		var shipment Shipment
+		if err := e.Decode(&shipment); err != nil {
+			return err
+		}
+		for i := range shipments {
+			if shipments[i].ID == shipment.ID {
+				shipments[i] = shipment
+				return nil
+			}
+		}
+		return fmt.Errorf("shipment %q not found", shipment.ID)
+
+	case EventExchangeRateCreated:
+		var rate ExchangeRate
+		if err := e.Decode(&rate); err != nil {
+			return err
+		}
+		exchangeRates = append(exchangeRates, rate)
+		reserveID(rate.ID)
+
+	case EventExchangeRateDeleted:
+		var rate ExchangeRate
+		if err := e.Decode(&rate); err != nil {
+			return err
+		}
+		for i := range exchangeRates {
+			if exchangeRates[i].ID == rate.ID {
+				exchangeRates = append(exchangeRates[:i], exchangeRates[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("exchange rate %q not found", rate.ID)
+
+	case EventCustomerCreated:
+		var customer Customer
+		if err := e.Decode(&customer); err != nil {
+			return err
+		}
+		customers = append(customers, customer)
+		reserveID(customer.ID)
+
+	case EventCustomerUpdated:
+		var customer Customer
+		if err := e.Decode(&customer); err != nil {
+			return err
+		}
+		if i := customerIndex(customer.ID); i >= 0 {
+			customers[i] = customer
+			return nil
+		}
+		return fmt.Errorf("customer %q not found", customer.ID)
+
+	case EventCustomerGuestOrdersMerged:
+		var merged GuestOrdersMergedEvent
+		if err := e.Decode(&merged); err != nil {
+			return err
+		}
+		applyGuestOrdersMerged(merged)
+
+	case EventReviewCreated:
+		var review Review
+		if err := e.Decode(&review); err != nil {
+			return err
+		}
+		reviews = append(reviews, review)
+		reserveID(review.ID)
+
+	case EventReviewModerated:
+		var review Review
+		if err := e.Decode(&review); err != nil {
+			return err
+		}
+		if i := reviewIndex(review.ID); i >= 0 {
+			reviews[i] = review
+			return nil
+		}
+		return fmt.Errorf("review %q not found", review.ID)
+
+	case EventReviewVoted:
+		var vote ReviewVote
+		if err := e.Decode(&vote); err != nil {
+			return err
+		}
+		applyReviewVote(vote)
+
+	case EventCostPriceCreated:
+		var cost CostPrice
+		if err := e.Decode(&cost); err != nil {
+			return err
+		}
+		costPrices = append(costPrices, cost)
+		reserveID(cost.ID)
+
+	case EventCostPriceDeleted:
+		var cost CostPrice
+		if err := e.Decode(&cost); err != nil {
+			return err
+		}
+		for i := range costPrices {
+			if costPrices[i].ID == cost.ID {
+				costPrices = append(costPrices[:i], costPrices[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("cost price %q not found", cost.ID)
+
+	case EventSaleCostRecorded:
+		var sale SaleCost
+		if err := e.Decode(&sale); err != nil {
+			return err
+		}
+		saleCosts = append(saleCosts, sale)
+
+	case EventWarehouseCreated:
+		var warehouse Warehouse
+		if err := e.Decode(&warehouse); err != nil {
+			return err
+		}
+		warehouses = append(warehouses, warehouse)
+		reserveID(warehouse.ID)
+
+	case EventWarehouseUpdated:
+		var warehouse Warehouse
+		if err := e.Decode(&warehouse); err != nil {
+			return err
+		}
+		if i := warehouseIndex(warehouse.ID); i >= 0 {
+			warehouses[i] = warehouse
+			return nil
+		}
+		return fmt.Errorf("warehouse %q not found", warehouse.ID)
+
+	case EventWarehouseDeleted:
+		var warehouse Warehouse
+		if err := e.Decode(&warehouse); err != nil {
+			return err
+		}
+		if warehouseIndex(warehouse.ID) < 0 {
+			return fmt.Errorf("warehouse %q not found", warehouse.ID)
+		}
+		removeWarehouse(warehouse.ID)
+
+	case EventStockMoved:
+		var movement StockMovement
+		if err := e.Decode(&movement); err != nil {
+			return err
+		}
+		applyMovement(movement)
+		reserveID(movement.ID)
+
+	case EventAPIKeyCreated, EventAPIKeyUpdated:
+		var key auth.StoredAPIKey
+		if err := e.Decode(&key); err != nil {
+			return err
+		}
+		apiKeys.Put(key.Key())
+
+	case EventWebhookCreated, EventWebhookUpdated:
+		var sub webhook.Subscription
+		if err := e.Decode(&sub); err != nil {
+			return err
+		}
+		webhooks.Put(sub)
+
+	case EventWebhookDeleted:
+		var sub webhook.Subscription
+		if err := e.Decode(&sub); err != nil {
+			return err
+		}
+		return webhooks.Delete(sub.ID)
+	}
+	return nil
+}
+
+func productIndex(id string) (int, error) {
+	for i := range products {
+		if products[i].ID == id {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("product %q not found", id)
+}
+
+// reserveID memastikan generateID tidak mengembalikan ID yang sudah
+// dipakai entry journal
+func reserveID(id string) {
+	if n, err := strconv.Atoi(id); err == nil && n >= nextID {
+		nextID = n + 1
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+	"e-commerce/internal/events"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openTestJournal membuka backend journal di dir dan memasang bus yang
+// mencatat ke journal tersebut
+func openTestJournal(t *testing.T, cfg *config.Config, dir string) {
+	t.Helper()
+	products, sources, transactions, nextID = []Product{}, []Source{}, []Transaction{}, 1
+	apiKeys.Restore(nil)
+	webhooks.Restore(nil, nil)
+	if err := openJournal(cfg, dir); err != nil {
+		t.Fatal(err)
+	}
+	bus = newEventBus(events.NewMemoryStore())
+}
+
+func TestJournalReplayRebuildsState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := testConfig()
+	cfg.Storage.Backend = config.BackendJournal
+	dir := t.TempDir()
+	t.Cleanup(func() {
+		eventLog.Close(context.Background())
+		eventLog, snapshots = nil, nil
+		bus = newEventBus(events.NewMemoryStore())
+	})
+
+	openTestJournal(t, cfg, dir)
+	if err := initializeAuth(cfg); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := tokenIssuer.Issue(auth.User{ID: "u1", Username: "admin", Role: auth.RoleAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := setupRouter(cfg)
+
+	for _, req := range []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/products", `{"name":"Mouse","price":100,"stock":10,"source_id":"1"}`},
+		{http.MethodPut, "/products/3", `{"name":"Mouse","price":150,"stock":10,"source_id":"1"}`},
+		{http.MethodPost, "/transactions", `{"product_id":"3","quantity":2,"customer_id":"1"}`},
+		{http.MethodPatch, "/products/1/stock", `{"stock":4}`},
+		{http.MethodPost, "/sources", `{"name":"Supplier C"}`},
+		{http.MethodPost, "/sources/2/api-keys", `{"name":"pos"}`},
+		{http.MethodPost, "/sources/1/api-keys", `{"name":"erp"}`},
+		{http.MethodPost, "/api-keys/2/rotate", `{"grace_period":"1h"}`},
+		{http.MethodPost, "/webhooks", `{"url":"https://example.com/hook","events":["product.stock_low"],"active":false}`},
+		{http.MethodPut, "/webhooks/1", `{"url":"https://example.com/hook","events":["product.stock_low"],"description":"stock","active":false}`},
+		{http.MethodPost, "/webhooks", `{"url":"https://example.com/other","events":["source.deleted"],"active":false}`},
+		{http.MethodDelete, "/webhooks/2", ``},
+		{http.MethodDelete, "/sources/2", ``},
+		{http.MethodDelete, "/products/2", ``},
+		{http.MethodPost, "/products/bulk", `{"mode":"atomic","operations":[{"op":"create","data":{"name":"Pad","price":5,"stock":1,"source_id":"1"}},{"op":"update","id":"1","data":{"name":"Laptop","price":9000000,"stock":4,"source_id":"1"}}]}`},
+	} {
+		w := httptest.NewRecorder()
+		httpReq := httptest.NewRequest(req.method, req.path, bytes.NewBufferString(req.body))
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		httpReq.Header.Set("Content-Type", "application/json")
+		r.ServeHTTP(w, httpReq)
+		if w.Code >= http.StatusBadRequest {
+			t.Fatalf("%s %s: status %d: %s", req.method, req.path, w.Code, w.Body)
+		}
+	}
+	want := captureState().(State)
+	if want.JournalSeq == 0 {
+		t.Fatal("nothing was written to the journal")
+	}
+	if len(want.APIKeys) != 3 || want.APIKeys[0].RevokedAt == nil || want.APIKeys[1].RotatedTo != "3" || len(want.Webhooks) != 1 {
+		t.Fatalf("API keys %+v, webhooks %+v", want.APIKeys, want.Webhooks)
+	}
+
+	// Restart tanpa shutdown: snapshot hanya berisi fixture, sisanya dari
+	// replay journal
+	eventLog.Close(context.Background())
+	openTestJournal(t, cfg, dir)
+	if got := captureState().(State); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed state differs\n got %+v\nwant %+v", got, want)
+	}
+	if id := generateID(); id != "8" {
+		t.Errorf("next generated ID = %s, want 8", id)
+	}
+
+	// Setelah compaction journal kosong dan state tetap sama
+	if _, err := compactJournal(); err != nil {
+		t.Fatal(err)
+	}
+	if n := eventLog.Len(); n != 0 {
+		t.Errorf("journal has %d entries after compaction", n)
+	}
+	eventLog.Close(context.Background())
+	openTestJournal(t, cfg, dir)
+	want.NextID = 9
+	if got := captureState().(State); !reflect.DeepEqual(got, want) {
+		t.Errorf("state after compaction differs\n got %+v\nwant %+v", got, want)
+	}
+}
@@ -1,33 +1,91 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+	"e-commerce/internal/cors"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/openapi"
+	"e-commerce/internal/ratelimit"
+	"e-commerce/internal/requestid"
+	"e-commerce/internal/server"
+	"e-commerce/internal/shipping"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Structs sesuai requirement
+// Aturan validasi ditulis di tag `validate`, lihat validation.go
+// Currency kosong berarti IDR, lihat currency.go
 type Product struct {
 	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int     `json:"stock"`
-	SourceID    string  `json:"source_id"`
+	Name        string  `json:"name" validate:"required,max=200"`
+	Description string  `json:"description" validate:"max=2000"`
+	Price       float64 `json:"price" validate:"gt=0"`
+	Currency    string  `json:"currency" validate:"currency"`
+	Stock       int     `json:"stock" validate:"gte=0"`
+	SourceID    string  `json:"source_id" validate:"source_exists"`
+	// Berat dan ukuran satu unit untuk ongkos kirim, lihat shipping.go
+	WeightGrams int                  `json:"weight_grams" validate:"gte=0"`
+	Dimensions  *shipping.Dimensions `json:"dimensions,omitempty"`
+	// Agregat review approved, dihitung saat response (lihat reviews.go)
+	AverageRating float64 `json:"average_rating" doc:"Rata-rata rating review approved, hanya di response"`
+	ReviewCount   int     `json:"review_count" doc:"Jumlah review approved, hanya di response"`
 }
 
+type ProductQuery struct {
+	SourceID string `form:"source_id" json:"source_id"`
+	Sort     string `form:"sort" json:"sort" validate:"omitempty,oneof=rating"`
+}
+
+// LeadTimeDays adalah lama pengiriman dari supplier setelah order dan
+// MinOrderQuantity jumlah minimum per order; 0 berarti tidak diketahui
+// atau tanpa minimum.
 type Source struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID               string `json:"id"`
+	Name             string `json:"name" validate:"required,max=200"`
+	LeadTimeDays     int    `json:"lead_time_days" validate:"gte=0"`
+	MinOrderQuantity int    `json:"min_order_quantity" validate:"gte=0"`
 }
 
+// Total dalam Currency, termasuk ShippingCost. ExchangeRate adalah kurs
+// dari mata uang produk ke Currency saat transaksi dibuat, 1 kalau sama.
+// Transaksi baru disimpan setelah pembayaran berhasil, lihat payments.go;
+// PaymentToken hanya dibaca dari request dan tidak pernah disimpan.
+// Transaksi tanpa ShippingAddress tidak dikirim (ambil sendiri).
 type Transaction struct {
-	ID        string  `json:"id"`
-	ProductID string  `json:"product_id"`
-	Quantity  int     `json:"quantity"`
-	Total     float64 `json:"total"`
+	ID           string  `json:"id"`
+	ProductID    string  `json:"product_id" validate:"required"`
+	Quantity     int     `json:"quantity" validate:"gt=0"`
+	Total        float64 `json:"total"`
+	Currency     string  `json:"currency" validate:"omitempty,currency"`
+	ExchangeRate float64 `json:"exchange_rate"`
+	ShippingCost float64 `json:"shipping_cost"`
+	Status       string  `json:"status"`
+	PaymentID    string  `json:"payment_id,omitempty"`
+	PaymentToken string  `json:"payment_token,omitempty"`
+	UserID       string  `json:"user_id"`
+	CustomerID   string  `json:"customer_id,omitempty" validate:"omitempty,customer_exists"`
+	WarehouseID  string  `json:"warehouse_id,omitempty" validate:"omitempty,warehouse_exists"`
+
+	ShippingAddress *shipping.Address `json:"shipping_address,omitempty"`
+
+	// Guest diisi untuk guest checkout. GuestToken hanya dikirim di
+	// response guest checkout dan dipakai untuk merge ke akun customer.
+	Guest      *GuestContact `json:"guest,omitempty"`
+	GuestToken string        `json:"guest_token,omitempty"`
 }
 
 // Response format yang konsisten
@@ -37,6 +95,15 @@ type APIResponse struct {
 	Error   interface{} `json:"error"`
 }
 
+// Rate limit default per route group, bisa di-override lewat konfigurasi
+// (rate_limits, RATE_LIMITS atau --rate-limits)
+var defaultRateLimits = map[string]ratelimit.Rule{
+	"ip":           {Requests: 600, Period: time.Minute},
+	"default":      {Requests: 300, Period: time.Minute},
+	"auth":         {Requests: 10, Period: time.Minute},
+	"transactions": {Requests: 30, Period: time.Minute},
+}
+
 // In-memory storage
 var (
 	products     = []Product{}
@@ -46,72 +113,307 @@ var (
 )
 
 func main() {
-	// Initialize dengan data sample
-	initializeData()
+	cfg, err := config.Load("e-commerce", config.Defaults(defaultRateLimits), os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(2)
+	}
+	if cfg.PrintConfig {
+		cfg.Write(os.Stdout)
+		return
+	}
+
+	slog.SetDefault(logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format))
+
+	// Outbox dibuka sebelum ada perubahan data supaya tidak ada event yang
+	// hilang; event yang belum diproses sebelum restart dikirim ulang
+	store, err := events.Open(cfg.Outbox.Dir, "e-commerce")
+	if err != nil {
+		slog.Error("Failed to open event outbox", "dir", cfg.Outbox.Dir, "error", err)
+		os.Exit(1)
+	}
+
+	// Muat snapshot, journal atau fixture, lihat snapshots.go. Bus dibuat
+	// setelahnya karena butuh journal yang sudah di-replay.
+	if err := openStorage(cfg); err != nil {
+		slog.Error("Failed to load storage", "backend", cfg.Storage.Backend, "error", err)
+		os.Exit(1)
+	}
+	if err := watchStorage(cfg.Outbox.Dir); err != nil {
+		slog.Error("Failed to prepare storage", "backend", cfg.Storage.Backend, "error", err)
+		os.Exit(1)
+	}
+	storageLoaded.Store(true)
+
+	bus = newEventBus(store)
+	if err := bus.Start(); err != nil {
+		slog.Error("Failed to start event consumers", "error", err)
+		os.Exit(1)
+	}
+
+	if err := initializeAuth(cfg); err != nil {
+		slog.Error("Failed to initialize auth", "error", err)
+		os.Exit(1)
+	}
+	initializePayments(cfg)
+	initializeShipping(cfg)
+	initializeCustomers()
+	initializeWarehouses(cfg)
+
+	gin.SetMode(cfg.Mode)
+	srv := server.New(cfg, setupRouter(cfg))
+
+	// Stream SSE diputus begitu shutdown dimulai. Hook berjalan terbalik:
+	// snapshot terakhir ditulis setelah request selesai, lalu consumer event
+	// berhenti, lalu journal ditutup, lalu pengiriman webhook yang sedang
+	// berjalan ditunggu selesai.
+	srv.OnStop(closeStreams)
+	srv.OnShutdown("webhooks", webhooks.Close)
+	if eventLog != nil {
+		eventLog.Start()
+		srv.OnShutdown("journal", eventLog.Close)
+	}
+	srv.OnShutdown("events", bus.Close)
+	if snapshots != nil {
+		snapshots.Start()
+		srv.OnShutdown("snapshot", snapshots.Close)
+	}
+
+	slog.Info("Server starting", "addr", cfg.Addr, "mode", cfg.Mode, "storage", cfg.Storage.Backend, "tls", cfg.TLS.Enabled())
+	if err := srv.Run(); err != nil {
+		slog.Error("Server stopped", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Server stopped")
+}
+
+// setupRouter mendaftarkan middleware dan semua endpoint. Setiap route
+// baru juga harus ditambahkan ke openapi.go.
+func setupRouter(cfg *config.Config) *gin.Engine {
+	limits := ratelimit.NewGroups(cfg.RateLimits, ratelimit.ClientKey)
+	ipLimits := ratelimit.NewGroups(cfg.RateLimits, ratelimit.IPKey)
+
+	r := gin.New()
+	// Tanpa proxy yang dipercaya, gin memakai X-Forwarded-For dari siapa
+	// saja dan rate limit per IP bisa dihindari. Nilai sudah divalidasi
+	// saat konfigurasi dimuat.
+	r.SetTrustedProxies(cfg.Proxies.Trusted)
+
+	// Request ID dan access log JSON untuk setiap request
+	r.Use(requestid.Middleware())
+	r.Use(logging.Middleware(slog.Default()))
+	r.Use(logging.Recovery())
+
+	// Metrics mencatat semua request, termasuk yang ditolak middleware lain
+	r.Use(registry.Middleware())
+
+	// Probe dan metrics didaftarkan sebelum auth dan rate limit supaya
+	// tidak pernah ditolak
+	r.GET("/healthz", checker.Live)
+	r.GET("/readyz", checker.Ready)
+	r.GET("/metrics", registry.Handler())
+
+	// CORS dicek sebelum auth supaya preflight tidak butuh token
+	r.Use(cors.Middleware(cfg.CORS.Origins))
+
+	// Limit per IP dicek sebelum auth supaya token dan API key yang
+	// ditolak tetap dihitung
+	r.Use(ipLimits.Middleware("ip"))
+
+	// Auth middleware
+	r.Use(auth.Authenticate(tokenIssuer))
+	r.Use(auth.AuthenticateAPIKey(apiKeys))
+
+	// Rate limit per client, dicek setelah auth supaya bisa per user
+	r.Use(limits.Middleware("default"))
 
-	r := gin.Default()
+	// Permission per role
+	authenticated := auth.RequireRoles(auth.RoleAdmin, auth.RoleStaff, auth.RoleCustomer)
+	staffOnly := auth.RequireRoles(auth.RoleAdmin, auth.RoleStaff)
+	adminOnly := auth.RequireRoles(auth.RoleAdmin)
+	stockWriters := auth.RequireRoles(auth.RoleAdmin, auth.RoleStaff, auth.RoleSupplier)
 
-	// Middleware logger
-	r.Use(gin.Logger())
+	// Auth endpoints
+	r.POST("/auth/login", limits.Middleware("auth"), login)
+	r.GET("/auth/me", authenticated, getMe)
 
 	// Product endpoints
 	r.GET("/products", getProducts)
 	r.GET("/products/:id", getProduct)
-	r.POST("/products", createProduct)
-	r.PUT("/products/:id", updateProduct)
-	r.DELETE("/products/:id", deleteProduct)
+	r.POST("/products", staffOnly, createProduct)
+	r.PUT("/products/:id", staffOnly, updateProduct)
+	r.DELETE("/products/:id", adminOnly, deleteProduct)
+	r.POST("/products/bulk", staffOnly, bulkProducts)
+	r.PATCH("/products/:id/stock", stockWriters, auth.RequireScope(auth.ScopeStockWrite), updateProductStock)
+
+	// Review endpoints
+	r.GET("/products/:id/reviews", getProductReviews)
+	r.POST("/products/:id/reviews", authenticated, createReview)
+	r.GET("/reviews", staffOnly, getReviews)
+	r.PATCH("/reviews/:id/status", staffOnly, moderateReview)
+	r.POST("/reviews/:id/helpful", authenticated, voteReview)
+
+	// Harga beli dan laporan margin
+	r.GET("/products/:id/costs", staffOnly, getCostPrices)
+	r.POST("/products/:id/costs", staffOnly, createCostPrice)
+	r.DELETE("/products/:id/costs/:cost_id", staffOnly, deleteCostPrice)
+	r.GET("/reports/margins/products", staffOnly, getProductMargins)
+	r.GET("/reports/margins/sources", staffOnly, getSourceMargins)
+	r.GET("/reports/margins/transactions", staffOnly, getTransactionMargins)
+
+	// Gudang dan stock per gudang
+	r.GET("/warehouses", getWarehouses)
+	r.GET("/warehouses/:id", getWarehouse)
+	r.POST("/warehouses", adminOnly, createWarehouse)
+	r.PUT("/warehouses/:id", adminOnly, updateWarehouse)
+	r.DELETE("/warehouses/:id", adminOnly, deleteWarehouse)
+	r.GET("/warehouses/:id/stock", staffOnly, getWarehouseStock)
+	r.PUT("/warehouses/:id/stock/:product_id", staffOnly, setWarehouseStock)
+	r.GET("/products/:id/stock-levels", staffOnly, getProductStockLevels)
+	r.POST("/stock-transfers", staffOnly, transferStock)
+	r.GET("/stock-movements", staffOnly, getStockMovements)
 
 	// Source endpoints
 	r.GET("/sources", getSources)
 	r.GET("/sources/:id", getSource)
-	r.POST("/sources", createSource)
-	r.PUT("/sources/:id", updateSource)
-	r.DELETE("/sources/:id", deleteSource)
+	r.POST("/sources", staffOnly, createSource)
+	r.PUT("/sources/:id", staffOnly, updateSource)
+	r.DELETE("/sources/:id", adminOnly, deleteSource)
+	r.POST("/sources/bulk", staffOnly, bulkSources)
+
+	// Kurs mata uang
+	r.GET("/exchange-rates", getExchangeRates)
+	r.POST("/exchange-rates", adminOnly, createExchangeRate)
+	r.DELETE("/exchange-rates/:id", adminOnly, deleteExchangeRate)
+
+	// API key endpoints untuk supplier
+	r.POST("/sources/:id/api-keys", adminOnly, createAPIKey)
+	r.GET("/sources/:id/api-keys", adminOnly, getAPIKeys)
+	r.POST("/api-keys/:id/rotate", adminOnly, rotateAPIKey)
+	r.DELETE("/api-keys/:id", adminOnly, revokeAPIKey)
+
+	// Server-Sent Events
+	r.GET("/events/stock", streamStock)
+	r.GET("/events/transactions", authenticated, streamTransactions)
+
+	// Snapshot storage
+	r.POST("/admin/snapshots", adminOnly, createSnapshot)
+	r.GET("/admin/snapshots/latest", adminOnly, downloadSnapshot)
+
+	// Webhook endpoints
+	r.POST("/webhooks", adminOnly, createWebhook)
+	r.GET("/webhooks", adminOnly, getWebhooks)
+	r.GET("/webhooks/:id", adminOnly, getWebhook)
+	r.PUT("/webhooks/:id", adminOnly, updateWebhook)
+	r.DELETE("/webhooks/:id", adminOnly, deleteWebhook)
+	r.GET("/webhooks/:id/deliveries", adminOnly, getWebhookDeliveries)
+	r.GET("/webhooks/:id/deliveries/:delivery_id", adminOnly, getWebhookDelivery)
+	r.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", adminOnly, redeliverWebhook)
 
 	// Transaction endpoints
-	r.POST("/transactions", createTransaction)
-	r.GET("/transactions", getTransactions)
-	r.GET("/transactions/:id", getTransaction)
+	r.POST("/transactions", authenticated, limits.Middleware("transactions"), createTransaction)
+	r.GET("/transactions", authenticated, getTransactions)
+	r.GET("/transactions/:id", authenticated, getTransaction)
+	r.POST("/transactions/:id/refund", adminOnly, refundTransaction)
+	r.POST("/guest/transactions", limits.Middleware("transactions"), createTransaction)
+
+	// Customer endpoints
+	r.POST("/customers", authenticated, createCustomer)
+	r.GET("/customers", staffOnly, getCustomers)
+	r.GET("/customers/:id", authenticated, getCustomer)
+	r.PUT("/customers/:id", authenticated, updateCustomer)
+	r.GET("/customers/:id/transactions", authenticated, getCustomerTransactions)
+	r.POST("/customers/:id/merge-guest-orders", authenticated, mergeGuestOrders)
+
+	// Payment provider
+	r.POST("/payments/webhook", receivePaymentWebhook)
+
+	// Pengiriman
+	r.GET("/shipping/zones", getShippingZones)
+	r.POST("/shipping/zones", adminOnly, createShippingZone)
+	r.PUT("/shipping/zones/:id", adminOnly, updateShippingZone)
+	r.DELETE("/shipping/zones/:id", adminOnly, deleteShippingZone)
+	r.POST("/shipping/quote", quoteShippingCost)
+	r.GET("/shipments", authenticated, getShipments)
+	r.GET("/shipments/:id", authenticated, getShipment)
+	r.POST("/shipments/:id/dispatch", staffOnly, dispatchShipment)
+	r.POST("/shipments/:id/refresh", authenticated, refreshShipment)
+
+	// Dokumentasi API
+	spec := buildOpenAPISpec()
+	r.GET("/openapi.json", spec.Handler())
+	r.GET("/docs", openapi.DocsHandler("E-Commerce API", "/openapi.json"))
+
+	return r
+}
 
-	fmt.Println("Server starting on :8080")
-	r.Run(":8080")
+// generateID hanya boleh dipanggil di dalam prepare commitFunc (atau saat
+// seed dan replay sebelum server menerima request), supaya nextID tidak
+// diubah bersamaan dan ID dibagikan dalam urutan yang sama dengan journal.
+func generateID() string {
+	id := strconv.Itoa(nextID)
+	nextID++
+	return id
 }
 
-func initializeData() {
-	// Sample sources
-	sources = []Source{
-		{ID: "1", Name: "Supplier A"},
-		{ID: "2", Name: "Supplier B"},
-	}
+// allocateID membuat ID di dalam commit untuk data yang butuh ID sebelum
+// commit-nya sendiri. ID yang tidak jadi dipakai tidak dibagikan lagi.
+func allocateID(c *gin.Context) (string, bool) {
+	var id string
+	ok := commitFunc(c, func() (func(), []events.Message, error) {
+		id = generateID()
+		return nil, nil, nil
+	})
+	return id, ok
+}
 
-	// Sample products
-	products = []Product{
-		{ID: "1", Name: "Laptop", Description: "Gaming laptop", Price: 15000000, Stock: 10, SourceID: "1"},
-		{ID: "2", Name: "Mouse", Description: "Wireless mouse", Price: 250000, Stock: 50, SourceID: "2"},
+// Helper function untuk cek apakah source ada
+func sourceExists(id string) bool {
+	for _, source := range sources {
+		if source.ID == id {
+			return true
+		}
 	}
-
-	nextID = 3
+	return false
 }
 
-func generateID() string {
-	id := strconv.Itoa(nextID)
-	nextID++
-	return id
+// Helper function untuk response error yang konsisten
+func respondError(c *gin.Context, status int, message string, err *apierror.Error) {
+	c.JSON(status, APIResponse{
+		Message: message,
+		Data:    nil,
+		Error:   apierror.ForRequest(c, err),
+	})
 }
 
 // Product handlers
 func getProducts(c *gin.Context) {
-	sourceID := c.Query("source_id")
+	var query ProductQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid query", apierror.InvalidBody(err))
+		return
+	}
+	if fields := validate.Struct(&query); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+	currency, ok := queryCurrency(c)
+	if !ok {
+		return
+	}
 
 	var filteredProducts []Product
-	if sourceID != "" {
-		for _, product := range products {
-			if product.SourceID == sourceID {
-				filteredProducts = append(filteredProducts, product)
-			}
+	summaries := ratingSummaries()
+	for _, product := range products {
+		if query.SourceID == "" || product.SourceID == query.SourceID {
+			filteredProducts = append(filteredProducts, withRating(priceIn(product, currency), summaries))
 		}
-	} else {
-		filteredProducts = products
+	}
+	if query.Sort == "rating" {
+		sortByRating(filteredProducts)
 	}
 
 	c.JSON(http.StatusOK, APIResponse{
@@ -123,85 +425,45 @@ func getProducts(c *gin.Context) {
 
 func getProduct(c *gin.Context) {
 	id := c.Param("id")
+	currency, ok := queryCurrency(c)
+	if !ok {
+		return
+	}
 
 	for _, product := range products {
 		if product.ID == id {
 			c.JSON(http.StatusOK, APIResponse{
 				Message: "Product retrieved successfully",
-				Data:    product,
+				Data:    withRating(priceIn(product, currency), ratingSummaries()),
 				Error:   nil,
 			})
 			return
 		}
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "Product not found",
-		Data:    nil,
-		Error:   "Product with ID " + id + " not found",
-	})
+	respondError(c, http.StatusNotFound, "Product not found", apierror.NotFound("Product", id))
 }
 
 func createProduct(c *gin.Context) {
 	var newProduct Product
 	if err := c.ShouldBindJSON(&newProduct); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Invalid request body",
-			Data:    nil,
-			Error:   err.Error(),
-		})
-		return
-	}
-
-	// Validasi
-	if newProduct.Name == "" {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Name is required",
-		})
-		return
-	}
-
-	if newProduct.Price <= 0 {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Price must be greater than 0",
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
 		return
 	}
 
-	if newProduct.Stock < 0 {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Stock must be greater than or equal to 0",
-		})
-		return
-	}
-
-	// Cek apakah source ada
-	sourceExists := false
-	for _, source := range sources {
-		if source.ID == newProduct.SourceID {
-			sourceExists = true
-			break
+	// Validasi, termasuk cek apakah source ada, dan ID dibuat di dalam
+	// commit supaya source tidak terhapus di antaranya
+	normalizeCurrency(&newProduct.Currency)
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if fields := validate.Struct(&newProduct); len(fields) > 0 {
+			return nil, nil, reject(http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
 		}
-	}
-
-	if !sourceExists {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Source ID not found",
-		})
+		newProduct.ID = generateID()
+		return func() { products = append(products, newProduct) }, []events.Message{{Type: EventProductCreated, Data: newProduct}}, nil
+	}) {
 		return
 	}
 
-	newProduct.ID = generateID()
-	products = append(products, newProduct)
-
 	c.JSON(http.StatusCreated, APIResponse{
 		Message: "Product created successfully",
 		Data:    newProduct,
@@ -214,82 +476,62 @@ func updateProduct(c *gin.Context) {
 
 	var updatedProduct Product
 	if err := c.ShouldBindJSON(&updatedProduct); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Invalid request body",
-			Data:    nil,
-			Error:   err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
 		return
 	}
 
-	for i, product := range products {
-		if product.ID == id {
-			// Validasi
-			if updatedProduct.Name == "" {
-				c.JSON(http.StatusBadRequest, APIResponse{
-					Message: "Validation failed",
-					Data:    nil,
-					Error:   "Name is required",
-				})
-				return
-			}
-
-			if updatedProduct.Price <= 0 {
-				c.JSON(http.StatusBadRequest, APIResponse{
-					Message: "Validation failed",
-					Data:    nil,
-					Error:   "Price must be greater than 0",
-				})
-				return
-			}
-
-			if updatedProduct.Stock < 0 {
-				c.JSON(http.StatusBadRequest, APIResponse{
-					Message: "Validation failed",
-					Data:    nil,
-					Error:   "Stock must be greater than or equal to 0",
-				})
-				return
-			}
-
-			updatedProduct.ID = id
-			products[i] = updatedProduct
+	// Produk dicari di dalam commit supaya perubahan tidak menimpa produk
+	// lain kalau ada delete yang masuk lebih dulu
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		index, err := productIndex(id)
+		if err != nil {
+			return nil, nil, reject(http.StatusNotFound, "Product not found", apierror.NotFound("Product", id))
+		}
 
-			c.JSON(http.StatusOK, APIResponse{
-				Message: "Product updated successfully",
-				Data:    updatedProduct,
-				Error:   nil,
-			})
-			return
+		// Validasi
+		normalizeCurrency(&updatedProduct.Currency)
+		if fields := validate.Struct(&updatedProduct); len(fields) > 0 {
+			return nil, nil, reject(http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
 		}
+		if apiErr := checkStockFloor(id, updatedProduct.Stock); apiErr != nil {
+			return nil, nil, reject(http.StatusConflict, "Stock held in other warehouses", apiErr)
+		}
+
+		updatedProduct.ID = id
+		return func() { products[index] = updatedProduct }, productUpdated(products[index], updatedProduct, StockReasonUpdate), nil
+	}) {
+		return
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "Product not found",
-		Data:    nil,
-		Error:   "Product with ID " + id + " not found",
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Product updated successfully",
+		Data:    updatedProduct,
+		Error:   nil,
 	})
 }
 
 func deleteProduct(c *gin.Context) {
 	id := c.Param("id")
 
-	for i, product := range products {
-		if product.ID == id {
-			products = append(products[:i], products[i+1:]...)
-			c.JSON(http.StatusOK, APIResponse{
-				Message: "Product deleted successfully",
-				Data:    nil,
-				Error:   nil,
-			})
-			return
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		index, err := productIndex(id)
+		if err != nil {
+			return nil, nil, reject(http.StatusNotFound, "Product not found", apierror.NotFound("Product", id))
 		}
+		// Stock produk di gudang lain ikut dibuang supaya tidak tertinggal
+		// di daftar stock gudang
+		return func() {
+			products = append(products[:index], products[index+1:]...)
+			removeProductStock(id)
+		}, []events.Message{{Type: EventProductDeleted, Data: products[index]}}, nil
+	}) {
+		return
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "Product not found",
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Product deleted successfully",
 		Data:    nil,
-		Error:   "Product with ID " + id + " not found",
+		Error:   nil,
 	})
 }
 
@@ -316,36 +558,28 @@ func getSource(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "Source not found",
-		Data:    nil,
-		Error:   "Source with ID " + id + " not found",
-	})
+	respondError(c, http.StatusNotFound, "Source not found", apierror.NotFound("Source", id))
 }
 
 func createSource(c *gin.Context) {
 	var newSource Source
 	if err := c.ShouldBindJSON(&newSource); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Invalid request body",
-			Data:    nil,
-			Error:   err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
 		return
 	}
 
 	// Validasi
-	if newSource.Name == "" {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Name is required",
-		})
+	if fields := validate.Struct(&newSource); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
 		return
 	}
 
-	newSource.ID = generateID()
-	sources = append(sources, newSource)
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		newSource.ID = generateID()
+		return func() { sources = append(sources, newSource) }, []events.Message{{Type: EventSourceCreated, Data: newSource}}, nil
+	}) {
+		return
+	}
 
 	c.JSON(http.StatusCreated, APIResponse{
 		Message: "Source created successfully",
@@ -359,149 +593,284 @@ func updateSource(c *gin.Context) {
 
 	var updatedSource Source
 	if err := c.ShouldBindJSON(&updatedSource); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Invalid request body",
-			Data:    nil,
-			Error:   err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
 		return
 	}
 
-	for i, source := range sources {
-		if source.ID == id {
-			// Validasi
-			if updatedSource.Name == "" {
-				c.JSON(http.StatusBadRequest, APIResponse{
-					Message: "Validation failed",
-					Data:    nil,
-					Error:   "Name is required",
-				})
-				return
-			}
-
-			updatedSource.ID = id
-			sources[i] = updatedSource
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		index := sourceIndex(id)
+		if index < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Source not found", apierror.NotFound("Source", id))
+		}
 
-			c.JSON(http.StatusOK, APIResponse{
-				Message: "Source updated successfully",
-				Data:    updatedSource,
-				Error:   nil,
-			})
-			return
+		// Validasi
+		if fields := validate.Struct(&updatedSource); len(fields) > 0 {
+			return nil, nil, reject(http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
 		}
+
+		updatedSource.ID = id
+		return func() { sources[index] = updatedSource }, []events.Message{{Type: EventSourceUpdated, Data: updatedSource}}, nil
+	}) {
+		return
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "Source not found",
-		Data:    nil,
-		Error:   "Source with ID " + id + " not found",
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Source updated successfully",
+		Data:    updatedSource,
+		Error:   nil,
 	})
 }
 
 func deleteSource(c *gin.Context) {
 	id := c.Param("id")
 
-	for i, source := range sources {
-		if source.ID == id {
-			sources = append(sources[:i], sources[i+1:]...)
-			c.JSON(http.StatusOK, APIResponse{
-				Message: "Source deleted successfully",
-				Data:    nil,
-				Error:   nil,
-			})
-			return
+	// API key milik source dicabut di commit yang sama, supaya tidak ada
+	// saat source sudah terhapus tapi key-nya masih bisa dipakai
+	var revoked int
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		source, found := findSource(id)
+		if !found {
+			return nil, nil, reject(http.StatusNotFound, "Source not found", apierror.NotFound("Source", id))
 		}
+		revokeKeys, keyEvents := revokeSourceKeys(id)
+		revoked = len(keyEvents)
+		return func() {
+			sources = removeSource(sources, id)
+			revokeKeys()
+		}, append([]events.Message{{Type: EventSourceDeleted, Data: source}}, keyEvents...), nil
+	}) {
+		return
 	}
+	logging.From(c).Info("Source deleted", "source_id", id, "revoked_api_keys", revoked)
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "Source not found",
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Source deleted successfully",
 		Data:    nil,
-		Error:   "Source with ID " + id + " not found",
+		Error:   nil,
 	})
 }
 
+func findSource(id string) (Source, bool) {
+	if i := sourceIndex(id); i >= 0 {
+		return sources[i], true
+	}
+	return Source{}, false
+}
+
+func sourceIndex(id string) int {
+	for i := range sources {
+		if sources[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeSource(list []Source, id string) []Source {
+	kept := make([]Source, 0, len(list))
+	for _, source := range list {
+		if source.ID != id {
+			kept = append(kept, source)
+		}
+	}
+	return kept
+}
+
 // Transaction handlers
 func createTransaction(c *gin.Context) {
 	var newTransaction Transaction
 	if err := c.ShouldBindJSON(&newTransaction); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Invalid request body",
-			Data:    nil,
-			Error:   err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
 		return
 	}
 
-	// Validasi quantity
-	if newTransaction.Quantity <= 0 {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Quantity must be greater than 0",
-		})
+	// Validasi
+	newTransaction.Currency = strings.ToUpper(newTransaction.Currency)
+	normalizeAddress(newTransaction.ShippingAddress)
+	if fields := validate.Struct(&newTransaction); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
 		return
 	}
 
-	// Cari produk
-	var product *Product
-	var productIndex int
-	for i, p := range products {
-		if p.ID == newTransaction.ProductID {
-			product = &p
-			productIndex = i
-			break
+	// Checkout yang login selalu atas nama customer; tanpa login, kontak
+	// guest wajib diisi
+	principal, authenticated := auth.FromContext(c)
+	newTransaction.GuestToken = ""
+	if authenticated {
+		customerID, ok := checkoutCustomer(c, principal, newTransaction.CustomerID)
+		if !ok {
+			return
+		}
+		newTransaction.CustomerID = customerID
+		newTransaction.Guest = nil
+	} else {
+		newTransaction.CustomerID = ""
+		if newTransaction.Guest == nil {
+			respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(apierror.Field("guest", "required", "Guest contact is required for guest checkout")))
+			return
 		}
+		newTransaction.Guest.Email = strings.TrimSpace(newTransaction.Guest.Email)
 	}
 
-	if product == nil {
-		c.JSON(http.StatusNotFound, APIResponse{
-			Message: "Product not found",
-			Data:    nil,
-			Error:   "Product with ID " + newTransaction.ProductID + " not found",
-		})
+	// Cari produk
+	product, found := findProduct(newTransaction.ProductID)
+	if !found {
+		respondError(c, http.StatusNotFound, "Product not found", apierror.NotFound("Product", newTransaction.ProductID))
 		return
 	}
 
-	// Cek stock
-	if product.Stock < newTransaction.Quantity {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Insufficient stock",
-			Data:    nil,
-			Error:   fmt.Sprintf("Available stock: %d, requested: %d", product.Stock, newTransaction.Quantity),
-		})
+	// Total dihitung dalam currency yang diminta (default mata uang
+	// produk) dengan kurs saat ini, dan kursnya ikut dicatat
+	if newTransaction.Currency == "" {
+		newTransaction.Currency = currencyOf(product.Currency)
+	}
+	total, rate, ok := convert(product.Price*float64(newTransaction.Quantity), product.Currency, newTransaction.Currency, time.Now())
+	if !ok {
+		respondError(c, http.StatusBadRequest, "Unsupported currency", apierror.Newf(apierror.CodeUnsupportedCurrency, "No exchange rate from %s to %s", currencyOf(product.Currency), newTransaction.Currency))
 		return
 	}
 
-	// Buat transaksi
-	newTransaction.ID = generateID()
-	newTransaction.Total = product.Price * float64(newTransaction.Quantity)
-
-	// Kurangi stock
-	products[productIndex].Stock -= newTransaction.Quantity
+	// Ongkos kirim ikut dibayar dalam currency yang sama
+	if newTransaction.ShippingAddress != nil {
+		quote, apiErr := quoteShipping(product, newTransaction.Quantity, *newTransaction.ShippingAddress, newTransaction.Currency)
+		if apiErr != nil {
+			respondError(c, http.StatusBadRequest, "Shipping unavailable", apiErr)
+			return
+		}
+		newTransaction.ShippingCost = quote.Cost
+		total += quote.Cost
+	}
 
-	// Simpan transaksi
-	transactions = append(transactions, newTransaction)
+	// Tahan stock selama pembayaran berjalan. Stock baru dikurangi setelah
+	// pembayaran berhasil; kalau gagal atau timeout, hold dilepas.
+	// Gudangnya dipilih client atau menurut aturan alokasi; satu transaksi
+	// selalu diambil dari satu gudang.
+	candidates := allocationCandidates(product, newTransaction.WarehouseID, newTransaction.ShippingAddress)
+	warehouseID, available, ok := holdStock(product, candidates, newTransaction.Quantity)
+	if !ok {
+		respondError(c, http.StatusBadRequest, "Insufficient stock", apierror.Newf(apierror.CodeInsufficientStock, "Available stock: %d, requested: %d", available, newTransaction.Quantity))
+		return
+	}
+	defer releaseStock(product.ID, warehouseID, newTransaction.Quantity)
+	newTransaction.WarehouseID = warehouseID
+
+	// Buat transaksi atas nama user yang login. ID dipakai sebagai
+	// referensi pembayaran, jadi dipesan sebelum commit transaksinya.
+	token := newTransaction.PaymentToken
+	newTransaction.PaymentToken = ""
+	if newTransaction.ID, ok = allocateID(c); !ok {
+		return
+	}
+	if authenticated {
+		newTransaction.UserID = principal.Subject
+	}
+	newTransaction.Total = total
+	newTransaction.ExchangeRate = rate
 
+	paymentID, ok := chargeTransaction(c, newTransaction, token)
+	if !ok {
+		return
+	}
+	newTransaction.Status = TransactionPaid
+	newTransaction.PaymentID = paymentID
+
+	// Produk bisa diubah atau dihapus selama pembayaran, jadi dibaca ulang
+	// di dalam commit; cek stock dan pengurangannya terjadi di bawah lock
+	// yang sama. Kalau stock-nya tidak cukup lagi, pembayaran dikembalikan.
+	// Metric, webhook dan notifikasi stock menipis diproses dari event.
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		index, err := productIndex(product.ID)
+		if err != nil || stockIn(products[index], warehouseID) < newTransaction.Quantity {
+			return nil, nil, reject(http.StatusConflict, "Product changed during payment", apierror.Newf(apierror.CodeConflict, "Product %s was changed during payment and the payment was refunded", product.ID))
+		}
+		product := products[index]
+
+		updatedProduct := product
+		updatedProduct.Stock -= newTransaction.Quantity
+		messages := append([]events.Message{{Type: EventTransactionCreated, Data: newTransaction}}, stockChanged(product, updatedProduct, StockReasonTransaction)...)
+		sale := newSaleCost(newTransaction, product, time.Now())
+		messages = append(messages, events.Message{Type: EventSaleCostRecorded, Data: sale})
+		var movement *StockMovement
+		if warehouseID != "" {
+			movement = &StockMovement{
+				ID:              generateID(),
+				ProductID:       product.ID,
+				FromWarehouseID: warehouseID,
+				Quantity:        newTransaction.Quantity,
+				Reason:          StockReasonTransaction,
+				TransactionID:   newTransaction.ID,
+				CreatedAt:       time.Now().UTC(),
+			}
+			messages = append(messages, events.Message{Type: EventStockMoved, Data: *movement})
+		}
+		var shipment *Shipment
+		if newTransaction.ShippingAddress != nil {
+			created := newShipment(newTransaction, product)
+			shipment = &created
+			messages = append(messages, events.Message{Type: EventShipmentCreated, Data: created})
+		}
+		return func() {
+			products[index] = updatedProduct
+			transactions = append(transactions, newTransaction)
+			saleCosts = append(saleCosts, sale)
+			if movement != nil {
+				applyMovement(*movement)
+			}
+			if shipment != nil {
+				shipments = append(shipments, *shipment)
+			}
+		}, messages, nil
+	}) {
+		cancelPayment(c, paymentID, newTransaction.Total)
+		return
+	}
+	logging.From(c).Info("Transaction created",
+		"transaction_id", newTransaction.ID,
+		"product_id", newTransaction.ProductID,
+		"quantity", newTransaction.Quantity,
+		"total", newTransaction.Total,
+		"currency", newTransaction.Currency,
+		"payment_id", newTransaction.PaymentID,
+		"user_id", newTransaction.UserID,
+		"customer_id", newTransaction.CustomerID,
+	)
+
+	// guest_token tidak disimpan, hanya dikirim ke pembeli guest
+	response := newTransaction
+	if !authenticated {
+		response.GuestToken = guestToken(newTransaction.ID)
+	}
 	c.JSON(http.StatusCreated, APIResponse{
 		Message: "Transaction created successfully",
-		Data:    newTransaction,
+		Data:    response,
 		Error:   nil,
 	})
 }
 
 func getTransactions(c *gin.Context) {
+	principal, _ := auth.FromContext(c)
+
+	// Customer hanya melihat transaksinya sendiri
+	visibleTransactions := []Transaction{}
+	for _, transaction := range transactions {
+		if canAccessTransaction(principal, transaction) {
+			visibleTransactions = append(visibleTransactions, transaction)
+		}
+	}
+
 	c.JSON(http.StatusOK, APIResponse{
 		Message: "Transactions retrieved successfully",
-		Data:    transactions,
+		Data:    visibleTransactions,
 		Error:   nil,
 	})
 }
 
 func getTransaction(c *gin.Context) {
 	id := c.Param("id")
+	principal, _ := auth.FromContext(c)
 
 	for _, transaction := range transactions {
-		if transaction.ID == id {
+		if transaction.ID == id && canAccessTransaction(principal, transaction) {
 			c.JSON(http.StatusOK, APIResponse{
 				Message: "Transaction retrieved successfully",
 				Data:    transaction,
@@ -511,9 +880,5 @@ func getTransaction(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "Transaction not found",
-		Data:    nil,
-		Error:   "Transaction with ID " + id + " not found",
-	})
+	respondError(c, http.StatusNotFound, "Transaction not found", apierror.NotFound("Transaction", id))
 }
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"e-commerce/internal/health"
+	"e-commerce/internal/metrics"
+)
+
+// Metric bisnis. Counter dihitung sejak server start, gauge dihitung
+// ulang dari storage setiap kali /metrics dibaca.
+var (
+	registry = metrics.NewRegistry()
+	checker  = health.New()
+
+	transactionsCreated = registry.Counter("shop_transactions_created_total", "Transactions created since the server started.")
+	paymentFailures     = registry.Counter("shop_payment_failures_total", "Failed payments by reason: declined, timeout or failed.", "reason")
+	revenueTotal        = registry.Counter("shop_revenue_total", "Sum of transaction totals created since the server started, by currency.", "currency")
+
+	// storageLoaded diisi setelah data awal selesai dimuat
+	storageLoaded atomic.Bool
+	// storageDirs adalah folder yang ditulis storage backend dan outbox,
+	// diisi sebelum storageLoaded
+	storageDirs []string
+)
+
+func init() {
+	registry.GaugeFunc("shop_products", "Number of products.", func() float64 {
+		return float64(len(products))
+	})
+	registry.GaugeFunc("shop_products_out_of_stock", "Number of products with zero stock.", func() float64 {
+		count := 0
+		for _, product := range products {
+			if product.Stock == 0 {
+				count++
+			}
+		}
+		return float64(count)
+	})
+	registry.GaugeFunc("shop_sources", "Number of sources.", func() float64 {
+		return float64(len(sources))
+	})
+	registry.GaugeFunc("shop_transactions", "Number of stored transactions.", func() float64 {
+		return float64(len(transactions))
+	})
+
+	registry.GaugeFunc("shop_sse_clients", "Number of connected Server-Sent Events clients.", func() float64 {
+		return float64(stockStream.Clients() + transactionStream.Clients())
+	})
+
+	checker.Add("storage", checkStorage)
+}
+
+// watchStorage mendaftarkan folder outbox dan snapshot untuk checkStorage.
+// Folder snapshot dibuat sekarang karena snapshot pertama bisa baru
+// ditulis beberapa saat lagi.
+func watchStorage(outboxDir string) error {
+	if outboxDir != "" {
+		storageDirs = append(storageDirs, filepath.Join(outboxDir, "e-commerce"))
+	}
+	if snapshots != nil {
+		dir := filepath.Dir(snapshots.Path())
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		storageDirs = append(storageDirs, dir)
+	}
+	return nil
+}
+
+// checkStorage gagal selama data awal belum dimuat atau kalau folder
+// snapshot, journal atau outbox tidak bisa ditulis lagi
+func checkStorage(ctx context.Context) error {
+	if !storageLoaded.Load() {
+		return errors.New("storage is not loaded yet")
+	}
+	for _, dir := range storageDirs {
+		if err := health.Writable(dir); err != nil {
+			return fmt.Errorf("storage is not writable: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordTransaction memperbarui metric setelah transaksi tersimpan
+func recordTransaction(t Transaction) {
+	transactionsCreated.Inc()
+	revenueTotal.Add(t.Total, currencyOf(t.Currency))
+}
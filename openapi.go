@@ -0,0 +1,268 @@
+package main
+
+import (
+	"net/http"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/health"
+	"e-commerce/internal/openapi"
+	"e-commerce/internal/payment"
+	"e-commerce/internal/shipping"
+	"e-commerce/internal/snapshot"
+	"e-commerce/internal/webhook"
+)
+
+// buildOpenAPISpec mendeskripsikan semua route di setupRouter.
+// openapi_test.go gagal kalau ada route yang belum terdaftar di sini.
+func buildOpenAPISpec() *openapi.Spec {
+	spec := openapi.New(openapi.Info{
+		Title:       "E-Commerce API",
+		Version:     "1.0.0",
+		Description: "REST API untuk manajemen produk, source (supplier) dan transaksi. Semua response memakai envelope {message, data, error}.",
+	}, apierror.Error{})
+
+	spec.SecurityScheme(openapi.BearerAuth, &openapi.SecurityScheme{
+		Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+		Description: "Token dari POST /auth/login",
+	})
+	spec.SecurityScheme(openapi.APIKeyAuth, &openapi.SecurityScheme{
+		Type: "apiKey", In: "header", Name: "X-API-Key",
+		Description: "API key supplier yang dibuat admin per source",
+	})
+
+	bearer := []string{openapi.BearerAuth}
+	bad := http.StatusBadRequest
+	notFound := http.StatusNotFound
+	currencyParam := openapi.Param{Name: "currency", Description: "Tampilkan harga dalam mata uang ini, contoh SGD"}
+	streamParams := []openapi.Param{
+		{Name: "product_id", Description: "Hanya event untuk produk ini"},
+		{Name: "source_id", Description: "Hanya event untuk produk milik source ini"},
+		{Name: "last_event_id", Description: "Alternatif header Last-Event-ID"},
+	}
+
+	routes := []openapi.Route{
+		// Auth
+		{Method: "POST", Path: "/auth/login", Tag: "Auth", Summary: "Login dan dapatkan JWT",
+			Request: LoginRequest{}, Response: LoginResponse{}, Errors: []int{bad, http.StatusUnauthorized, http.StatusTooManyRequests}},
+		{Method: "GET", Path: "/auth/me", Tag: "Auth", Summary: "User yang sedang login",
+			Security: bearer, Response: auth.Principal{}},
+
+		// Products
+		{Method: "GET", Path: "/products", Tag: "Products", Summary: "Ambil semua produk",
+			Query:    []openapi.Param{{Name: "source_id", Description: "Filter berdasarkan source"}, {Name: "sort", Description: "rating: rating tertinggi dulu"}, currencyParam},
+			Response: []Product{}, Errors: []int{bad}},
+		{Method: "GET", Path: "/products/:id", Tag: "Products", Summary: "Ambil produk berdasarkan ID",
+			Query: []openapi.Param{currencyParam}, Response: Product{}, Errors: []int{bad, notFound}},
+		{Method: "POST", Path: "/products", Tag: "Products", Summary: "Tambah produk baru", Description: "Role: admin, staff",
+			Security: bearer, Request: Product{}, Response: Product{}, Status: http.StatusCreated, Errors: []int{bad}},
+		{Method: "PUT", Path: "/products/:id", Tag: "Products", Summary: "Update produk", Description: "Role: admin, staff. Perubahan stock hanya mengenai gudang utama.",
+			Security: bearer, Request: Product{}, Response: Product{}, Errors: []int{bad, notFound, http.StatusConflict}},
+		{Method: "DELETE", Path: "/products/:id", Tag: "Products", Summary: "Hapus produk", Description: "Role: admin",
+			Security: bearer, Errors: []int{notFound}},
+		{Method: "POST", Path: "/products/bulk", Tag: "Products", Summary: "Bulk create, update dan delete produk",
+			Description: "Role: admin, staff. Mode atomic membatalkan semua operasi kalau ada yang gagal, best_effort menyimpan yang berhasil (207).",
+			Security:    bearer, Request: BulkRequest{}, Response: BulkResult{}, Errors: []int{bad, http.StatusMultiStatus}},
+		{Method: "PATCH", Path: "/products/:id/stock", Tag: "Products", Summary: "Update stock produk",
+			Description: "Role: admin, staff, atau API key supplier dengan scope stock:write untuk produk milik source-nya. Perubahan stock hanya mengenai gudang utama.",
+			Security:    []string{openapi.BearerAuth, openapi.APIKeyAuth}, Request: StockUpdateRequest{}, Response: Product{}, Errors: []int{bad, notFound, http.StatusConflict}},
+
+		// Sources
+		{Method: "GET", Path: "/sources", Tag: "Sources", Summary: "Ambil semua source", Response: []Source{}},
+		{Method: "GET", Path: "/sources/:id", Tag: "Sources", Summary: "Ambil source berdasarkan ID",
+			Response: Source{}, Errors: []int{notFound}},
+		{Method: "POST", Path: "/sources", Tag: "Sources", Summary: "Tambah source baru", Description: "Role: admin, staff",
+			Security: bearer, Request: Source{}, Response: Source{}, Status: http.StatusCreated, Errors: []int{bad}},
+		{Method: "PUT", Path: "/sources/:id", Tag: "Sources", Summary: "Update source", Description: "Role: admin, staff",
+			Security: bearer, Request: Source{}, Response: Source{}, Errors: []int{bad, notFound}},
+		{Method: "DELETE", Path: "/sources/:id", Tag: "Sources", Summary: "Hapus source", Description: "Role: admin. API key milik source ikut dicabut.",
+			Security: bearer, Errors: []int{notFound}},
+		{Method: "POST", Path: "/sources/bulk", Tag: "Sources", Summary: "Bulk create, update dan delete source", Description: "Role: admin, staff",
+			Security: bearer, Request: BulkRequest{}, Response: BulkResult{}, Errors: []int{bad, http.StatusMultiStatus}},
+
+		// API keys
+		{Method: "POST", Path: "/sources/:id/api-keys", Tag: "API Keys", Summary: "Buat API key untuk source", Description: "Role: admin. Key asli hanya ditampilkan sekali.",
+			Security: bearer, Request: CreateAPIKeyRequest{}, Response: IssuedAPIKey{}, Status: http.StatusCreated, Errors: []int{bad, notFound}},
+		{Method: "GET", Path: "/sources/:id/api-keys", Tag: "API Keys", Summary: "Lihat API key milik source", Description: "Role: admin",
+			Security: bearer, Response: []auth.APIKey{}, Errors: []int{notFound}},
+		{Method: "POST", Path: "/api-keys/:id/rotate", Tag: "API Keys", Summary: "Rotasi API key", Description: "Role: admin",
+			Security: bearer, Request: RotateAPIKeyRequest{}, Response: IssuedAPIKey{}, Status: http.StatusCreated, Errors: []int{bad, notFound, http.StatusConflict}},
+		{Method: "DELETE", Path: "/api-keys/:id", Tag: "API Keys", Summary: "Cabut API key", Description: "Role: admin",
+			Security: bearer, Response: auth.APIKey{}, Errors: []int{notFound}},
+
+		// Server-Sent Events
+		{Method: "GET", Path: "/events/stock", Tag: "Events", Summary: "Stream perubahan stock",
+			Description: "Server-Sent Events product.stock_changed. Kirim header Last-Event-ID untuk melanjutkan setelah reconnect; heartbeat dikirim setiap 15 detik.",
+			Query:       streamParams, Produces: "text/event-stream", Errors: []int{bad, http.StatusServiceUnavailable}},
+		{Method: "GET", Path: "/events/transactions", Tag: "Events", Summary: "Stream transaksi baru",
+			Description: "Server-Sent Events transaction.created. Role: admin, staff, customer; customer hanya menerima transaksinya sendiri.",
+			Security:    bearer, Query: streamParams, Produces: "text/event-stream", Errors: []int{bad, http.StatusServiceUnavailable}},
+
+		// Snapshots
+		{Method: "POST", Path: "/admin/snapshots", Tag: "Admin", Summary: "Tulis snapshot sekarang",
+			Description: "Role: admin. Hanya tersedia dengan storage backend snapshot atau journal; di backend journal sekaligus compaction.",
+			Security:    bearer, Response: snapshot.Info{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+		{Method: "GET", Path: "/admin/snapshots/latest", Tag: "Admin", Summary: "Download snapshot terakhir",
+			Description: "Role: admin. File JSON berversi yang sama dengan yang dimuat saat start.",
+			Security:    bearer, Produces: "application/json", Errors: []int{notFound, http.StatusConflict}},
+
+		// Webhooks
+		{Method: "POST", Path: "/webhooks", Tag: "Webhooks", Summary: "Daftarkan webhook", Description: "Role: admin. Secret untuk verifikasi signature hanya ditampilkan sekali.",
+			Security: bearer, Request: WebhookRequest{}, Response: webhook.Subscription{}, Status: http.StatusCreated, Errors: []int{bad}},
+		{Method: "GET", Path: "/webhooks", Tag: "Webhooks", Summary: "Ambil semua webhook", Description: "Role: admin",
+			Security: bearer, Response: []webhook.Subscription{}},
+		{Method: "GET", Path: "/webhooks/:id", Tag: "Webhooks", Summary: "Ambil webhook berdasarkan ID", Description: "Role: admin",
+			Security: bearer, Response: webhook.Subscription{}, Errors: []int{notFound}},
+		{Method: "PUT", Path: "/webhooks/:id", Tag: "Webhooks", Summary: "Update webhook", Description: "Role: admin",
+			Security: bearer, Request: WebhookRequest{}, Response: webhook.Subscription{}, Errors: []int{bad, notFound}},
+		{Method: "DELETE", Path: "/webhooks/:id", Tag: "Webhooks", Summary: "Hapus webhook", Description: "Role: admin. Delivery yang belum terkirim dibatalkan.",
+			Security: bearer, Errors: []int{notFound}},
+		{Method: "GET", Path: "/webhooks/:id/deliveries", Tag: "Webhooks", Summary: "Delivery log webhook", Description: "Role: admin. Terbaru lebih dulu.",
+			Security: bearer, Query: []openapi.Param{{Name: "status", Description: "Filter status: pending, retrying, succeeded, failed"}},
+			Response: []webhook.Delivery{}, Errors: []int{bad, notFound}},
+		{Method: "GET", Path: "/webhooks/:id/deliveries/:delivery_id", Tag: "Webhooks", Summary: "Detail delivery beserta semua percobaan", Description: "Role: admin",
+			Security: bearer, Response: webhook.Delivery{}, Errors: []int{notFound}},
+		{Method: "POST", Path: "/webhooks/:id/deliveries/:delivery_id/redeliver", Tag: "Webhooks", Summary: "Kirim ulang event", Description: "Role: admin. Membuat delivery baru dengan event yang sama.",
+			Security: bearer, Response: webhook.Delivery{}, Status: http.StatusAccepted, Errors: []int{notFound}},
+
+		// Exchange Rates
+		{Method: "GET", Path: "/exchange-rates", Tag: "Exchange Rates", Summary: "Ambil semua kurs",
+			Description: "Rate adalah nilai 1 unit currency dalam IDR mulai effective_from",
+			Query:       []openapi.Param{{Name: "currency", Description: "Filter berdasarkan kode mata uang"}},
+			Response:    []ExchangeRate{}},
+		{Method: "POST", Path: "/exchange-rates", Tag: "Exchange Rates", Summary: "Tambah kurs", Description: "Role: admin. effective_from kosong berarti berlaku sekarang.",
+			Security: bearer, Request: ExchangeRate{}, Response: ExchangeRate{}, Status: http.StatusCreated, Errors: []int{bad, http.StatusConflict}},
+		{Method: "DELETE", Path: "/exchange-rates/:id", Tag: "Exchange Rates", Summary: "Hapus kurs terjadwal", Description: "Role: admin. Kurs yang sudah berlaku tidak bisa dihapus.",
+			Security: bearer, Errors: []int{notFound, http.StatusConflict}},
+
+		// Cost Prices
+		{Method: "GET", Path: "/products/:id/costs", Tag: "Cost Prices", Summary: "Riwayat harga beli produk",
+			Description: "Role: admin, staff. Harga beli per source berlaku mulai effective_from.",
+			Security:    bearer, Query: []openapi.Param{{Name: "source_id", Description: "Filter berdasarkan source"}},
+			Response: []CostPrice{}, Errors: []int{notFound}},
+		{Method: "POST", Path: "/products/:id/costs", Tag: "Cost Prices", Summary: "Tambah harga beli", Description: "Role: admin, staff. effective_from kosong berarti berlaku sekarang.",
+			Security: bearer, Request: CostPrice{}, Response: CostPrice{}, Status: http.StatusCreated, Errors: []int{bad, notFound, http.StatusConflict}},
+		{Method: "DELETE", Path: "/products/:id/costs/:cost_id", Tag: "Cost Prices", Summary: "Hapus harga beli terjadwal", Description: "Role: admin, staff. Harga beli yang sudah berlaku tidak bisa dihapus.",
+			Security: bearer, Errors: []int{notFound, http.StatusConflict}},
+		{Method: "GET", Path: "/reports/margins/products", Tag: "Cost Prices", Summary: "Margin kotor per produk",
+			Description: "Role: admin, staff. Dalam IDR dari transaksi paid, tanpa ongkos kirim.",
+			Security:    bearer, Response: []MarginRow{}},
+		{Method: "GET", Path: "/reports/margins/sources", Tag: "Cost Prices", Summary: "Margin kotor per source",
+			Description: "Role: admin, staff. Dalam IDR dari transaksi paid, tanpa ongkos kirim.",
+			Security:    bearer, Response: []MarginRow{}},
+		{Method: "GET", Path: "/reports/margins/transactions", Tag: "Cost Prices", Summary: "Margin kotor per transaksi",
+			Description: "Role: admin, staff. cost dan margin null kalau belum ada harga beli saat transaksi.",
+			Security:    bearer, Response: []TransactionMargin{}},
+
+		// Warehouses
+		{Method: "GET", Path: "/warehouses", Tag: "Warehouses", Summary: "Ambil semua gudang", Response: []Warehouse{}},
+		{Method: "GET", Path: "/warehouses/:id", Tag: "Warehouses", Summary: "Ambil gudang berdasarkan ID", Response: Warehouse{}, Errors: []int{notFound}},
+		{Method: "POST", Path: "/warehouses", Tag: "Warehouses", Summary: "Buat gudang baru",
+			Description: "Role: admin. Gudang pertama menjadi gudang utama dan menampung stock produk yang belum ada di gudang lain.",
+			Security:    bearer, Request: Warehouse{}, Response: Warehouse{}, Status: http.StatusCreated, Errors: []int{bad}},
+		{Method: "PUT", Path: "/warehouses/:id", Tag: "Warehouses", Summary: "Update gudang", Description: "Role: admin. Gudang utama tidak bisa diganti.",
+			Security: bearer, Request: Warehouse{}, Response: Warehouse{}, Errors: []int{bad, notFound}},
+		{Method: "DELETE", Path: "/warehouses/:id", Tag: "Warehouses", Summary: "Hapus gudang",
+			Description: "Role: admin. Hanya gudang tanpa stock; gudang utama hanya kalau tidak ada gudang lain.",
+			Security:    bearer, Errors: []int{notFound, http.StatusConflict}},
+		{Method: "GET", Path: "/warehouses/:id/stock", Tag: "Warehouses", Summary: "Stock semua produk di gudang", Description: "Role: admin, staff",
+			Security: bearer, Response: []StockLevel{}, Errors: []int{notFound}},
+		{Method: "PUT", Path: "/warehouses/:id/stock/:product_id", Tag: "Warehouses", Summary: "Atur stock produk di gudang",
+			Description: "Role: admin, staff. Stock produk ikut berubah sebesar selisihnya dan dicatat sebagai stock movement adjustment.",
+			Security:    bearer, Request: WarehouseStockRequest{}, Response: StockLevel{}, Errors: []int{bad, notFound}},
+		{Method: "GET", Path: "/products/:id/stock-levels", Tag: "Warehouses", Summary: "Stock produk per gudang", Description: "Role: admin, staff. Jumlahnya sama dengan stock produk.",
+			Security: bearer, Response: []StockLevel{}, Errors: []int{notFound}},
+		{Method: "POST", Path: "/stock-transfers", Tag: "Warehouses", Summary: "Pindahkan stock antar gudang", Description: "Role: admin, staff. Stock produk tidak berubah.",
+			Security: bearer, Request: StockTransferRequest{}, Response: StockMovement{}, Status: http.StatusCreated, Errors: []int{bad, notFound}},
+		{Method: "GET", Path: "/stock-movements", Tag: "Warehouses", Summary: "Riwayat perpindahan stock", Description: "Role: admin, staff",
+			Security: bearer, Query: []openapi.Param{{Name: "product_id", Description: "Filter berdasarkan produk"}, {Name: "warehouse_id", Description: "Perpindahan dari atau ke gudang ini"}},
+			Response: []StockMovement{}},
+
+		// Transactions
+		{Method: "POST", Path: "/transactions", Tag: "Transactions", Summary: "Buat transaksi baru",
+			Description: "Role: admin, staff, customer. Customer memakai profilnya sendiri; admin dan staff wajib mengisi customer_id. currency kosong berarti currency produk. Transaksi disimpan dan stock dikurangi hanya kalau pembayaran dengan payment_token berhasil. Stock diambil dari satu gudang: warehouse_id kalau diisi, selain itu menurut aturan warehouses.allocation.",
+			Security:    bearer, Request: Transaction{}, Response: Transaction{}, Status: http.StatusCreated,
+			Errors: []int{bad, http.StatusPaymentRequired, notFound, http.StatusConflict, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusGatewayTimeout}},
+		{Method: "GET", Path: "/transactions", Tag: "Transactions", Summary: "Ambil semua transaksi", Description: "Customer hanya melihat transaksinya sendiri",
+			Security: bearer, Response: []Transaction{}},
+		{Method: "GET", Path: "/transactions/:id", Tag: "Transactions", Summary: "Ambil transaksi berdasarkan ID", Description: "Customer hanya melihat transaksinya sendiri",
+			Security: bearer, Response: Transaction{}, Errors: []int{notFound}},
+		{Method: "POST", Path: "/transactions/:id/refund", Tag: "Transactions", Summary: "Refund transaksi", Description: "Role: admin. Seluruh total dikembalikan lewat payment provider; stock tidak dikembalikan.",
+			Security: bearer, Response: Transaction{}, Errors: []int{notFound, http.StatusConflict, http.StatusBadGateway, http.StatusGatewayTimeout}},
+		{Method: "POST", Path: "/guest/transactions", Tag: "Transactions", Summary: "Guest checkout tanpa login",
+			Description: "guest wajib diisi. Response berisi guest_token untuk memindahkan pesanan ke akun customer nanti.",
+			Request:     Transaction{}, Response: Transaction{}, Status: http.StatusCreated,
+			Errors: []int{bad, http.StatusPaymentRequired, notFound, http.StatusConflict, http.StatusTooManyRequests, http.StatusBadGateway, http.StatusGatewayTimeout}},
+		{Method: "POST", Path: "/payments/webhook", Tag: "Transactions", Summary: "Webhook dari payment provider",
+			Description: "Diverifikasi dengan signature provider. payment.refunded menandai transaksi sebagai refunded.",
+			Request:     payment.Event{}, Errors: []int{bad, http.StatusUnauthorized}},
+
+		// Reviews
+		{Method: "GET", Path: "/products/:id/reviews", Tag: "Reviews", Summary: "Ambil review produk",
+			Description: "Hanya review approved, yang paling membantu dulu. Admin dan staff bisa memfilter status lain.",
+			Query:       []openapi.Param{{Name: "status", Description: "Filter status: pending, approved, rejected (admin, staff)"}},
+			Response:    []Review{}, Errors: []int{bad, notFound}},
+		{Method: "POST", Path: "/products/:id/reviews", Tag: "Reviews", Summary: "Tulis review produk",
+			Description: "Hanya customer dengan transaksi paid untuk produk ini, satu review per produk. Review baru berstatus pending sampai dimoderasi.",
+			Security:    bearer, Request: Review{}, Response: Review{}, Status: http.StatusCreated, Errors: []int{bad, http.StatusForbidden, notFound, http.StatusConflict}},
+		{Method: "GET", Path: "/reviews", Tag: "Reviews", Summary: "Antrian moderasi review", Description: "Role: admin, staff",
+			Security: bearer, Query: []openapi.Param{{Name: "status", Description: "Filter status: pending, approved, rejected"}}, Response: []Review{}, Errors: []int{bad}},
+		{Method: "PATCH", Path: "/reviews/:id/status", Tag: "Reviews", Summary: "Moderasi review", Description: "Role: admin, staff",
+			Security: bearer, Request: ReviewModerationRequest{}, Response: Review{}, Errors: []int{bad, notFound}},
+		{Method: "POST", Path: "/reviews/:id/helpful", Tag: "Reviews", Summary: "Tandai review membantu",
+			Description: "Satu vote per user, tidak untuk review sendiri",
+			Security:    bearer, Response: Review{}, Errors: []int{http.StatusForbidden, notFound, http.StatusConflict}},
+
+		// Customers
+		{Method: "POST", Path: "/customers", Tag: "Customers", Summary: "Buat profil customer",
+			Description: "Role: admin, staff, customer. Customer membuat profilnya sendiri (satu per akun); admin dan staff boleh mengisi user_id. Email unik.",
+			Security:    bearer, Request: Customer{}, Response: Customer{}, Status: http.StatusCreated, Errors: []int{bad, http.StatusConflict}},
+		{Method: "GET", Path: "/customers", Tag: "Customers", Summary: "Ambil semua customer", Description: "Role: admin, staff",
+			Security: bearer, Query: []openapi.Param{{Name: "email", Description: "Filter berdasarkan email"}}, Response: []Customer{}},
+		{Method: "GET", Path: "/customers/:id", Tag: "Customers", Summary: "Ambil customer berdasarkan ID", Description: "Customer hanya melihat profilnya sendiri",
+			Security: bearer, Response: Customer{}, Errors: []int{notFound}},
+		{Method: "PUT", Path: "/customers/:id", Tag: "Customers", Summary: "Update profil customer", Description: "Customer hanya mengubah profilnya sendiri",
+			Security: bearer, Request: Customer{}, Response: Customer{}, Errors: []int{bad, notFound, http.StatusConflict}},
+		{Method: "GET", Path: "/customers/:id/transactions", Tag: "Customers", Summary: "Riwayat transaksi customer", Description: "Customer hanya melihat riwayatnya sendiri",
+			Security: bearer, Response: []Transaction{}, Errors: []int{notFound}},
+		{Method: "POST", Path: "/customers/:id/merge-guest-orders", Tag: "Customers", Summary: "Pindahkan pesanan guest ke customer",
+			Description: "Pesanan diklaim dengan guest_token dari guest checkout. Admin dan staff juga boleh memakai email untuk semua pesanan guest dengan email itu.",
+			Security:    bearer, Request: MergeGuestOrdersRequest{}, Response: GuestOrdersMergedEvent{}, Errors: []int{bad, http.StatusForbidden, notFound}},
+
+		// Shipping
+		{Method: "GET", Path: "/shipping/zones", Tag: "Shipping", Summary: "Ambil semua zona dan tabel tarif", Response: []shipping.Zone{}},
+		{Method: "POST", Path: "/shipping/zones", Tag: "Shipping", Summary: "Tambah zona pengiriman", Description: "Role: admin. Satu negara hanya boleh ada di satu zona.",
+			Security: bearer, Request: shipping.Zone{}, Response: shipping.Zone{}, Status: http.StatusCreated, Errors: []int{bad, http.StatusConflict}},
+		{Method: "PUT", Path: "/shipping/zones/:id", Tag: "Shipping", Summary: "Update zona pengiriman", Description: "Role: admin",
+			Security: bearer, Request: shipping.Zone{}, Response: shipping.Zone{}, Errors: []int{bad, notFound, http.StatusConflict}},
+		{Method: "DELETE", Path: "/shipping/zones/:id", Tag: "Shipping", Summary: "Hapus zona pengiriman", Description: "Role: admin",
+			Security: bearer, Errors: []int{notFound}},
+		{Method: "POST", Path: "/shipping/quote", Tag: "Shipping", Summary: "Hitung ongkos kirim",
+			Description: "Berat yang ditagih adalah berat asli atau berat volumetrik (cm³ / 6000), mana yang lebih besar",
+			Request:     ShippingQuoteRequest{}, Response: ShippingQuote{}, Errors: []int{bad, notFound}},
+		{Method: "GET", Path: "/shipments", Tag: "Shipping", Summary: "Ambil semua shipment", Description: "Customer hanya melihat shipment dari transaksinya sendiri",
+			Security: bearer, Query: []openapi.Param{{Name: "transaction_id", Description: "Filter berdasarkan transaksi"}, {Name: "status", Description: "Filter status: pending, shipped, in_transit, out_for_delivery, delivered"}},
+			Response: []Shipment{}},
+		{Method: "GET", Path: "/shipments/:id", Tag: "Shipping", Summary: "Ambil shipment berdasarkan ID", Description: "Customer hanya melihat shipment dari transaksinya sendiri",
+			Security: bearer, Response: Shipment{}, Errors: []int{notFound}},
+		{Method: "POST", Path: "/shipments/:id/dispatch", Tag: "Shipping", Summary: "Serahkan paket ke kurir", Description: "Role: admin, staff. Mengisi carrier dan tracking_number.",
+			Security: bearer, Response: Shipment{}, Errors: []int{notFound, http.StatusConflict, http.StatusBadGateway, http.StatusGatewayTimeout}},
+		{Method: "POST", Path: "/shipments/:id/refresh", Tag: "Shipping", Summary: "Ambil status terbaru dari kurir", Description: "Customer hanya untuk shipment dari transaksinya sendiri",
+			Security: bearer, Response: Shipment{}, Errors: []int{notFound, http.StatusConflict, http.StatusBadGateway, http.StatusGatewayTimeout}},
+
+		// Monitoring
+		{Method: "GET", Path: "/healthz", Tag: "Monitoring", Summary: "Liveness probe", Response: health.Report{}},
+		{Method: "GET", Path: "/readyz", Tag: "Monitoring", Summary: "Readiness probe, mengecek storage",
+			Response: health.Report{}, Errors: []int{http.StatusServiceUnavailable}},
+		{Method: "GET", Path: "/metrics", Tag: "Monitoring", Summary: "Metrics dalam format teks Prometheus", Produces: "text/plain"},
+
+		// Docs
+		{Method: "GET", Path: "/openapi.json", Tag: "Docs", Summary: "Dokumen OpenAPI ini", Produces: "application/json"},
+		{Method: "GET", Path: "/docs", Tag: "Docs", Summary: "UI dokumentasi interaktif", Produces: "text/html"},
+	}
+
+	for _, route := range routes {
+		spec.Add(route)
+	}
+	return spec
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/config"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/payment"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status transaksi. Transaksi hanya disimpan setelah pembayaran berhasil,
+// jadi tidak ada status pending.
+const (
+	TransactionPaid     = "paid"
+	TransactionRefunded = "refunded"
+)
+
+// payments diganti di main sesuai konfigurasi
+var (
+	payments       payment.Provider = payment.NewFake("")
+	paymentTimeout                  = 10 * time.Second
+)
+
+// stockHolds adalah jumlah unit per produk dan gudang yang ditahan
+// transaksi yang sedang menunggu pembayaran. Hold hanya di memory: kalau
+// server mati, pembayaran yang sedang berjalan ikut batal dan hold-nya
+// hilang.
+var (
+	holdMu     sync.Mutex
+	stockHolds = map[stockKey]int{}
+)
+
+// refundsInFlight menandai transaksi yang refund-nya sedang dikirim ke
+// provider supaya refund yang bersamaan tidak mengembalikan uang dua kali.
+// Sama seperti hold, hanya di memory.
+var (
+	refundMu        sync.Mutex
+	refundsInFlight = map[string]bool{}
+)
+
+// stockKey dengan warehouseID kosong berarti belum ada gudang
+type stockKey struct {
+	productID   string
+	warehouseID string
+}
+
+func initializePayments(cfg *config.Config) {
+	secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if secret == "" {
+		slog.Warn("PAYMENT_WEBHOOK_SECRET not set, payment webhooks can only be simulated in tests")
+	}
+	switch cfg.Payment.Provider {
+	case payment.ProviderFake:
+		payments = payment.NewFake(secret)
+	}
+	paymentTimeout = cfg.Payment.Timeout.Duration
+}
+
+// holdStock menahan quantity unit produk selama pembayaran berjalan di
+// gudang pertama dari candidates yang stock-nya cukup. Stock produk belum
+// dikurangi, tapi unit yang ditahan tidak bisa dibeli transaksi lain.
+// Kalau tidak ada yang cukup, available adalah stock terbanyak yang tersisa.
+func holdStock(product Product, candidates []string, quantity int) (warehouseID string, available int, ok bool) {
+	holdMu.Lock()
+	defer holdMu.Unlock()
+	available = 0
+	for i, candidate := range candidates {
+		key := stockKey{product.ID, candidate}
+		free := stockIn(product, candidate) - stockHolds[key]
+		if free >= quantity {
+			stockHolds[key] += quantity
+			return candidate, free, true
+		}
+		if i == 0 || free > available {
+			available = free
+		}
+	}
+	return "", available, false
+}
+
+func releaseStock(productID, warehouseID string, quantity int) {
+	holdMu.Lock()
+	defer holdMu.Unlock()
+	key := stockKey{productID, warehouseID}
+	if stockHolds[key] -= quantity; stockHolds[key] <= 0 {
+		delete(stockHolds, key)
+	}
+}
+
+// chargeTransaction meng-authorize lalu capture total transaksi dalam
+// batas paymentTimeout. Kalau capture gagal, authorization dibatalkan.
+// Kalau gagal, request sudah dijawab dan hasilnya false.
+func chargeTransaction(c *gin.Context, t Transaction, token string) (string, bool) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), paymentTimeout)
+	defer cancel()
+
+	authorization, err := payments.Authorize(ctx, payment.Request{Reference: t.ID, Amount: t.Total, Currency: t.Currency, Token: token})
+	if err == nil {
+		if err = payments.Capture(ctx, authorization.ID); err != nil {
+			cancelPayment(c, authorization.ID, t.Total)
+		}
+	}
+	if err != nil {
+		respondPaymentError(c, t, err)
+		return "", false
+	}
+	return authorization.ID, true
+}
+
+// cancelPayment membatalkan authorization atau mengembalikan dana yang
+// sudah ditarik. Tetap dijalankan walaupun client sudah memutus request.
+func cancelPayment(c *gin.Context, paymentID string, amount float64) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), paymentTimeout)
+	defer cancel()
+	if err := payments.Refund(ctx, paymentID, amount); err != nil {
+		logging.From(c).Error("Could not cancel payment", "payment_id", paymentID, "provider", payments.Name(), "error", err)
+	}
+}
+
+func respondPaymentError(c *gin.Context, t Transaction, err error) {
+	reason := "failed"
+	switch {
+	case errors.Is(err, payment.ErrDeclined):
+		reason = "declined"
+		respondError(c, http.StatusPaymentRequired, "Payment declined", apierror.New(apierror.CodePaymentDeclined, "The payment was declined by the provider"))
+	case errors.Is(err, context.DeadlineExceeded):
+		reason = "timeout"
+		respondError(c, http.StatusGatewayTimeout, "Payment timed out", apierror.Newf(apierror.CodePaymentTimeout, "The payment provider did not respond within %s", paymentTimeout))
+	default:
+		respondError(c, http.StatusBadGateway, "Payment failed", apierror.New(apierror.CodePaymentFailed, "The payment could not be processed"))
+	}
+	paymentFailures.Inc(reason)
+	logging.From(c).Warn("Payment failed",
+		"transaction_id", t.ID,
+		"product_id", t.ProductID,
+		"total", t.Total,
+		"currency", t.Currency,
+		"provider", payments.Name(),
+		"reason", reason,
+		"error", err,
+	)
+}
+
+// refundTransaction mengembalikan seluruh total ke pembeli. Stock tidak
+// dikembalikan otomatis; kalau barang kembali, stock diupdate terpisah.
+func refundTransaction(c *gin.Context) {
+	id := c.Param("id")
+
+	if !startRefund(id) {
+		respondError(c, http.StatusConflict, "Refund in progress", apierror.Newf(apierror.CodeConflict, "A refund for transaction %s is already in progress", id))
+		return
+	}
+	defer finishRefund(id)
+
+	// Status dibaca setelah refund ditandai, jadi refund yang baru selesai
+	// atau webhook yang sudah diproses ikut terlihat
+	transaction, ok := findTransaction(id)
+	if !ok {
+		respondError(c, http.StatusNotFound, "Transaction not found", apierror.NotFound("Transaction", id))
+		return
+	}
+	if transaction.Status != TransactionPaid || transaction.PaymentID == "" {
+		respondError(c, http.StatusConflict, "Transaction cannot be refunded", apierror.Newf(apierror.CodeConflict, "Transaction %s is %s and has no payment to refund", id, transaction.Status))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), paymentTimeout)
+	defer cancel()
+	if err := payments.Refund(ctx, transaction.PaymentID, transaction.Total); err != nil {
+		respondPaymentError(c, transaction, err)
+		return
+	}
+
+	refunded, ok := markRefunded(c, transaction)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Transaction refunded successfully",
+		Data:    refunded,
+		Error:   nil,
+	})
+}
+
+// receivePaymentWebhook memproses notifikasi dari payment provider,
+// misalnya refund yang dilakukan dari dashboard provider. Event yang tidak
+// dikenal atau sudah diproses tetap dijawab 200 supaya provider tidak
+// mengirim ulang.
+func receivePaymentWebhook(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+	event, err := payments.VerifyWebhook(c.Request.Header, body)
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		respondError(c, http.StatusUnauthorized, "Invalid signature", apierror.New(apierror.CodeUnauthorized, "Payment webhook signature is invalid"))
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	log := logging.From(c).With("payment_event_id", event.ID, "payment_event", event.Type, "payment_id", event.PaymentID)
+	if event.Type == payment.EventRefunded {
+		transaction, found := findTransactionByPayment(event.PaymentID)
+		switch {
+		case !found:
+			log.Warn("Payment webhook for unknown payment")
+		case transaction.Status != TransactionPaid:
+			log.Info("Payment webhook already applied", "transaction_id", transaction.ID, "status", transaction.Status)
+		case event.Amount < transaction.Total:
+			log.Warn("Partial refund is not tracked", "transaction_id", transaction.ID, "amount", event.Amount, "total", transaction.Total)
+		default:
+			if _, ok := markRefunded(c, transaction); !ok {
+				return
+			}
+		}
+	} else {
+		log.Info("Payment webhook ignored")
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Payment webhook processed",
+		Data:    nil,
+		Error:   nil,
+	})
+}
+
+// markRefunded dipanggil dari refund dan webhook. Status dicek ulang di
+// dalam commit; transaksi yang sudah refunded tidak dicatat lagi.
+func markRefunded(c *gin.Context, transaction Transaction) (Transaction, bool) {
+	var refunded Transaction
+	applied := false
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		i := transactionIndex(transaction.ID)
+		if i < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Transaction not found", apierror.NotFound("Transaction", transaction.ID))
+		}
+		refunded = transactions[i]
+		if refunded.Status == TransactionRefunded {
+			return nil, nil, nil
+		}
+		refunded.Status = TransactionRefunded
+		applied = true
+		return func() { transactions[i] = refunded }, []events.Message{{Type: EventTransactionRefunded, Data: refunded}}, nil
+	}) {
+		return Transaction{}, false
+	}
+	if applied {
+		logging.From(c).Info("Transaction refunded",
+			"transaction_id", refunded.ID,
+			"payment_id", refunded.PaymentID,
+			"total", refunded.Total,
+			"currency", refunded.Currency,
+		)
+	}
+	return refunded, true
+}
+
+func startRefund(id string) bool {
+	refundMu.Lock()
+	defer refundMu.Unlock()
+	if refundsInFlight[id] {
+		return false
+	}
+	refundsInFlight[id] = true
+	return true
+}
+
+func finishRefund(id string) {
+	refundMu.Lock()
+	defer refundMu.Unlock()
+	delete(refundsInFlight, id)
+}
+
+func transactionIndex(id string) int {
+	for i := range transactions {
+		if transactions[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func findTransaction(id string) (Transaction, bool) {
+	if i := transactionIndex(id); i >= 0 {
+		return transactions[i], true
+	}
+	return Transaction{}, false
+}
+
+func findTransactionByPayment(paymentID string) (Transaction, bool) {
+	for _, transaction := range transactions {
+		if paymentID != "" && transaction.PaymentID == paymentID {
+			return transaction, true
+		}
+	}
+	return Transaction{}, false
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"e-commerce/internal/auth"
+	"e-commerce/internal/payment"
+)
+
+func TestPaymentCommitsStockOnlyOnSuccess(t *testing.T) {
+	api := newTestAPI(t, auth.User{ID: "u1", Username: "admin", Role: auth.RoleAdmin})
+	fake := payment.NewFake("secret")
+	savedProvider, savedTimeout := payments, paymentTimeout
+	payments, paymentTimeout = fake, 50*time.Millisecond
+	t.Cleanup(func() { payments, paymentTimeout = savedProvider, savedTimeout })
+
+	do := func(method, path, body string, header http.Header) (int, Transaction) {
+		t.Helper()
+		var transaction Transaction
+		code := api.send("admin", method, path, body, header, &transaction)
+		return code, transaction
+	}
+	stock := func() int {
+		product, _ := findProduct("1")
+		return product.Stock
+	}
+
+	for token, want := range map[string]int{
+		payment.TokenDeclined:        http.StatusPaymentRequired,
+		payment.TokenTimeout:         http.StatusGatewayTimeout,
+		payment.TokenCaptureDeclined: http.StatusPaymentRequired,
+	} {
+		code, _ := do(http.MethodPost, "/transactions", `{"product_id":"1","quantity":10,"customer_id":"1","payment_token":"`+token+`"}`, nil)
+		if code != want {
+			t.Errorf("%s: status %d, want %d", token, code, want)
+		}
+	}
+	if stock() != 10 || len(transactions) != 0 || len(stockHolds) != 0 {
+		t.Fatalf("failed payments changed state: stock %d, %d transactions, holds %v", stock(), len(transactions), stockHolds)
+	}
+
+	code, paid := do(http.MethodPost, "/transactions", `{"product_id":"1","quantity":10,"customer_id":"1","payment_token":"tok_success"}`, nil)
+	if code != http.StatusCreated || paid.Status != TransactionPaid || paid.PaymentID == "" || paid.PaymentToken != "" || stock() != 0 {
+		t.Fatalf("status %d, transaction %+v, stock %d", code, paid, stock())
+	}
+
+	// Refund dari dashboard provider lewat webhook, dikirim dua kali
+	body, header := fake.Webhook(payment.Event{ID: "evt_1", Type: payment.EventRefunded, PaymentID: paid.PaymentID, Amount: paid.Total})
+	for range 2 {
+		if code, _ := do(http.MethodPost, "/payments/webhook", string(body), header); code != http.StatusOK {
+			t.Fatalf("webhook status %d", code)
+		}
+	}
+	if got, _ := findTransaction(paid.ID); got.Status != TransactionRefunded {
+		t.Errorf("status after webhook = %s", got.Status)
+	}
+	if code, _ := do(http.MethodPost, "/transactions/"+paid.ID+"/refund", "", nil); code != http.StatusConflict {
+		t.Errorf("second refund status %d", code)
+	}
+
+	// Refund bersamaan hanya dikirim sekali ke provider
+	code, paid = do(http.MethodPost, "/transactions", `{"product_id":"2","quantity":1,"customer_id":"1","payment_token":"tok_success"}`, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("status %d", code)
+	}
+	codes := make(chan int, 5)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			code, _ := do(http.MethodPost, "/transactions/"+paid.ID+"/refund", "", nil)
+			codes <- code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	refunded := 0
+	for code := range codes {
+		switch code {
+		case http.StatusOK:
+			refunded++
+		case http.StatusConflict:
+		default:
+			t.Errorf("concurrent refund status %d", code)
+		}
+	}
+	if refunded != 1 {
+		t.Errorf("%d refunds succeeded, want 1", refunded)
+	}
+
+	header.Set(payment.HeaderSignature, "t=1,v1=00")
+	if code, _ := do(http.MethodPost, "/payments/webhook", string(body), header); code != http.StatusUnauthorized {
+		t.Errorf("invalid signature status %d", code)
+	}
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status moderasi review. Review baru menunggu moderasi dan hanya review
+// approved yang tampil ke publik dan dihitung di rating produk.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// Review hanya bisa ditulis customer yang punya transaksi paid untuk
+// produk itu, satu review per customer per produk.
+type Review struct {
+	ID           string    `json:"id"`
+	ProductID    string    `json:"product_id"`
+	CustomerID   string    `json:"customer_id"`
+	Rating       int       `json:"rating" validate:"min=1,max=5"`
+	Text         string    `json:"text" validate:"required,max=2000"`
+	Status       string    `json:"status"`
+	HelpfulVotes int       `json:"helpful_votes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ReviewVote mencatat user yang menandai review membantu, supaya satu
+// user hanya bisa vote sekali
+type ReviewVote struct {
+	ReviewID string `json:"review_id"`
+	UserID   string `json:"user_id"`
+}
+
+type ReviewModerationRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending approved rejected"`
+}
+
+type ReviewQuery struct {
+	Status string `form:"status" json:"status" validate:"omitempty,oneof=pending approved rejected"`
+}
+
+// Domain event review
+const (
+	EventReviewCreated   = "review.created"
+	EventReviewModerated = "review.moderated"
+	EventReviewVoted     = "review.voted"
+)
+
+var (
+	reviews     = []Review{}
+	reviewVotes = []ReviewVote{}
+)
+
+// ratingSummary adalah agregat review approved untuk satu produk
+type ratingSummary struct {
+	total int
+	count int
+}
+
+func (s ratingSummary) average() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return math.Round(float64(s.total)/float64(s.count)*100) / 100
+}
+
+// ratingSummaries menghitung agregat semua produk sekaligus untuk list
+func ratingSummaries() map[string]ratingSummary {
+	summaries := map[string]ratingSummary{}
+	for _, review := range reviews {
+		if review.Status == ReviewApproved {
+			s := summaries[review.ProductID]
+			s.total += review.Rating
+			s.count++
+			summaries[review.ProductID] = s
+		}
+	}
+	return summaries
+}
+
+// withRating mengisi average_rating dan review_count di response produk.
+// Nilainya selalu dihitung dari review, bukan dari yang tersimpan.
+func withRating(product Product, summaries map[string]ratingSummary) Product {
+	s := summaries[product.ID]
+	product.AverageRating = s.average()
+	product.ReviewCount = s.count
+	return product
+}
+
+// sortByRating mengurutkan rating tertinggi dulu; rating sama diurutkan
+// dari jumlah review terbanyak, produk tanpa review di akhir
+func sortByRating(list []Product) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].AverageRating != list[j].AverageRating {
+			return list[i].AverageRating > list[j].AverageRating
+		}
+		return list[i].ReviewCount > list[j].ReviewCount
+	})
+}
+
+// Review handlers
+
+// getProductReviews menampilkan review approved, yang paling membantu
+// dulu. Admin dan staff bisa melihat status lain lewat ?status=.
+func getProductReviews(c *gin.Context) {
+	id := c.Param("id")
+	if _, found := findProduct(id); !found {
+		respondError(c, http.StatusNotFound, "Product not found", apierror.NotFound("Product", id))
+		return
+	}
+
+	var query ReviewQuery
+	if !bindReviewQuery(c, &query) {
+		return
+	}
+	if query.Status == "" || !isStaff(c) {
+		query.Status = ReviewApproved
+	}
+
+	filtered := []Review{}
+	for _, review := range reviews {
+		if review.ProductID == id && review.Status == query.Status {
+			filtered = append(filtered, review)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		if filtered[i].HelpfulVotes != filtered[j].HelpfulVotes {
+			return filtered[i].HelpfulVotes > filtered[j].HelpfulVotes
+		}
+		return filtered[i].CreatedAt.After(filtered[j].CreatedAt)
+	})
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Reviews retrieved successfully",
+		Data:    filtered,
+		Error:   nil,
+	})
+}
+
+// getReviews adalah antrian moderasi untuk admin dan staff
+func getReviews(c *gin.Context) {
+	var query ReviewQuery
+	if !bindReviewQuery(c, &query) {
+		return
+	}
+
+	filtered := []Review{}
+	for _, review := range reviews {
+		if query.Status == "" || review.Status == query.Status {
+			filtered = append(filtered, review)
+		}
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Reviews retrieved successfully",
+		Data:    filtered,
+		Error:   nil,
+	})
+}
+
+func createReview(c *gin.Context) {
+	productID := c.Param("id")
+
+	var newReview Review
+	if err := c.ShouldBindJSON(&newReview); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	// Validasi
+	if fields := validate.Struct(&newReview); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	// Produk, pembelian dan review ganda dicek di dalam commit supaya dua
+	// request tidak bisa menyimpan dua review untuk produk yang sama
+	principal, _ := auth.FromContext(c)
+	newReview.ProductID = productID
+	newReview.Status = ReviewPending
+	newReview.HelpfulVotes = 0
+	newReview.CreatedAt = time.Now().UTC()
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if _, found := findProduct(productID); !found {
+			return nil, nil, reject(http.StatusNotFound, "Product not found", apierror.NotFound("Product", productID))
+		}
+
+		// Hanya customer yang sudah membeli produk ini
+		customer, found := customerForUser(principal.Subject)
+		if !found || !hasPurchased(customer.ID, productID) {
+			return nil, nil, reject(http.StatusForbidden, "Forbidden", apierror.Newf(apierror.CodeForbidden, "Only customers with a completed transaction for product %s can review it", productID))
+		}
+		for _, review := range reviews {
+			if review.ProductID == productID && review.CustomerID == customer.ID {
+				return nil, nil, reject(http.StatusConflict, "Review already exists", apierror.Newf(apierror.CodeConflict, "Customer %s already reviewed product %s in review %s", customer.ID, productID, review.ID))
+			}
+		}
+
+		newReview.CustomerID = customer.ID
+		newReview.ID = generateID()
+		return func() { reviews = append(reviews, newReview) }, []events.Message{{Type: EventReviewCreated, Data: newReview}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Review created", "review_id", newReview.ID, "product_id", productID, "customer_id", newReview.CustomerID, "rating", newReview.Rating)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Review created successfully",
+		Data:    newReview,
+		Error:   nil,
+	})
+}
+
+func moderateReview(c *gin.Context) {
+	id := c.Param("id")
+
+	var req ReviewModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	var moderated Review
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		i := reviewIndex(id)
+		if i < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Review not found", apierror.NotFound("Review", id))
+		}
+		moderated = reviews[i]
+		moderated.Status = req.Status
+		return func() { reviews[i] = moderated }, []events.Message{{Type: EventReviewModerated, Data: moderated}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Review moderated", "review_id", id, "status", moderated.Status)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Review moderated successfully",
+		Data:    moderated,
+		Error:   nil,
+	})
+}
+
+// voteReview menandai review approved sebagai membantu. Penulis review
+// tidak bisa vote review-nya sendiri.
+func voteReview(c *gin.Context) {
+	id := c.Param("id")
+	principal, _ := auth.FromContext(c)
+
+	// Semua cek jalan di dalam commit supaya dua vote yang sama tidak
+	// tercatat dua kali dan review yang dihapus tidak dibaca lagi
+	vote := ReviewVote{ReviewID: id, UserID: principal.Subject}
+	var voted Review
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		i := reviewIndex(id)
+		if i < 0 || reviews[i].Status != ReviewApproved {
+			return nil, nil, reject(http.StatusNotFound, "Review not found", apierror.NotFound("Review", id))
+		}
+		if customer, found := customerForUser(principal.Subject); found && customer.ID == reviews[i].CustomerID {
+			return nil, nil, reject(http.StatusForbidden, "Forbidden", apierror.New(apierror.CodeForbidden, "Customers cannot vote on their own review"))
+		}
+		for _, existing := range reviewVotes {
+			if existing == vote {
+				return nil, nil, reject(http.StatusConflict, "Already voted", apierror.Newf(apierror.CodeConflict, "User %s already voted on review %s", vote.UserID, id))
+			}
+		}
+
+		voted = reviews[i]
+		voted.HelpfulVotes++
+		return func() { applyReviewVote(vote) }, []events.Message{{Type: EventReviewVoted, Data: vote}}, nil
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Review vote recorded successfully",
+		Data:    voted,
+		Error:   nil,
+	})
+}
+
+// applyReviewVote juga dipakai replay journal
+func applyReviewVote(vote ReviewVote) {
+	reviewVotes = append(reviewVotes, vote)
+	if i := reviewIndex(vote.ReviewID); i >= 0 {
+		reviews[i].HelpfulVotes++
+	}
+}
+
+// hasPurchased mengecek transaksi paid customer untuk produk. Transaksi
+// yang sudah di-refund tidak dihitung.
+func hasPurchased(customerID, productID string) bool {
+	for _, transaction := range transactions {
+		if transaction.CustomerID == customerID && transaction.ProductID == productID && transaction.Status == TransactionPaid {
+			return true
+		}
+	}
+	return false
+}
+
+func bindReviewQuery(c *gin.Context, query *ReviewQuery) bool {
+	if err := c.ShouldBindQuery(query); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid query", apierror.InvalidBody(err))
+		return false
+	}
+	if fields := validate.Struct(query); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return false
+	}
+	return true
+}
+
+func isStaff(c *gin.Context) bool {
+	principal, ok := auth.FromContext(c)
+	return ok && principal.HasRole(auth.RoleAdmin, auth.RoleStaff)
+}
+
+func reviewIndex(id string) int {
+	for i := range reviews {
+		if reviews[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"e-commerce/internal/auth"
+)
+
+func TestReviewsAggregateOnProducts(t *testing.T) {
+	api := newTestAPI(t,
+		auth.User{ID: "u2", Username: "staff", Role: auth.RoleStaff},
+		auth.User{ID: "u3", Username: "customer", Role: auth.RoleCustomer},
+		auth.User{ID: "u4", Username: "other", Role: auth.RoleCustomer},
+	)
+	do := api.do
+
+	// Customer demo "1" belum membeli Mouse
+	body := `{"rating":4,"text":"Enak dipakai"}`
+	if code := do("customer", http.MethodPost, "/products/2/reviews", body, nil); code != http.StatusForbidden {
+		t.Errorf("review before purchase status %d", code)
+	}
+	if code := do("customer", http.MethodPost, "/transactions", `{"product_id":"2","quantity":1}`, nil); code != http.StatusCreated {
+		t.Fatalf("transaction status %d", code)
+	}
+	if code := do("customer", http.MethodPost, "/products/2/reviews", `{"rating":6,"text":"x"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("rating 6 status %d", code)
+	}
+	var review Review
+	if code := do("customer", http.MethodPost, "/products/2/reviews", body, &review); code != http.StatusCreated || review.Status != ReviewPending {
+		t.Fatalf("review status %d: %+v", code, review)
+	}
+	if code := do("customer", http.MethodPost, "/products/2/reviews", body, nil); code != http.StatusConflict {
+		t.Errorf("second review status %d", code)
+	}
+
+	// Review pending belum tampil dan belum dihitung
+	var list []Review
+	if do("", http.MethodGet, "/products/2/reviews", "", &list); len(list) != 0 {
+		t.Errorf("pending review is public: %+v", list)
+	}
+	if code := do("customer", http.MethodPatch, "/reviews/"+review.ID+"/status", `{"status":"approved"}`, nil); code != http.StatusForbidden {
+		t.Errorf("customer moderation status %d", code)
+	}
+	if code := do("staff", http.MethodPatch, "/reviews/"+review.ID+"/status", `{"status":"approved"}`, nil); code != http.StatusOK {
+		t.Fatalf("moderation status %d", code)
+	}
+
+	if code := do("customer", http.MethodPost, "/reviews/"+review.ID+"/helpful", "", nil); code != http.StatusForbidden {
+		t.Errorf("own vote status %d", code)
+	}
+	if code := do("other", http.MethodPost, "/reviews/"+review.ID+"/helpful", "", nil); code != http.StatusOK {
+		t.Errorf("vote status %d", code)
+	}
+	if code := do("other", http.MethodPost, "/reviews/"+review.ID+"/helpful", "", nil); code != http.StatusConflict {
+		t.Errorf("second vote status %d", code)
+	}
+	if do("", http.MethodGet, "/products/2/reviews", "", &list); len(list) != 1 || list[0].HelpfulVotes != 1 {
+		t.Errorf("approved reviews %+v", list)
+	}
+
+	var sorted []Product
+	if code := do("", http.MethodGet, "/products?sort=rating", "", &sorted); code != http.StatusOK || len(sorted) != 2 {
+		t.Fatalf("sorted products status %d: %+v", code, sorted)
+	}
+	if sorted[0].ID != "2" || sorted[0].AverageRating != 4 || sorted[0].ReviewCount != 1 || sorted[1].ReviewCount != 0 {
+		t.Errorf("products by rating %+v", sorted)
+	}
+	if code := do("", http.MethodGet, "/products?sort=price", "", nil); code != http.StatusBadRequest {
+		t.Errorf("unknown sort status %d", code)
+	}
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"e-commerce/internal/config"
+	"e-commerce/internal/fixture"
+	"e-commerce/internal/shipping"
+)
+
+// Set demo dan empty, lihat folder fixtures
+//
+//go:embed fixtures/*.yaml
+var fixtureFiles embed.FS
+
+// Fixture adalah isi file seed. Field sama dengan body request API; ID
+// boleh kosong dan akan dibuat otomatis.
+type Fixture struct {
+	Sources       []Source        `json:"sources"`
+	Products      []Product       `json:"products"`
+	Transactions  []Transaction   `json:"transactions"`
+	ExchangeRates []ExchangeRate  `json:"exchange_rates"`
+	ShippingZones []shipping.Zone `json:"shipping_zones"`
+	Customers     []Customer      `json:"customers"`
+	CostPrices    []CostPrice     `json:"cost_prices"`
+	Warehouses    []Warehouse     `json:"warehouses"`
+	StockLevels   []StockLevel    `json:"stock_levels"`
+}
+
+// loadFixtures mengisi storage dari file fixture atau set bawaan
+func loadFixtures(cfg config.Fixtures) error {
+	f, err := readFixture(cfg)
+	if err != nil {
+		return err
+	}
+	if err := applyFixture(f); err != nil {
+		return err
+	}
+
+	source := cfg.File
+	if source == "" {
+		source = cfg.Set
+	}
+	slog.Info("Fixtures loaded", "fixture", source, "sources", len(sources), "products", len(products), "transactions", len(transactions), "exchange_rates", len(exchangeRates), "shipping_zones", len(shippingZones), "customers", len(customers), "cost_prices", len(costPrices), "warehouses", len(warehouses))
+	return nil
+}
+
+func readFixture(cfg config.Fixtures) (*Fixture, error) {
+	var f Fixture
+	switch {
+	case cfg.File != "":
+		if err := fixture.Load(cfg.File, &f); err != nil {
+			return nil, err
+		}
+	case cfg.Set == fixture.SetLoadTest:
+		return generateFixture(cfg.Size), nil
+	default:
+		if err := fixture.LoadFS(fixtureFiles, "fixtures/"+cfg.Set+".yaml", &f); err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+// applyFixture mengganti seluruh storage dengan isi fixture. Item
+// divalidasi berurutan dengan aturan yang sama seperti request API, jadi
+// source_exists dan currency melihat source dan kurs dari fixture yang
+// sudah dimuat. Kalau ada error, storage lama dikembalikan dan semua error
+// dilaporkan sekaligus.
+func applyFixture(f *Fixture) error {
+	savedProducts, savedSources, savedTransactions, savedRates, savedNextID := products, sources, transactions, exchangeRates, nextID
+	savedZones, savedShipments, savedCustomers := shippingZones, shipments, customers
+	savedReviews, savedVotes := reviews, reviewVotes
+	savedCosts, savedSales := costPrices, saleCosts
+	savedWarehouses, savedLevels, savedMovements := warehouses, stockLevels, stockMovements
+	products, sources, transactions, exchangeRates = []Product{}, []Source{}, []Transaction{}, []ExchangeRate{}
+	shippingZones, shipments, customers = []shipping.Zone{}, []Shipment{}, []Customer{}
+	reviews, reviewVotes = []Review{}, []ReviewVote{}
+	costPrices, saleCosts = []CostPrice{}, []SaleCost{}
+	warehouses, stockLevels, stockMovements = []Warehouse{}, []StockLevel{}, []StockMovement{}
+
+	var errs fixture.Errors
+
+	// ID yang ditulis di fixture dicatat dulu supaya ID otomatis tidak bentrok
+	sourceIDs, productIDs, transactionIDs, rateIDs, zoneIDs, customerIDs, costIDs := fixture.IDs{}, fixture.IDs{}, fixture.IDs{}, fixture.IDs{}, fixture.IDs{}, fixture.IDs{}, fixture.IDs{}
+	warehouseIDs := fixture.IDs{}
+	for i, s := range f.Sources {
+		if s.ID != "" && !sourceIDs.Add(s.ID) {
+			errs.Addf(fmt.Sprintf("sources[%d]", i), "duplicate id %q", s.ID)
+		}
+	}
+	for i, p := range f.Products {
+		if p.ID != "" && !productIDs.Add(p.ID) {
+			errs.Addf(fmt.Sprintf("products[%d]", i), "duplicate id %q", p.ID)
+		}
+	}
+	for i, t := range f.Transactions {
+		if t.ID != "" && !transactionIDs.Add(t.ID) {
+			errs.Addf(fmt.Sprintf("transactions[%d]", i), "duplicate id %q", t.ID)
+		}
+	}
+	for i, r := range f.ExchangeRates {
+		if r.ID != "" && !rateIDs.Add(r.ID) {
+			errs.Addf(fmt.Sprintf("exchange_rates[%d]", i), "duplicate id %q", r.ID)
+		}
+	}
+	for i, z := range f.ShippingZones {
+		if z.ID != "" && !zoneIDs.Add(z.ID) {
+			errs.Addf(fmt.Sprintf("shipping_zones[%d]", i), "duplicate id %q", z.ID)
+		}
+	}
+	for i, c := range f.Customers {
+		if c.ID != "" && !customerIDs.Add(c.ID) {
+			errs.Addf(fmt.Sprintf("customers[%d]", i), "duplicate id %q", c.ID)
+		}
+	}
+	for i, c := range f.CostPrices {
+		if c.ID != "" && !costIDs.Add(c.ID) {
+			errs.Addf(fmt.Sprintf("cost_prices[%d]", i), "duplicate id %q", c.ID)
+		}
+	}
+	for i, w := range f.Warehouses {
+		if w.ID != "" && !warehouseIDs.Add(w.ID) {
+			errs.Addf(fmt.Sprintf("warehouses[%d]", i), "duplicate id %q", w.ID)
+		}
+	}
+	nextID = fixture.NextID(sourceIDs, productIDs, transactionIDs, rateIDs, zoneIDs, customerIDs, costIDs, warehouseIDs)
+
+	// Kurs dimuat dulu karena currency produk harus punya kurs berlaku
+	now := time.Now().UTC()
+	for i, rate := range f.ExchangeRates {
+		rate.Currency = strings.ToUpper(rate.Currency)
+		if fields := validate.Struct(&rate); len(fields) > 0 {
+			errs.Add(fmt.Sprintf("exchange_rates[%d]", i), fields...)
+			continue
+		}
+		if rate.EffectiveFrom.IsZero() {
+			rate.EffectiveFrom = now
+		}
+		if rate.ID == "" {
+			rate.ID = generateID()
+		}
+		exchangeRates = append(exchangeRates, rate)
+	}
+
+	for i, zone := range f.ShippingZones {
+		for j := range zone.Countries {
+			zone.Countries[j] = strings.ToUpper(zone.Countries[j])
+		}
+		if fields := validate.Struct(&zone); len(fields) > 0 {
+			errs.Add(fmt.Sprintf("shipping_zones[%d]", i), fields...)
+			continue
+		}
+		zone.SortRates()
+		if zone.ID == "" {
+			zone.ID = generateID()
+		}
+		shippingZones = append(shippingZones, zone)
+	}
+
+	for i, source := range f.Sources {
+		if fields := validate.Struct(&source); len(fields) > 0 {
+			errs.Add(fmt.Sprintf("sources[%d]", i), fields...)
+			continue
+		}
+		if source.ID == "" {
+			source.ID = generateID()
+		}
+		sources = append(sources, source)
+	}
+
+	for i, product := range f.Products {
+		normalizeCurrency(&product.Currency)
+		if fields := validate.Struct(&product); len(fields) > 0 {
+			errs.Add(fmt.Sprintf("products[%d]", i), fields...)
+			continue
+		}
+		if product.ID == "" {
+			product.ID = generateID()
+		}
+		products = append(products, product)
+	}
+
+	// Harga beli dimuat sebelum transaksi supaya margin transaksi fixture
+	// bisa dihitung
+	for i, cost := range f.CostPrices {
+		path := fmt.Sprintf("cost_prices[%d]", i)
+		normalizeCurrency(&cost.Currency)
+		if fields := validate.Struct(&cost); len(fields) > 0 {
+			errs.Add(path, fields...)
+			continue
+		}
+		if _, ok := findProduct(cost.ProductID); !ok {
+			errs.Addf(path+".product_id", "product %q not found", cost.ProductID)
+			continue
+		}
+		if cost.EffectiveFrom.IsZero() {
+			cost.EffectiveFrom = now
+		}
+		if cost.ID == "" {
+			cost.ID = generateID()
+		}
+		costPrices = append(costPrices, cost)
+	}
+
+	// Seperti di API, gudang pertama menjadi gudang utama. Stock gudang
+	// utama adalah sisa stock produk, jadi stock_levels hanya untuk gudang
+	// lain dan jumlahnya tidak boleh melebihi stock produk.
+	for i, warehouse := range f.Warehouses {
+		warehouse.Country = strings.ToUpper(warehouse.Country)
+		if fields := validate.Struct(&warehouse); len(fields) > 0 {
+			errs.Add(fmt.Sprintf("warehouses[%d]", i), fields...)
+			continue
+		}
+		if warehouse.ID == "" {
+			warehouse.ID = generateID()
+		}
+		warehouse.Default = len(warehouses) == 0
+		warehouses = append(warehouses, warehouse)
+	}
+	for i, level := range f.StockLevels {
+		path := fmt.Sprintf("stock_levels[%d]", i)
+		switch {
+		case warehouseIndex(level.WarehouseID) < 0:
+			errs.Addf(path+".warehouse_id", "warehouse %q not found", level.WarehouseID)
+			continue
+		case level.WarehouseID == defaultWarehouseID():
+			errs.Addf(path+".warehouse_id", "stock of default warehouse %q is the product stock not held in other warehouses", level.WarehouseID)
+			continue
+		case stockLevelIndex(level.WarehouseID, level.ProductID) >= 0:
+			errs.Addf(path, "duplicate stock level for product %q", level.ProductID)
+			continue
+		case level.Quantity < 0:
+			errs.Addf(path+".quantity", "quantity must be 0 or greater")
+			continue
+		}
+		product, ok := findProduct(level.ProductID)
+		if !ok {
+			errs.Addf(path+".product_id", "product %q not found", level.ProductID)
+			continue
+		}
+		if located := locatedStock(product.ID) + level.Quantity; located > product.Stock {
+			errs.Addf(path+".quantity", "%d units in warehouses exceed stock %d of product %q", located, product.Stock, product.ID)
+			continue
+		}
+		stockLevels = append(stockLevels, level)
+	}
+
+	// Customer dimuat sebelum transaksi karena customer_id dicek
+	emails := map[string]bool{}
+	for i, customer := range f.Customers {
+		path := fmt.Sprintf("customers[%d]", i)
+		for j := range customer.Addresses {
+			normalizeAddress(&customer.Addresses[j])
+		}
+		if fields := validate.Struct(&customer); len(fields) > 0 {
+			errs.Add(path, fields...)
+			continue
+		}
+		email := strings.ToLower(customer.Email)
+		if emails[email] {
+			errs.Addf(path+".email", "duplicate email %q", customer.Email)
+			continue
+		}
+		emails[email] = true
+		if customer.Addresses == nil {
+			customer.Addresses = []shipping.Address{}
+		}
+		if customer.CreatedAt.IsZero() {
+			customer.CreatedAt = now
+		}
+		if customer.ID == "" {
+			customer.ID = generateID()
+		}
+		customers = append(customers, customer)
+	}
+
+	// Transaksi adalah riwayat; stok di fixture sudah stok akhir
+	for i, transaction := range f.Transactions {
+		path := fmt.Sprintf("transactions[%d]", i)
+		transaction.Currency = strings.ToUpper(transaction.Currency)
+		normalizeAddress(transaction.ShippingAddress)
+		if fields := validate.Struct(&transaction); len(fields) > 0 {
+			errs.Add(path, fields...)
+			continue
+		}
+		product, ok := findProduct(transaction.ProductID)
+		if !ok {
+			errs.Addf(path+".product_id", "product %q not found", transaction.ProductID)
+			continue
+		}
+		if transaction.ID == "" {
+			transaction.ID = generateID()
+		}
+		if transaction.Currency == "" {
+			transaction.Currency = product.Currency
+		}
+		total, rate, _ := convert(product.Price*float64(transaction.Quantity), product.Currency, transaction.Currency, now)
+		if transaction.ExchangeRate == 0 {
+			transaction.ExchangeRate = rate
+		}
+		if transaction.Total == 0 {
+			transaction.Total = total
+		}
+		// Transaksi fixture tidak lewat payment provider
+		if transaction.Status == "" {
+			transaction.Status = TransactionPaid
+		}
+		transaction.PaymentToken, transaction.GuestToken = "", ""
+		transactions = append(transactions, transaction)
+		saleCosts = append(saleCosts, newSaleCost(transaction, product, now))
+	}
+
+	if err := errs.Err(); err != nil {
+		products, sources, transactions, exchangeRates, nextID = savedProducts, savedSources, savedTransactions, savedRates, savedNextID
+		shippingZones, shipments, customers = savedZones, savedShipments, savedCustomers
+		reviews, reviewVotes = savedReviews, savedVotes
+		costPrices, saleCosts = savedCosts, savedSales
+		warehouses, stockLevels, stockMovements = savedWarehouses, savedLevels, savedMovements
+		return err
+	}
+	return nil
+}
+
+// generateFixture membuat katalog load test dengan size produk, satu
+// source untuk setiap 100 produk. Seed tetap supaya katalog sama setiap run.
+func generateFixture(size int) *Fixture {
+	g := fixture.NewGenerator(1)
+	f := &Fixture{
+		Sources:  make([]Source, 0, size/100+1),
+		Products: make([]Product, 0, size),
+	}
+
+	for i := 1; i <= size/100+1; i++ {
+		f.Sources = append(f.Sources, Source{ID: fmt.Sprint(i), Name: g.Company()})
+	}
+	for i := 0; i < size; i++ {
+		name, description, price := g.Product()
+		f.Products = append(f.Products, Product{
+			Name:        name,
+			Description: description,
+			Price:       price,
+			Stock:       g.Stock(),
+			SourceID:    f.Sources[g.Intn(len(f.Sources))].ID,
+		})
+	}
+	return f
+}
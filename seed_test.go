@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"e-commerce/internal/config"
+	"e-commerce/internal/fixture"
+)
+
+func TestFixtureSets(t *testing.T) {
+	for _, set := range fixture.Sets {
+		t.Run(set, func(t *testing.T) {
+			if err := loadFixtures(config.Fixtures{Set: set, Size: 500}); err != nil {
+				t.Fatal(err)
+			}
+			switch set {
+			case fixture.SetDemo:
+				if len(sources) != 2 || len(products) != 2 || nextID != 3 {
+					t.Errorf("demo: %d sources, %d products, nextID %d", len(sources), len(products), nextID)
+				}
+			case fixture.SetEmpty:
+				if len(sources) != 0 || len(products) != 0 || nextID != 1 {
+					t.Errorf("empty: %d sources, %d products, nextID %d", len(sources), len(products), nextID)
+				}
+			case fixture.SetLoadTest:
+				if len(sources) != 6 || len(products) != 500 {
+					t.Errorf("load-test: %d sources, %d products", len(sources), len(products))
+				}
+				// Generator harus deterministik
+				first := products[0]
+				loadFixtures(config.Fixtures{Set: set, Size: 500})
+				if products[0] != first {
+					t.Errorf("generated catalog changed between runs: %+v != %+v", products[0], first)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidFixtureKeepsStorage(t *testing.T) {
+	if err := loadFixtures(config.Fixtures{Set: fixture.SetDemo}); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	content := `
+sources:
+  - id: "10"
+    name: Supplier C
+  - id: "10"
+    name: Supplier D
+products:
+  - name: Keyboard
+    price: 100000
+    stock: 5
+    source_id: "10"
+  - name: ""
+    price: 0
+    stock: 1
+    source_id: "99"
+transactions:
+  - product_id: "404"
+    quantity: 1
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := loadFixtures(config.Fixtures{File: path})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{
+		`sources[1]: duplicate id "10"`,
+		"products[1].name:",
+		"products[1].price:",
+		"products[1].source_id:",
+		`transactions[0].product_id: product "404" not found`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not contain %q:\n%v", want, err)
+		}
+	}
+
+	if len(products) != 2 || products[0].Name != "Laptop" || nextID != 3 {
+		t.Errorf("storage changed after failed load: %+v, nextID %d", products, nextID)
+	}
+}
+
+func TestFixtureFileAssignsIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shop.json")
+	content := `{
+		"sources": [{"id": "7", "name": "Supplier"}],
+		"products": [{"name": "Pen", "price": 5000, "stock": 3, "source_id": "7"}],
+		"transactions": [{"product_id": "8", "quantity": 2, "user_id": "u3"}]
+	}`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loadFixtures(config.Fixtures{File: path}); err != nil {
+		t.Fatal(err)
+	}
+	if products[0].ID != "8" || transactions[0].ID != "9" || transactions[0].Total != 10000 {
+		t.Errorf("products %+v, transactions %+v", products, transactions)
+	}
+	if id := generateID(); id != "10" {
+		t.Errorf("next generated ID = %s, want 10", id)
+	}
+
+	// Key yang tidak dikenal ditolak
+	os.WriteFile(path, []byte(`{"products": [{"title": "Pen"}]}`), 0o600)
+	if err := loadFixtures(config.Fixtures{File: path}); err == nil || !strings.Contains(err.Error(), `unknown field "title"`) {
+		t.Errorf("error = %v", err)
+	}
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/shipping"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Shipment dibuat otomatis untuk transaksi dengan alamat pengiriman.
+// Timeline dimulai dengan status pending dari server, diikuti checkpoint
+// dari kurir setelah paket diserahkan.
+type Shipment struct {
+	ID             string                `json:"id"`
+	TransactionID  string                `json:"transaction_id"`
+	Address        shipping.Address      `json:"address"`
+	WeightGrams    int                   `json:"weight_grams"`
+	Carrier        string                `json:"carrier,omitempty"`
+	TrackingNumber string                `json:"tracking_number,omitempty"`
+	Status         string                `json:"status"`
+	Timeline       []shipping.Checkpoint `json:"timeline"`
+}
+
+// ShippingQuoteRequest menghitung ongkos kirim sebelum checkout
+type ShippingQuoteRequest struct {
+	ProductID string           `json:"product_id" validate:"required"`
+	Quantity  int              `json:"quantity" validate:"gt=0"`
+	Currency  string           `json:"currency" validate:"omitempty,currency"`
+	Address   shipping.Address `json:"address"`
+}
+
+type ShippingQuote struct {
+	ZoneID      string  `json:"zone_id"`
+	WeightGrams int     `json:"weight_grams"`
+	Cost        float64 `json:"cost"`
+	Currency    string  `json:"currency"`
+}
+
+// Domain event pengiriman
+const (
+	EventShippingZoneCreated = "shipping_zone.created"
+	EventShippingZoneUpdated = "shipping_zone.updated"
+	EventShippingZoneDeleted = "shipping_zone.deleted"
+	EventShipmentCreated     = "shipment.created"
+	EventShipmentUpdated     = "shipment.updated"
+)
+
+// Batas waktu satu panggilan ke kurir
+const carrierTimeout = 10 * time.Second
+
+var (
+	shippingZones = []shipping.Zone{}
+	shipments     = []Shipment{}
+
+	// carrier diganti di main sesuai konfigurasi
+	carrier shipping.Carrier = shipping.NewFake(time.Minute)
+)
+
+func initializeShipping(cfg *config.Config) {
+	switch cfg.Shipping.Carrier {
+	case shipping.CarrierFake:
+		carrier = shipping.NewFake(time.Minute)
+	}
+}
+
+// normalizeAddress dipanggil sebelum validasi
+func normalizeAddress(address *shipping.Address) {
+	if address != nil {
+		address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	}
+}
+
+// quoteShipping menghitung ongkos kirim quantity unit product ke address
+// dalam currency dengan kurs saat ini. Kalau tidak ada zona atau tarif
+// yang cocok, hasilnya error SHIPPING_UNAVAILABLE.
+func quoteShipping(product Product, quantity int, address shipping.Address, currency string) (ShippingQuote, *apierror.Error) {
+	grams := shipping.ChargeableGrams(product.WeightGrams, product.Dimensions, quantity)
+	for _, zone := range shippingZones {
+		if !zone.Covers(address.Country) {
+			continue
+		}
+		cost, ok := zone.Cost(grams)
+		if !ok {
+			return ShippingQuote{}, apierror.Newf(apierror.CodeShippingUnavailable, "No %s rate for %d g", zone.Name, grams)
+		}
+		converted, _, ok := convert(cost, baseCurrency, currency, time.Now())
+		if !ok {
+			return ShippingQuote{}, apierror.Newf(apierror.CodeUnsupportedCurrency, "No exchange rate from %s to %s", baseCurrency, currency)
+		}
+		return ShippingQuote{ZoneID: zone.ID, WeightGrams: grams, Cost: converted, Currency: currencyOf(currency)}, nil
+	}
+	return ShippingQuote{}, apierror.Newf(apierror.CodeShippingUnavailable, "No shipping zone covers %s", address.Country)
+}
+
+// newShipment membuat shipment pending untuk transaksi yang sudah dibayar
+func newShipment(t Transaction, product Product) Shipment {
+	return Shipment{
+		ID:            generateID(),
+		TransactionID: t.ID,
+		Address:       *t.ShippingAddress,
+		WeightGrams:   shipping.ChargeableGrams(product.WeightGrams, product.Dimensions, t.Quantity),
+		Status:        shipping.StatusPending,
+		Timeline: []shipping.Checkpoint{{
+			Status:      shipping.StatusPending,
+			Description: "Waiting to be handed to the carrier",
+			At:          time.Now().UTC(),
+		}},
+	}
+}
+
+// Shipping zone handlers
+func getShippingZones(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Shipping zones retrieved successfully",
+		Data:    shippingZones,
+		Error:   nil,
+	})
+}
+
+// bindShippingZone membaca dan memvalidasi body zona. Negara yang sudah
+// ada di zona lain dicek di dalam commit lewat checkZoneOverlap.
+func bindShippingZone(c *gin.Context) (shipping.Zone, bool) {
+	var zone shipping.Zone
+	if err := c.ShouldBindJSON(&zone); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return zone, false
+	}
+	for i := range zone.Countries {
+		zone.Countries[i] = strings.ToUpper(strings.TrimSpace(zone.Countries[i]))
+	}
+	if fields := validate.Struct(&zone); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return zone, false
+	}
+	zone.SortRates()
+	return zone, true
+}
+
+// checkZoneOverlap dipanggil di dalam commit supaya dua zone yang dibuat
+// bersamaan tidak bisa mencakup negara yang sama
+func checkZoneOverlap(zone shipping.Zone, except string) error {
+	for _, other := range shippingZones {
+		if other.ID == except {
+			continue
+		}
+		for _, country := range zone.Countries {
+			if other.Covers(country) {
+				return reject(http.StatusConflict, "Country already in another zone", apierror.Newf(apierror.CodeConflict, "%s is already covered by zone %s", country, other.ID))
+			}
+		}
+	}
+	return nil
+}
+
+func createShippingZone(c *gin.Context) {
+	zone, ok := bindShippingZone(c)
+	if !ok {
+		return
+	}
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if err := checkZoneOverlap(zone, ""); err != nil {
+			return nil, nil, err
+		}
+		zone.ID = generateID()
+		return func() { shippingZones = append(shippingZones, zone) }, []events.Message{{Type: EventShippingZoneCreated, Data: zone}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Shipping zone created", "zone_id", zone.ID, "countries", zone.Countries)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Shipping zone created successfully",
+		Data:    zone,
+		Error:   nil,
+	})
+}
+
+func updateShippingZone(c *gin.Context) {
+	id := c.Param("id")
+	if zoneIndex(id) < 0 {
+		respondError(c, http.StatusNotFound, "Shipping zone not found", apierror.NotFound("Shipping zone", id))
+		return
+	}
+
+	zone, ok := bindShippingZone(c)
+	if !ok {
+		return
+	}
+	zone.ID = id
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		index := zoneIndex(id)
+		if index < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Shipping zone not found", apierror.NotFound("Shipping zone", id))
+		}
+		if err := checkZoneOverlap(zone, id); err != nil {
+			return nil, nil, err
+		}
+		return func() { shippingZones[index] = zone }, []events.Message{{Type: EventShippingZoneUpdated, Data: zone}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Shipping zone updated", "zone_id", zone.ID, "countries", zone.Countries)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Shipping zone updated successfully",
+		Data:    zone,
+		Error:   nil,
+	})
+}
+
+func deleteShippingZone(c *gin.Context) {
+	id := c.Param("id")
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		index := zoneIndex(id)
+		if index < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Shipping zone not found", apierror.NotFound("Shipping zone", id))
+		}
+		return func() { shippingZones = append(shippingZones[:index], shippingZones[index+1:]...) }, []events.Message{{Type: EventShippingZoneDeleted, Data: shippingZones[index]}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Shipping zone deleted", "zone_id", id)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Shipping zone deleted successfully",
+		Data:    nil,
+		Error:   nil,
+	})
+}
+
+func quoteShippingCost(c *gin.Context) {
+	var req ShippingQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+	req.Currency = strings.ToUpper(req.Currency)
+	normalizeAddress(&req.Address)
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	product, found := findProduct(req.ProductID)
+	if !found {
+		respondError(c, http.StatusNotFound, "Product not found", apierror.NotFound("Product", req.ProductID))
+		return
+	}
+	if req.Currency == "" {
+		req.Currency = currencyOf(product.Currency)
+	}
+	quote, apiErr := quoteShipping(product, req.Quantity, req.Address, req.Currency)
+	if apiErr != nil {
+		respondError(c, http.StatusBadRequest, "Shipping unavailable", apiErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Shipping quote calculated successfully",
+		Data:    quote,
+		Error:   nil,
+	})
+}
+
+// Shipment handlers
+func getShipments(c *gin.Context) {
+	principal, _ := auth.FromContext(c)
+	transactionID := c.Query("transaction_id")
+	status := c.Query("status")
+
+	visible := []Shipment{}
+	for _, shipment := range shipments {
+		if transactionID != "" && shipment.TransactionID != transactionID {
+			continue
+		}
+		if status != "" && shipment.Status != status {
+			continue
+		}
+		if canAccessShipment(principal, shipment) {
+			visible = append(visible, shipment)
+		}
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Shipments retrieved successfully",
+		Data:    visible,
+		Error:   nil,
+	})
+}
+
+func getShipment(c *gin.Context) {
+	shipment, ok := accessibleShipment(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Shipment retrieved successfully",
+		Data:    shipment,
+		Error:   nil,
+	})
+}
+
+// dispatchShipment menyerahkan paket ke kurir dan mencatat nomor resi
+func dispatchShipment(c *gin.Context) {
+	shipment, ok := accessibleShipment(c)
+	if !ok {
+		return
+	}
+	if shipment.Status != shipping.StatusPending || shipment.TrackingNumber != "" {
+		respondError(c, http.StatusConflict, "Shipment already dispatched", apierror.Newf(apierror.CodeConflict, "Shipment %s is %s", shipment.ID, shipment.Status))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), carrierTimeout)
+	defer cancel()
+	tracking, err := carrier.CreateShipment(ctx, shipping.Parcel{Reference: shipment.ID, Address: shipment.Address, WeightGrams: shipment.WeightGrams})
+	if err != nil {
+		respondCarrierError(c, shipment, err)
+		return
+	}
+
+	// Nomor resi langsung disimpan supaya tidak hilang kalau tracking
+	// gagal; timeline-nya bisa diambil lagi lewat refresh
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		i := shipmentIndex(shipment.ID)
+		if i < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Shipment not found", apierror.NotFound("Shipment", shipment.ID))
+		}
+		if shipments[i].TrackingNumber != "" {
+			return nil, nil, reject(http.StatusConflict, "Shipment already dispatched", apierror.Newf(apierror.CodeConflict, "Shipment %s already has tracking number %s", shipment.ID, shipments[i].TrackingNumber))
+		}
+		shipment = shipments[i]
+		shipment.Carrier = carrier.Name()
+		shipment.TrackingNumber = tracking
+		return func() { shipments[i] = shipment }, []events.Message{{Type: EventShipmentUpdated, Data: shipment}}, nil
+	}) {
+		return
+	}
+
+	updated, ok := trackShipment(ctx, c, shipment)
+	if !ok {
+		return
+	}
+	logging.From(c).Info("Shipment dispatched", "shipment_id", updated.ID, "carrier", updated.Carrier, "tracking_number", updated.TrackingNumber)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Shipment dispatched successfully",
+		Data:    updated,
+		Error:   nil,
+	})
+}
+
+// refreshShipment mengambil checkpoint terbaru dari kurir
+func refreshShipment(c *gin.Context) {
+	shipment, ok := accessibleShipment(c)
+	if !ok {
+		return
+	}
+	if shipment.TrackingNumber == "" {
+		respondError(c, http.StatusConflict, "Shipment not dispatched", apierror.Newf(apierror.CodeConflict, "Shipment %s has no tracking number yet", shipment.ID))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), carrierTimeout)
+	defer cancel()
+	updated, ok := trackShipment(ctx, c, shipment)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Shipment tracking refreshed successfully",
+		Data:    updated,
+		Error:   nil,
+	})
+}
+
+// trackShipment mengganti bagian timeline dari kurir dengan checkpoint
+// terbaru lalu menyimpannya. Event hanya dicatat kalau ada perubahan.
+func trackShipment(ctx context.Context, c *gin.Context, shipment Shipment) (Shipment, bool) {
+	checkpoints, err := carrier.Track(ctx, shipment.TrackingNumber)
+	if err != nil {
+		respondCarrierError(c, shipment, err)
+		return Shipment{}, false
+	}
+
+	current, _ := findShipment(shipment.ID)
+	if current.TrackingNumber == shipment.TrackingNumber && len(current.Timeline) == len(checkpoints)+1 {
+		return current, true
+	}
+	shipment.Timeline = append(shipment.Timeline[:1:1], checkpoints...)
+	shipment.Status = shipment.Timeline[len(shipment.Timeline)-1].Status
+
+	if !commit(c, func() {
+		for i := range shipments {
+			if shipments[i].ID == shipment.ID {
+				shipments[i] = shipment
+			}
+		}
+	}, events.Message{Type: EventShipmentUpdated, Data: shipment}) {
+		return Shipment{}, false
+	}
+	logging.From(c).Info("Shipment updated", "shipment_id", shipment.ID, "status", shipment.Status)
+	return shipment, true
+}
+
+func respondCarrierError(c *gin.Context, shipment Shipment, err error) {
+	logging.From(c).Warn("Carrier request failed", "shipment_id", shipment.ID, "carrier", carrier.Name(), "error", err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		respondError(c, http.StatusGatewayTimeout, "Carrier timed out", apierror.Newf(apierror.CodeCarrierFailed, "The carrier did not respond within %s", carrierTimeout))
+		return
+	}
+	respondError(c, http.StatusBadGateway, "Carrier request failed", apierror.New(apierror.CodeCarrierFailed, "The carrier could not process the shipment"))
+}
+
+// accessibleShipment mencari shipment dari parameter id. Customer hanya
+// melihat shipment dari transaksinya sendiri; selain itu dijawab 404.
+func accessibleShipment(c *gin.Context) (Shipment, bool) {
+	id := c.Param("id")
+	principal, _ := auth.FromContext(c)
+	shipment, found := findShipment(id)
+	if !found || !canAccessShipment(principal, shipment) {
+		respondError(c, http.StatusNotFound, "Shipment not found", apierror.NotFound("Shipment", id))
+		return Shipment{}, false
+	}
+	return shipment, true
+}
+
+func canAccessShipment(principal *auth.Principal, shipment Shipment) bool {
+	transaction, found := findTransaction(shipment.TransactionID)
+	return found && canAccessTransaction(principal, transaction)
+}
+
+func findShipment(id string) (Shipment, bool) {
+	if i := shipmentIndex(id); i >= 0 {
+		return shipments[i], true
+	}
+	return Shipment{}, false
+}
+
+func shipmentIndex(id string) int {
+	for i := range shipments {
+		if shipments[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func zoneIndex(id string) int {
+	for i := range shippingZones {
+		if shippingZones[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
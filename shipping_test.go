@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"e-commerce/internal/auth"
+	"e-commerce/internal/shipping"
+)
+
+func TestShippingCostAndShipment(t *testing.T) {
+	api := newTestAPI(t,
+		auth.User{ID: "u1", Username: "staff", Role: auth.RoleStaff},
+		auth.User{ID: "u3", Username: "customer", Role: auth.RoleCustomer},
+		auth.User{ID: "u4", Username: "other", Role: auth.RoleCustomer},
+	)
+	savedCarrier := carrier
+	carrier = shipping.NewFake(time.Hour)
+	t.Cleanup(func() { carrier = savedCarrier })
+	do := api.do
+
+	// u3 terhubung ke customer demo "1". Laptop 2500 g, 40x30x8 cm = 1600 g volumetrik; 2 unit 5000 g
+	address := `{"name":"Budi","line1":"Jl. Merdeka 1","city":"Bandung","postal_code":"40111","country":"id"}`
+	var quote ShippingQuote
+	if code := do("customer", http.MethodPost, "/shipping/quote", `{"product_id":"1","quantity":2,"address":`+address+`}`, &quote); code != http.StatusOK || quote.Cost != 40000 || quote.ZoneID != "1" {
+		t.Fatalf("quote status %d: %+v", code, quote)
+	}
+	if code := do("customer", http.MethodPost, "/shipping/quote", `{"product_id":"1","quantity":1,"address":{"name":"A","line1":"B","city":"C","postal_code":"1","country":"US"}}`, nil); code != http.StatusBadRequest {
+		t.Errorf("uncovered country status %d", code)
+	}
+
+	var transaction Transaction
+	if code := do("customer", http.MethodPost, "/transactions", `{"product_id":"1","quantity":2,"shipping_address":`+address+`}`, &transaction); code != http.StatusCreated {
+		t.Fatalf("transaction status %d", code)
+	}
+	if transaction.ShippingCost != 40000 || transaction.Total != 2*15000000+40000 {
+		t.Errorf("shipping cost %v, total %v", transaction.ShippingCost, transaction.Total)
+	}
+
+	var list []Shipment
+	do("customer", http.MethodGet, "/shipments?transaction_id="+transaction.ID, "", &list)
+	if len(list) != 1 || list[0].Status != shipping.StatusPending || list[0].WeightGrams != 5000 {
+		t.Fatalf("shipments %+v", list)
+	}
+	id := list[0].ID
+	if code := do("other", http.MethodGet, "/shipments/"+id, "", nil); code != http.StatusNotFound {
+		t.Errorf("other customer status %d", code)
+	}
+	if code := do("customer", http.MethodPost, "/shipments/"+id+"/dispatch", "", nil); code != http.StatusForbidden {
+		t.Errorf("customer dispatch status %d", code)
+	}
+
+	var shipment Shipment
+	if code := do("staff", http.MethodPost, "/shipments/"+id+"/dispatch", "", &shipment); code != http.StatusOK {
+		t.Fatalf("dispatch status %d", code)
+	}
+	if shipment.TrackingNumber == "" || shipment.Status != shipping.StatusShipped || len(shipment.Timeline) != 2 {
+		t.Errorf("dispatched shipment %+v", shipment)
+	}
+	if code := do("staff", http.MethodPost, "/shipments/"+id+"/dispatch", "", nil); code != http.StatusConflict {
+		t.Errorf("second dispatch status %d", code)
+	}
+	if code := do("customer", http.MethodPost, "/shipments/"+id+"/refresh", "", &shipment); code != http.StatusOK || shipment.Status != shipping.StatusShipped {
+		t.Errorf("refresh status %d: %+v", code, shipment)
+	}
+
+	// Kalau tracking gagal setelah paket diserahkan, nomor resi tetap
+	// tersimpan dan timeline diambil lewat refresh
+	if code := do("customer", http.MethodPost, "/transactions", `{"product_id":"1","quantity":1,"shipping_address":`+address+`}`, &transaction); code != http.StatusCreated {
+		t.Fatalf("transaction status %d", code)
+	}
+	do("customer", http.MethodGet, "/shipments?transaction_id="+transaction.ID, "", &list)
+	id = list[0].ID
+	carrier = trackingDown{carrier}
+	if code := do("staff", http.MethodPost, "/shipments/"+id+"/dispatch", "", nil); code != http.StatusBadGateway {
+		t.Errorf("dispatch with tracking down status %d", code)
+	}
+	if stored, _ := findShipment(id); stored.TrackingNumber == "" || stored.Carrier == "" {
+		t.Fatalf("tracking number lost: %+v", stored)
+	}
+	carrier = carrier.(trackingDown).Carrier
+	if code := do("staff", http.MethodPost, "/shipments/"+id+"/dispatch", "", nil); code != http.StatusConflict {
+		t.Errorf("dispatch after carrier accepted status %d", code)
+	}
+	if code := do("staff", http.MethodPost, "/shipments/"+id+"/refresh", "", &shipment); code != http.StatusOK || shipment.Status != shipping.StatusShipped {
+		t.Errorf("refresh after tracking failure status %d: %+v", code, shipment)
+	}
+}
+
+// trackingDown menerima paket tapi selalu gagal saat tracking
+type trackingDown struct{ shipping.Carrier }
+
+func (trackingDown) Track(context.Context, string) ([]shipping.Checkpoint, error) {
+	return nil, errors.New("tracking unavailable")
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/shipping"
+	"e-commerce/internal/snapshot"
+	"e-commerce/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Versi format State di file snapshot. Naikkan kalau struct berubah dan
+// daftarkan migrasi dari versi lama di snapshot.Options.Migrations.
+const snapshotVersion = 1
+
+// State adalah seluruh data yang disimpan di snapshot. API key disimpan
+// sebagai hash, tapi secret webhook disimpan apa adanya karena dibutuhkan
+// untuk menandatangani delivery, jadi file snapshot harus dijaga seperti
+// secret. JournalSeq hanya diisi backend journal: entry journal sampai Seq
+// ini sudah termasuk di snapshot.
+type State struct {
+	Sources           []Source               `json:"sources"`
+	Products          []Product              `json:"products"`
+	Transactions      []Transaction          `json:"transactions"`
+	ExchangeRates     []ExchangeRate         `json:"exchange_rates"`
+	ShippingZones     []shipping.Zone        `json:"shipping_zones"`
+	Shipments         []Shipment             `json:"shipments"`
+	Customers         []Customer             `json:"customers"`
+	Reviews           []Review               `json:"reviews"`
+	ReviewVotes       []ReviewVote           `json:"review_votes"`
+	CostPrices        []CostPrice            `json:"cost_prices"`
+	SaleCosts         []SaleCost             `json:"sale_costs"`
+	Warehouses        []Warehouse            `json:"warehouses"`
+	StockLevels       []StockLevel           `json:"stock_levels"`
+	StockMovements    []StockMovement        `json:"stock_movements"`
+	APIKeys           []auth.StoredAPIKey    `json:"api_keys"`
+	Webhooks          []webhook.Subscription `json:"webhooks"`
+	WebhookDeliveries []webhook.Delivery     `json:"webhook_deliveries"`
+	NextID            int                    `json:"next_id"`
+	JournalSeq        int64                  `json:"journal_seq,omitempty"`
+}
+
+// snapshots nil kalau storage backend memory
+var snapshots *snapshot.Store
+
+// openStorage memuat data saat start. Backend snapshot memuat snapshot
+// terakhir, backend journal me-replay journal (lihat journal.go); fixture
+// hanya dipakai kalau belum ada data tersimpan.
+func openStorage(cfg *config.Config) error {
+	if cfg.Storage.Backend == config.BackendJournal {
+		dir := cfg.Storage.Path
+		if dir == "" {
+			dir = filepath.Join("data", "e-commerce")
+		}
+		return openJournal(cfg, dir)
+	}
+
+	if cfg.Storage.Backend == config.BackendSnapshot {
+		path := cfg.Storage.Path
+		if path == "" {
+			path = filepath.Join("data", "e-commerce.json")
+		}
+		snapshots = snapshot.New(snapshot.Options{
+			Path:     path,
+			App:      "e-commerce",
+			Version:  snapshotVersion,
+			Interval: cfg.Storage.SnapshotInterval.Duration,
+			Capture:  captureState,
+		})
+
+		var state State
+		info, err := snapshots.Load(&state)
+		switch {
+		case err == nil:
+			restoreState(state)
+			slog.Info("Snapshot loaded", "path", path, "created_at", info.CreatedAt, "sources", len(sources), "products", len(products), "transactions", len(transactions))
+			return nil
+		case !errors.Is(err, snapshot.ErrNotFound):
+			return err
+		}
+		slog.Info("No snapshot found, starting from fixtures", "path", path)
+	}
+
+	// Isi data awal dari fixture, lihat seed.go
+	if cfg.Seed {
+		return loadFixtures(cfg.Fixtures)
+	}
+	return nil
+}
+
+// captureState menyalin state di antara commit supaya snapshot konsisten
+func captureState() interface{} {
+	var state State
+	bus.View(func() {
+		state = State{
+			Sources:        append([]Source{}, sources...),
+			Products:       append([]Product{}, products...),
+			Transactions:   append([]Transaction{}, transactions...),
+			ExchangeRates:  append([]ExchangeRate{}, exchangeRates...),
+			ShippingZones:  append([]shipping.Zone{}, shippingZones...),
+			Shipments:      append([]Shipment{}, shipments...),
+			Customers:      append([]Customer{}, customers...),
+			Reviews:        append([]Review{}, reviews...),
+			ReviewVotes:    append([]ReviewVote{}, reviewVotes...),
+			CostPrices:     append([]CostPrice{}, costPrices...),
+			SaleCosts:      append([]SaleCost{}, saleCosts...),
+			Warehouses:     append([]Warehouse{}, warehouses...),
+			StockLevels:    append([]StockLevel{}, stockLevels...),
+			StockMovements: append([]StockMovement{}, stockMovements...),
+			NextID:         nextID,
+		}
+		state.APIKeys = apiKeys.Export()
+		state.Webhooks, state.WebhookDeliveries = webhooks.Export()
+		if eventLog != nil {
+			state.JournalSeq = eventLog.LastSeq()
+		}
+	})
+	return state
+}
+
+func restoreState(state State) {
+	sources = append([]Source{}, state.Sources...)
+	products = append([]Product{}, state.Products...)
+	transactions = append([]Transaction{}, state.Transactions...)
+	exchangeRates = append([]ExchangeRate{}, state.ExchangeRates...)
+	shippingZones = append([]shipping.Zone{}, state.ShippingZones...)
+	shipments = append([]Shipment{}, state.Shipments...)
+	customers = append([]Customer{}, state.Customers...)
+	reviews = append([]Review{}, state.Reviews...)
+	reviewVotes = append([]ReviewVote{}, state.ReviewVotes...)
+	costPrices = append([]CostPrice{}, state.CostPrices...)
+	saleCosts = append([]SaleCost{}, state.SaleCosts...)
+	warehouses = append([]Warehouse{}, state.Warehouses...)
+	stockLevels = append([]StockLevel{}, state.StockLevels...)
+	stockMovements = append([]StockMovement{}, state.StockMovements...)
+	apiKeys.Restore(state.APIKeys)
+	webhooks.Restore(state.Webhooks, state.WebhookDeliveries)
+	nextID = state.NextID
+	legacyCurrency(products, transactions)
+}
+
+// Snapshot handlers
+func createSnapshot(c *gin.Context) {
+	if snapshots == nil {
+		respondSnapshotsDisabled(c)
+		return
+	}
+
+	// Di backend journal, snapshot manual sekaligus compaction
+	var info snapshot.Info
+	var err error
+	if eventLog != nil {
+		info, err = compactJournal()
+	} else {
+		info, err = snapshots.Save()
+	}
+	if err != nil {
+		logging.From(c).Error("Snapshot failed", "error", err)
+		respondError(c, http.StatusInternalServerError, "Could not write snapshot", apierror.New(apierror.CodeInternal, err.Error()))
+		return
+	}
+	logging.From(c).Info("Snapshot written", "path", snapshots.Path(), "size", info.Size)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Snapshot created successfully",
+		Data:    info,
+		Error:   nil,
+	})
+}
+
+func downloadSnapshot(c *gin.Context) {
+	if snapshots == nil {
+		respondSnapshotsDisabled(c)
+		return
+	}
+
+	f, info, err := snapshots.Open()
+	if errors.Is(err, snapshot.ErrNotFound) {
+		respondError(c, http.StatusNotFound, "Snapshot not found", apierror.New(apierror.CodeNotFound, "No snapshot has been written yet"))
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Could not read snapshot", apierror.New(apierror.CodeInternal, err.Error()))
+		return
+	}
+	defer f.Close()
+
+	filename := fmt.Sprintf("e-commerce-%s.json", info.CreatedAt.Format("20060102T150405Z"))
+	c.DataFromReader(http.StatusOK, info.Size, "application/json", f, map[string]string{
+		"Content-Disposition": `attachment; filename="` + filename + `"`,
+	})
+}
+
+func respondSnapshotsDisabled(c *gin.Context) {
+	respondError(c, http.StatusConflict, "Snapshots are disabled", apierror.New(apierror.CodeConflict, "Set storage.backend to snapshot or journal to enable snapshots"))
+}
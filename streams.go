@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/events"
+	"e-commerce/internal/stream"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Query filter untuk endpoint SSE
+type StreamQuery struct {
+	ProductID string `form:"product_id"`
+	SourceID  string `form:"source_id"`
+}
+
+// Stream SSE diisi dari domain event; ID event SSE sama dengan seq outbox
+var (
+	stockStream       = stream.NewBroker(stream.Options{})
+	transactionStream = stream.NewBroker(stream.Options{})
+)
+
+// publishStockChange diteruskan dari event product.stock_changed
+func publishStockChange(ctx context.Context, e events.Event) error {
+	var change StockChangedEvent
+	if err := e.Decode(&change); err != nil {
+		return err
+	}
+	stockStream.Publish(stream.Message{
+		ID:    e.Seq,
+		Event: e.Type,
+		Data:  e.Data,
+		Attrs: map[string]string{"product_id": change.ProductID, "source_id": change.SourceID},
+	})
+	return nil
+}
+
+// publishTransaction diteruskan dari event transaction.created. Source
+// diambil dari produk supaya stream bisa difilter per source.
+func publishTransaction(ctx context.Context, e events.Event) error {
+	var transaction Transaction
+	if err := e.Decode(&transaction); err != nil {
+		return err
+	}
+	product, _ := findProduct(transaction.ProductID)
+	transactionStream.Publish(stream.Message{
+		ID:    e.Seq,
+		Event: e.Type,
+		Data:  e.Data,
+		Attrs: map[string]string{
+			"product_id": transaction.ProductID,
+			"source_id":  product.SourceID,
+		},
+	})
+	return nil
+}
+
+func streamStock(c *gin.Context) {
+	query, lastID, ok := bindStreamQuery(c)
+	if !ok {
+		return
+	}
+
+	stockStream.Serve(c, lastID, stream.Match(map[string]string{
+		"product_id": query.ProductID,
+		"source_id":  query.SourceID,
+	}))
+}
+
+// Customer hanya menerima transaksi yang boleh dibacanya lewat GET
+func streamTransactions(c *gin.Context) {
+	query, lastID, ok := bindStreamQuery(c)
+	if !ok {
+		return
+	}
+
+	principal, _ := auth.FromContext(c)
+	transactionStream.Serve(c, lastID, transactionFilter(principal, query))
+}
+
+// transactionFilter memakai aturan akses yang sama dengan
+// canAccessTransaction, termasuk transaksi milik profil customer
+func transactionFilter(principal *auth.Principal, query StreamQuery) stream.Filter {
+	match := stream.Match(map[string]string{
+		"product_id": query.ProductID,
+		"source_id":  query.SourceID,
+	})
+	return func(m stream.Message) bool {
+		if !match(m) {
+			return false
+		}
+		var transaction Transaction
+		return json.Unmarshal(m.Data, &transaction) == nil && canAccessTransaction(principal, transaction)
+	}
+}
+
+func bindStreamQuery(c *gin.Context) (StreamQuery, int64, bool) {
+	var query StreamQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid query", apierror.InvalidBody(err))
+		return query, 0, false
+	}
+
+	lastID, err := stream.LastEventID(c)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(apierror.Field("last_event_id", "numeric", err.Error())))
+		return query, 0, false
+	}
+	return query, lastID, true
+}
+
+// closeStreams memutus semua client SSE saat shutdown dimulai
+func closeStreams() {
+	stockStream.Close()
+	transactionStream.Close()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Domain event yang dicatat di outbox
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+	EventPostCreated = "post.created"
+	EventPostDeleted = "post.deleted"
+	EventLikeCreated = "like.created"
+)
+
+// bus diganti di main kalau outbox disimpan di disk
+var bus = newEventBus(events.NewMemoryStore())
+
+func newEventBus(store events.Store) *events.Bus {
+	return events.NewBus(store, events.Options{})
+}
+
+// deleteUserCascade menghapus user beserta post-nya, like di post tersebut
+// dan like yang dibuat user dalam satu commit, supaya snapshot tidak
+// pernah berisi like yang yatim. Setiap post tetap dicatat sebagai
+// post.deleted.
+func deleteUserCascade(c *gin.Context, id string) bool {
+	return commitFunc(c, func() (func(), []events.Message, error) {
+		user, found := findUser(id)
+		if !found {
+			return nil, nil, reject(http.StatusNotFound, "User not found", apierror.NotFound("User", id))
+		}
+		messages := []events.Message{{Type: EventUserDeleted, Data: user}}
+		deleted := map[string]bool{}
+		for _, post := range posts {
+			if post.UserID == id {
+				deleted[post.ID] = true
+				messages = append(messages, events.Message{Type: EventPostDeleted, Data: post})
+			}
+		}
+		return func() {
+			users = filterUsers(func(u User) bool { return u.ID != id })
+			posts = filterPosts(func(p Post) bool { return !deleted[p.ID] })
+			likes = filterLikes(func(like Like) bool { return like.UserID != id && !deleted[like.PostID] })
+		}, messages, nil
+	})
+}
+
+// deletePostCascade menghapus post dan like-nya dalam satu commit
+func deletePostCascade(c *gin.Context, id string) bool {
+	return commitFunc(c, func() (func(), []events.Message, error) {
+		post, found := findPost(id)
+		if !found {
+			return nil, nil, reject(http.StatusNotFound, "Post not found", apierror.NotFound("Post", id))
+		}
+		return func() {
+			posts = filterPosts(func(p Post) bool { return p.ID != id })
+			likes = filterLikes(func(like Like) bool { return like.PostID != id })
+		}, []events.Message{{Type: EventPostDeleted, Data: post}}, nil
+	})
+}
+
+func filterUsers(keep func(User) bool) []User {
+	filtered := []User{}
+	for _, user := range users {
+		if keep(user) {
+			filtered = append(filtered, user)
+		}
+	}
+	return filtered
+}
+
+func filterPosts(keep func(Post) bool) []Post {
+	filtered := []Post{}
+	for _, post := range posts {
+		if keep(post) {
+			filtered = append(filtered, post)
+		}
+	}
+	return filtered
+}
+
+func filterLikes(keep func(Like) bool) []Like {
+	filtered := []Like{}
+	for _, like := range likes {
+		if keep(like) {
+			filtered = append(filtered, like)
+		}
+	}
+	return filtered
+}
+
+// rejection dikembalikan prepare di commitFunc untuk membatalkan commit
+// dan menjawab request dengan error
+type rejection struct {
+	status  int
+	message string
+	err     *apierror.Error
+}
+
+func (r *rejection) Error() string { return r.err.Message }
+
+func reject(status int, message string, err *apierror.Error) error {
+	return &rejection{status: status, message: message, err: err}
+}
+
+// commitFunc seperti commit, tapi prepare dijalankan di antara dua commit
+// sehingga data yang dicari berdasarkan ID tidak berubah sebelum apply.
+// Error dari reject dijawab apa adanya tanpa mengubah apa pun.
+func commitFunc(c *gin.Context, prepare func() (apply func(), messages []events.Message, err error)) bool {
+	_, err := bus.CommitFunc(c.Request.Context(), prepare)
+	if err == nil {
+		return true
+	}
+	var rejected *rejection
+	if errors.As(err, &rejected) {
+		respondError(c, rejected.status, rejected.message, rejected.err)
+		return false
+	}
+	logging.From(c).Error("Could not write event outbox", "error", err)
+	respondError(c, http.StatusInternalServerError, "Could not save changes", apierror.New(apierror.CodeInternal, "Could not save changes"))
+	return false
+}
+
+// commit mencatat messages ke outbox lalu menjalankan apply. Kalau outbox
+// gagal ditulis, state tidak berubah dan request dijawab 500.
+func commit(c *gin.Context, apply func(), messages ...events.Message) bool {
+	if _, err := bus.Commit(c.Request.Context(), apply, messages...); err != nil {
+		logging.From(c).Error("Could not write event outbox", "error", err)
+		respondError(c, http.StatusInternalServerError, "Could not save changes", apierror.New(apierror.CodeInternal, "Could not save changes"))
+		return false
+	}
+	return true
+}
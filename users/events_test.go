@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"e-commerce/internal/config"
+	"e-commerce/internal/events"
+	"e-commerce/internal/fixture"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeleteUserCascadesThroughEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := loadFixtures(config.Fixtures{Set: fixture.SetDemo}); err != nil {
+		t.Fatal(err)
+	}
+	likes = []Like{
+		{ID: "10", UserID: "2", PostID: "1"}, // like jane di post john
+		{ID: "11", UserID: "1", PostID: "2"}, // like john di post jane
+		{ID: "12", UserID: "2", PostID: "2"},
+	}
+
+	store := events.NewMemoryStore()
+	bus = newEventBus(store)
+	r := setupRouter(testConfig())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	if len(posts) != 1 || posts[0].ID != "2" {
+		t.Errorf("posts after delete = %+v", posts)
+	}
+	if len(likes) != 1 || likes[0].ID != "12" {
+		t.Errorf("likes after delete = %+v", likes)
+	}
+
+	recorded, _ := store.Read(0, 0)
+	if len(recorded) != 2 || recorded[0].Type != EventUserDeleted || recorded[1].Type != EventPostDeleted {
+		t.Errorf("outbox = %+v", recorded)
+	}
+}
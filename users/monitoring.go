@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"e-commerce/internal/health"
+	"e-commerce/internal/metrics"
+)
+
+// Gauge dihitung ulang dari storage setiap kali /metrics dibaca
+var (
+	registry = metrics.NewRegistry()
+	checker  = health.New()
+
+	// storageLoaded diisi setelah data awal selesai dimuat
+	storageLoaded atomic.Bool
+	// storageDirs adalah folder yang ditulis storage backend dan outbox,
+	// diisi sebelum storageLoaded
+	storageDirs []string
+)
+
+func init() {
+	registry.GaugeFunc("social_users", "Number of users.", func() float64 {
+		return float64(len(users))
+	})
+	registry.GaugeFunc("social_posts", "Number of posts.", func() float64 {
+		return float64(len(posts))
+	})
+	registry.GaugeFunc("social_likes", "Number of likes.", func() float64 {
+		return float64(len(likes))
+	})
+
+	checker.Add("storage", checkStorage)
+}
+
+// watchStorage mendaftarkan folder outbox dan snapshot untuk checkStorage.
+// Folder snapshot dibuat sekarang karena snapshot pertama bisa baru
+// ditulis beberapa saat lagi.
+func watchStorage(outboxDir string) error {
+	if outboxDir != "" {
+		storageDirs = append(storageDirs, filepath.Join(outboxDir, "social-media-api"))
+	}
+	if snapshots != nil {
+		dir := filepath.Dir(snapshots.Path())
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		storageDirs = append(storageDirs, dir)
+	}
+	return nil
+}
+
+// checkStorage gagal selama data awal belum dimuat atau kalau folder
+// snapshot, journal atau outbox tidak bisa ditulis lagi
+func checkStorage(ctx context.Context) error {
+	if !storageLoaded.Load() {
+		return errors.New("storage is not loaded yet")
+	}
+	for _, dir := range storageDirs {
+		if err := health.Writable(dir); err != nil {
+			return fmt.Errorf("storage is not writable: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/health"
+	"e-commerce/internal/openapi"
+	"e-commerce/internal/snapshot"
+)
+
+// buildOpenAPISpec mendeskripsikan semua route di setupRouter.
+// openapi_test.go gagal kalau ada route yang belum terdaftar di sini.
+func buildOpenAPISpec() *openapi.Spec {
+	spec := openapi.New(openapi.Info{
+		Title:       "Social Media API",
+		Version:     "1.0.0",
+		Description: "REST API untuk user, post dan like. Semua response memakai envelope {message, data, error}.",
+	}, apierror.Error{})
+
+	spec.SecurityScheme(openapi.BearerAuth, &openapi.SecurityScheme{
+		Type: "http", Scheme: "bearer",
+		Description: "Token admin dari environment variable ADMIN_TOKEN",
+	})
+
+	admin := []string{openapi.BearerAuth}
+	bad := http.StatusBadRequest
+	notFound := http.StatusNotFound
+	limited := http.StatusTooManyRequests
+
+	routes := []openapi.Route{
+		// Users
+		{Method: "POST", Path: "/users", Tag: "Users", Summary: "Buat user baru",
+			Request: User{}, Response: User{}, Status: http.StatusCreated, Errors: []int{bad, limited}},
+		{Method: "GET", Path: "/users", Tag: "Users", Summary: "Ambil semua user", Response: []User{}},
+		{Method: "GET", Path: "/users/:id", Tag: "Users", Summary: "Ambil user berdasarkan ID",
+			Response: User{}, Errors: []int{notFound}},
+		{Method: "PUT", Path: "/users/:id", Tag: "Users", Summary: "Update user",
+			Request: User{}, Response: User{}, Errors: []int{bad, notFound, limited}},
+		{Method: "DELETE", Path: "/users/:id", Tag: "Users", Summary: "Hapus user beserta post dan like-nya",
+			Errors: []int{notFound, limited}},
+
+		// Posts
+		{Method: "POST", Path: "/posts", Tag: "Posts", Summary: "Buat post baru",
+			Request: Post{}, Response: Post{}, Status: http.StatusCreated, Errors: []int{bad, limited}},
+		{Method: "GET", Path: "/posts", Tag: "Posts", Summary: "Ambil semua post", Response: []Post{}},
+		{Method: "GET", Path: "/posts/:id", Tag: "Posts", Summary: "Ambil post berdasarkan ID",
+			Response: Post{}, Errors: []int{notFound}},
+		{Method: "GET", Path: "/users/:id/posts", Tag: "Posts", Summary: "Ambil semua post milik user",
+			Response: []Post{}, Errors: []int{notFound}},
+		{Method: "DELETE", Path: "/posts/:id", Tag: "Posts", Summary: "Hapus post beserta like-nya",
+			Errors: []int{notFound, limited}},
+
+		// Likes
+		{Method: "POST", Path: "/likes", Tag: "Likes", Summary: "Like sebuah post",
+			Request: Like{}, Response: Like{}, Status: http.StatusCreated, Errors: []int{bad, limited}},
+		{Method: "GET", Path: "/posts/:id/likes", Tag: "Likes", Summary: "Ambil semua like pada post",
+			Response: []Like{}, Errors: []int{notFound}},
+		{Method: "GET", Path: "/users/:id/likes", Tag: "Likes", Summary: "Ambil semua like dari user",
+			Response: []Like{}, Errors: []int{notFound}},
+
+		// Monitoring
+		{Method: "GET", Path: "/healthz", Tag: "Monitoring", Summary: "Liveness probe", Response: health.Report{}},
+		{Method: "GET", Path: "/readyz", Tag: "Monitoring", Summary: "Readiness probe, mengecek storage",
+			Response: health.Report{}, Errors: []int{http.StatusServiceUnavailable}},
+		{Method: "GET", Path: "/metrics", Tag: "Monitoring", Summary: "Metrics dalam format teks Prometheus", Produces: "text/plain"},
+
+		// Snapshots
+		{Method: "POST", Path: "/admin/snapshots", Tag: "Admin", Summary: "Tulis snapshot sekarang",
+			Description: "Butuh ADMIN_TOKEN. Hanya tersedia dengan storage backend snapshot.",
+			Security:    admin, Response: snapshot.Info{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+		{Method: "GET", Path: "/admin/snapshots/latest", Tag: "Admin", Summary: "Download snapshot terakhir",
+			Description: "Butuh ADMIN_TOKEN. File JSON berversi yang sama dengan yang dimuat saat start.",
+			Security:    admin, Produces: "application/json", Errors: []int{notFound, http.StatusConflict}},
+
+		// Docs
+		{Method: "GET", Path: "/openapi.json", Tag: "Docs", Summary: "Dokumen OpenAPI ini", Produces: "application/json"},
+		{Method: "GET", Path: "/docs", Tag: "Docs", Summary: "UI dokumentasi interaktif", Produces: "text/html"},
+	}
+
+	for _, route := range routes {
+		spec.Add(route)
+	}
+	return spec
+}
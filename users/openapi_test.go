@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"e-commerce/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testConfig() *config.Config {
+	cfg := config.Defaults(defaultRateLimits)
+	return &cfg
+}
+
+func TestOpenAPISpecCoversAllRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(testConfig())
+	spec := buildOpenAPISpec()
+
+	if err := spec.CheckRoutes(r.Routes()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOpenAPIEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := setupRouter(testConfig())
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", w.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if doc.OpenAPI == "" || len(doc.Paths) == 0 {
+		t.Fatalf("spec has no openapi version or paths")
+	}
+	for _, name := range []string{"User", "Post", "Like", "Error"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s missing from components", name)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /docs: status %d", w.Code)
+	}
+}
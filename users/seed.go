@@ -0,0 +1,182 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"e-commerce/internal/config"
+	"e-commerce/internal/fixture"
+)
+
+// Set demo dan empty, lihat folder fixtures
+//
+//go:embed fixtures/*.yaml
+var fixtureFiles embed.FS
+
+// Fixture adalah isi file seed. Field sama dengan body request API; ID
+// boleh kosong dan akan dibuat otomatis.
+type Fixture struct {
+	Users []User `json:"users"`
+	Posts []Post `json:"posts"`
+	Likes []Like `json:"likes"`
+}
+
+// loadFixtures mengisi storage dari file fixture atau set bawaan
+func loadFixtures(cfg config.Fixtures) error {
+	f, err := readFixture(cfg)
+	if err != nil {
+		return err
+	}
+	if err := applyFixture(f); err != nil {
+		return err
+	}
+
+	source := cfg.File
+	if source == "" {
+		source = cfg.Set
+	}
+	slog.Info("Fixtures loaded", "fixture", source, "users", len(users), "posts", len(posts), "likes", len(likes))
+	return nil
+}
+
+func readFixture(cfg config.Fixtures) (*Fixture, error) {
+	var f Fixture
+	switch {
+	case cfg.File != "":
+		if err := fixture.Load(cfg.File, &f); err != nil {
+			return nil, err
+		}
+	case cfg.Set == fixture.SetLoadTest:
+		return generateFixture(cfg.Size), nil
+	default:
+		if err := fixture.LoadFS(fixtureFiles, "fixtures/"+cfg.Set+".yaml", &f); err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+// applyFixture mengganti seluruh storage dengan isi fixture. Item
+// divalidasi berurutan dengan aturan yang sama seperti request API, jadi
+// user_exists, post_exists dan username unik melihat data fixture yang
+// sudah dimuat. Kalau ada error, storage lama dikembalikan dan semua error
+// dilaporkan sekaligus.
+func applyFixture(f *Fixture) error {
+	savedUsers, savedPosts, savedLikes, savedNextID := users, posts, likes, nextID
+	users, posts, likes = []User{}, []Post{}, []Like{}
+
+	var errs fixture.Errors
+
+	// ID yang ditulis di fixture dicatat dulu supaya ID otomatis tidak bentrok
+	userIDs, postIDs, likeIDs := fixture.IDs{}, fixture.IDs{}, fixture.IDs{}
+	for i, u := range f.Users {
+		if u.ID != "" && !userIDs.Add(u.ID) {
+			errs.Addf(fmt.Sprintf("users[%d]", i), "duplicate id %q", u.ID)
+		}
+	}
+	for i, p := range f.Posts {
+		if p.ID != "" && !postIDs.Add(p.ID) {
+			errs.Addf(fmt.Sprintf("posts[%d]", i), "duplicate id %q", p.ID)
+		}
+	}
+	for i, l := range f.Likes {
+		if l.ID != "" && !likeIDs.Add(l.ID) {
+			errs.Addf(fmt.Sprintf("likes[%d]", i), "duplicate id %q", l.ID)
+		}
+	}
+	nextID = fixture.NextID(userIDs, postIDs, likeIDs)
+
+	for i, user := range f.Users {
+		if fields := validate.Struct(&user); len(fields) > 0 {
+			errs.Add(fmt.Sprintf("users[%d]", i), fields...)
+			continue
+		}
+		if user.ID == "" {
+			user.ID = generateID()
+		}
+		users = append(users, user)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	for i, post := range f.Posts {
+		path := fmt.Sprintf("posts[%d]", i)
+		if fields := validate.Struct(&post); len(fields) > 0 {
+			errs.Add(path, fields...)
+			continue
+		}
+		if post.Created == "" {
+			post.Created = now
+		} else if _, err := time.Parse(time.RFC3339, post.Created); err != nil {
+			errs.Addf(path+".created_at", "%q must be an RFC 3339 time", post.Created)
+			continue
+		}
+		if post.ID == "" {
+			post.ID = generateID()
+		}
+		posts = append(posts, post)
+	}
+
+	for i, like := range f.Likes {
+		if fields := validate.Struct(&like); len(fields) > 0 {
+			errs.Add(fmt.Sprintf("likes[%d]", i), fields...)
+			continue
+		}
+		if like.ID == "" {
+			like.ID = generateID()
+		}
+		likes = append(likes, like)
+	}
+
+	if err := errs.Err(); err != nil {
+		users, posts, likes, nextID = savedUsers, savedPosts, savedLikes, savedNextID
+		return err
+	}
+	return nil
+}
+
+// generateFixture membuat data load test dengan size post, satu user untuk
+// setiap 10 post dan size like. Seed tetap supaya data sama setiap run.
+func generateFixture(size int) *Fixture {
+	g := fixture.NewGenerator(1)
+	userCount := size/10 + 1
+	f := &Fixture{
+		Users: make([]User, 0, userCount),
+		Posts: make([]Post, 0, size),
+		Likes: make([]Like, 0, size),
+	}
+
+	for i := 1; i <= userCount; i++ {
+		username := g.Username(i)
+		f.Users = append(f.Users, User{
+			ID:       fmt.Sprint(i),
+			Username: username,
+			Email:    username + "@example.com",
+			Bio:      g.Sentence(2, 8),
+		})
+	}
+
+	// Post tersebar dalam 30 hari terakhir
+	start := time.Now().Add(-30 * 24 * time.Hour)
+	for i := 0; i < size; i++ {
+		f.Posts = append(f.Posts, Post{
+			ID:      fmt.Sprint(userCount + i + 1),
+			UserID:  f.Users[g.Intn(userCount)].ID,
+			Content: g.Sentence(3, 30),
+			Created: start.Add(time.Duration(g.Intn(30*24*60)) * time.Minute).Format(time.RFC3339),
+		})
+	}
+
+	// Satu user hanya boleh like satu post sekali
+	liked := map[[2]string]bool{}
+	for attempts := 0; len(f.Likes) < size && attempts < size*3; attempts++ {
+		key := [2]string{f.Users[g.Intn(userCount)].ID, f.Posts[g.Intn(size)].ID}
+		if liked[key] {
+			continue
+		}
+		liked[key] = true
+		f.Likes = append(f.Likes, Like{UserID: key[0], PostID: key[1]})
+	}
+	return f
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/config"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/snapshot"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Versi format State di file snapshot. Naikkan kalau struct berubah dan
+// daftarkan migrasi dari versi lama di snapshot.Options.Migrations.
+const snapshotVersion = 1
+
+// State adalah seluruh data yang disimpan di snapshot
+type State struct {
+	Users  []User `json:"users"`
+	Posts  []Post `json:"posts"`
+	Likes  []Like `json:"likes"`
+	NextID int    `json:"next_id"`
+}
+
+// snapshots nil kalau storage backend memory
+var snapshots *snapshot.Store
+
+// openStorage memuat data saat start. Backend snapshot memuat snapshot
+// terakhir; fixture hanya dipakai kalau snapshot belum ada. Backend journal
+// hanya ada di server e-commerce.
+func openStorage(cfg *config.Config) error {
+	if cfg.Storage.Backend == config.BackendJournal {
+		return fmt.Errorf("storage backend %q is not supported by social-media-api, use memory or snapshot", cfg.Storage.Backend)
+	}
+
+	if cfg.Storage.Backend == config.BackendSnapshot {
+		path := cfg.Storage.Path
+		if path == "" {
+			path = filepath.Join("data", "social-media-api.json")
+		}
+		snapshots = snapshot.New(snapshot.Options{
+			Path:     path,
+			App:      "social-media-api",
+			Version:  snapshotVersion,
+			Interval: cfg.Storage.SnapshotInterval.Duration,
+			Capture:  captureState,
+		})
+
+		var state State
+		info, err := snapshots.Load(&state)
+		switch {
+		case err == nil:
+			restoreState(state)
+			slog.Info("Snapshot loaded", "path", path, "created_at", info.CreatedAt, "users", len(users), "posts", len(posts), "likes", len(likes))
+			return nil
+		case !errors.Is(err, snapshot.ErrNotFound):
+			return err
+		}
+		slog.Info("No snapshot found, starting from fixtures", "path", path)
+	}
+
+	// Isi data awal dari fixture, lihat seed.go
+	if cfg.Seed {
+		return loadFixtures(cfg.Fixtures)
+	}
+	return nil
+}
+
+// captureState menyalin state di antara commit supaya snapshot konsisten
+func captureState() interface{} {
+	var state State
+	bus.View(func() {
+		state = State{
+			Users:  append([]User{}, users...),
+			Posts:  append([]Post{}, posts...),
+			Likes:  append([]Like{}, likes...),
+			NextID: nextID,
+		}
+	})
+	return state
+}
+
+func restoreState(state State) {
+	users = append([]User{}, state.Users...)
+	posts = append([]Post{}, state.Posts...)
+	likes = append([]Like{}, state.Likes...)
+	nextID = state.NextID
+}
+
+// Snapshot handlers
+func createSnapshot(c *gin.Context) {
+	if snapshots == nil {
+		respondSnapshotsDisabled(c)
+		return
+	}
+
+	info, err := snapshots.Save()
+	if err != nil {
+		logging.From(c).Error("Snapshot failed", "error", err)
+		respondError(c, http.StatusInternalServerError, "Could not write snapshot", apierror.New(apierror.CodeInternal, err.Error()))
+		return
+	}
+	logging.From(c).Info("Snapshot written", "path", snapshots.Path(), "size", info.Size)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Snapshot created successfully",
+		Data:    info,
+		Error:   nil,
+	})
+}
+
+func downloadSnapshot(c *gin.Context) {
+	if snapshots == nil {
+		respondSnapshotsDisabled(c)
+		return
+	}
+
+	f, info, err := snapshots.Open()
+	if errors.Is(err, snapshot.ErrNotFound) {
+		respondError(c, http.StatusNotFound, "Snapshot not found", apierror.New(apierror.CodeNotFound, "No snapshot has been written yet"))
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Could not read snapshot", apierror.New(apierror.CodeInternal, err.Error()))
+		return
+	}
+	defer f.Close()
+
+	filename := fmt.Sprintf("social-media-api-%s.json", info.CreatedAt.Format("20060102T150405Z"))
+	c.DataFromReader(http.StatusOK, info.Size, "application/json", f, map[string]string{
+		"Content-Disposition": `attachment; filename="` + filename + `"`,
+	})
+}
+
+func respondSnapshotsDisabled(c *gin.Context) {
+	respondError(c, http.StatusConflict, "Snapshots are disabled", apierror.New(apierror.CodeConflict, "Set storage.backend to snapshot to enable snapshots"))
+}
@@ -1,33 +1,50 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
 	"strconv"
 	"time"
 
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+	"e-commerce/internal/cors"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/openapi"
+	"e-commerce/internal/ratelimit"
+	"e-commerce/internal/requestid"
+	"e-commerce/internal/server"
+	"e-commerce/internal/validation"
+
 	"github.com/gin-gonic/gin"
 )
 
 // Structs sesuai requirement
+// Aturan validasi ditulis di tag `validate`, lihat newValidator
 type User struct {
 	ID       string `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Bio      string `json:"bio"`
+	Username string `json:"username" validate:"required,min=3,max=30,username,unique_username"`
+	Email    string `json:"email" validate:"required,email,max=100,unique_email"`
+	Bio      string `json:"bio" validate:"max=160"`
 }
 
 type Post struct {
 	ID      string `json:"id"`
-	UserID  string `json:"user_id"`
-	Content string `json:"content"`
+	UserID  string `json:"user_id" validate:"required,user_exists"`
+	Content string `json:"content" validate:"required,max=280"`
 	Created string `json:"created_at"`
 }
 
 type Like struct {
 	ID     string `json:"id"`
-	UserID string `json:"user_id"`
-	PostID string `json:"post_id"`
+	UserID string `json:"user_id" validate:"required,user_exists"`
+	PostID string `json:"post_id" validate:"required,post_exists"`
 }
 
 // Response format yang konsisten
@@ -37,6 +54,17 @@ type APIResponse struct {
 	Error   interface{} `json:"error"`
 }
 
+// Rate limit default per route group, bisa di-override lewat konfigurasi
+// (rate_limits, RATE_LIMITS atau --rate-limits)
+var defaultRateLimits = map[string]ratelimit.Rule{
+	"default": {Requests: 300, Period: time.Minute},
+	"writes":  {Requests: 60, Period: time.Minute},
+	"likes":   {Requests: 30, Period: time.Minute},
+}
+
+// Validator yang dipakai semua handler
+var validate = newValidator()
+
 // In-memory storage
 var (
 	users  = []User{}
@@ -46,53 +74,131 @@ var (
 )
 
 func main() {
-	// Initialize dengan data sample
-	initializeData()
+	cfg, err := config.Load("social-media-api", config.Defaults(defaultRateLimits), os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Println("Invalid configuration:", err)
+		os.Exit(2)
+	}
+	if cfg.PrintConfig {
+		cfg.Write(os.Stdout)
+		return
+	}
+
+	slog.SetDefault(logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format))
 
-	r := gin.Default()
+	// Outbox dibuka sebelum ada perubahan data supaya tidak ada event yang
+	// hilang
+	store, err := events.Open(cfg.Outbox.Dir, "social-media-api")
+	if err != nil {
+		slog.Error("Failed to open event outbox", "dir", cfg.Outbox.Dir, "error", err)
+		os.Exit(1)
+	}
+	bus = newEventBus(store)
+	if err := bus.Start(); err != nil {
+		slog.Error("Failed to start event consumers", "error", err)
+		os.Exit(1)
+	}
+
+	// Muat snapshot atau fixture, lihat snapshots.go
+	if err := openStorage(cfg); err != nil {
+		slog.Error("Failed to load storage", "backend", cfg.Storage.Backend, "error", err)
+		os.Exit(1)
+	}
+	if err := watchStorage(cfg.Outbox.Dir); err != nil {
+		slog.Error("Failed to prepare storage", "backend", cfg.Storage.Backend, "error", err)
+		os.Exit(1)
+	}
+	storageLoaded.Store(true)
 
-	// Middleware logger
-	r.Use(gin.Logger())
+	gin.SetMode(cfg.Mode)
+	srv := server.New(cfg, setupRouter(cfg))
+
+	// Hook berjalan terbalik: snapshot terakhir ditulis setelah request
+	// selesai, lalu consumer event berhenti
+	srv.OnShutdown("events", bus.Close)
+	if snapshots != nil {
+		snapshots.Start()
+		srv.OnShutdown("snapshot", snapshots.Close)
+	}
+
+	slog.Info("Social Media API Server starting", "addr", cfg.Addr, "mode", cfg.Mode, "storage", cfg.Storage.Backend, "tls", cfg.TLS.Enabled())
+	if err := srv.Run(); err != nil {
+		slog.Error("Server stopped", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Server stopped")
+}
+
+// setupRouter mendaftarkan middleware dan semua endpoint. Setiap route
+// baru juga harus ditambahkan ke openapi.go.
+func setupRouter(cfg *config.Config) *gin.Engine {
+	limits := ratelimit.NewGroups(cfg.RateLimits, ratelimit.ClientKey)
+
+	r := gin.New()
+	// Tanpa proxy yang dipercaya, gin memakai X-Forwarded-For dari siapa
+	// saja dan rate limit per IP bisa dihindari. Nilai sudah divalidasi
+	// saat konfigurasi dimuat.
+	r.SetTrustedProxies(cfg.Proxies.Trusted)
+
+	// Request ID dan access log JSON untuk setiap request
+	r.Use(requestid.Middleware())
+	r.Use(logging.Middleware(slog.Default()))
+	r.Use(logging.Recovery())
+
+	// Metrics mencatat semua request, termasuk yang ditolak middleware lain
+	r.Use(registry.Middleware())
+
+	// Probe dan metrics didaftarkan sebelum rate limit supaya tidak pernah
+	// ditolak
+	r.GET("/healthz", checker.Live)
+	r.GET("/readyz", checker.Ready)
+	r.GET("/metrics", registry.Handler())
+
+	// CORS dicek sebelum rate limit supaya preflight tidak dibatasi
+	r.Use(cors.Middleware(cfg.CORS.Origins))
+
+	// Rate limit per IP
+	r.Use(limits.Middleware("default"))
+	writes := limits.Middleware("writes")
 
 	// User endpoints
-	r.POST("/users", createUser)
+	r.POST("/users", writes, createUser)
 	r.GET("/users", getUsers)
 	r.GET("/users/:id", getUser)
-	r.PUT("/users/:id", updateUser)
-	r.DELETE("/users/:id", deleteUser)
+	r.PUT("/users/:id", writes, updateUser)
+	r.DELETE("/users/:id", writes, deleteUser)
 
 	// Post endpoints
-	r.POST("/posts", createPost)
+	r.POST("/posts", writes, createPost)
 	r.GET("/posts", getPosts)
 	r.GET("/posts/:id", getPost)
 	r.GET("/users/:id/posts", getUserPosts)
-	r.DELETE("/posts/:id", deletePost)
+	r.DELETE("/posts/:id", writes, deletePost)
 
 	// Like endpoints
-	r.POST("/likes", createLike)
+	r.POST("/likes", limits.Middleware("likes"), createLike)
 	r.GET("/posts/:id/likes", getPostLikes)
 	r.GET("/users/:id/likes", getUserLikes)
 
-	fmt.Println("Social Media API Server starting on :8080")
-	r.Run(":8080")
-}
+	// Snapshot storage, dilindungi ADMIN_TOKEN karena server ini tidak
+	// punya login
+	adminOnly := auth.RequireToken(os.Getenv("ADMIN_TOKEN"))
+	r.POST("/admin/snapshots", adminOnly, createSnapshot)
+	r.GET("/admin/snapshots/latest", adminOnly, downloadSnapshot)
 
-func initializeData() {
-	// Sample users
-	users = []User{
-		{ID: "1", Username: "john_doe", Email: "john@example.com", Bio: "Hello world!"},
-		{ID: "2", Username: "jane_smith", Email: "jane@example.com", Bio: "Love coding!"},
-	}
+	// Dokumentasi API
+	spec := buildOpenAPISpec()
+	r.GET("/openapi.json", spec.Handler())
+	r.GET("/docs", openapi.DocsHandler("Social Media API", "/openapi.json"))
 
-	// Sample posts
-	posts = []Post{
-		{ID: "1", UserID: "1", Content: "My first post!", Created: time.Now().Format(time.RFC3339)},
-		{ID: "2", UserID: "2", Content: "Learning Go is fun!", Created: time.Now().Format(time.RFC3339)},
-	}
-
-	nextID = 3
+	return r
 }
 
+// generateID hanya boleh dipanggil di dalam prepare commitFunc (atau saat
+// seed dan replay), supaya nextID tidak diubah bersamaan.
 func generateID() string {
 	id := strconv.Itoa(nextID)
 	nextID++
@@ -129,58 +235,116 @@ func hasUserLikedPost(userID, postID string) bool {
 	return false
 }
 
-// User handlers
-func createUser(c *gin.Context) {
-	var newUser User
-	if err := c.ShouldBindJSON(&newUser); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Invalid request body",
-			Data:    nil,
-			Error:   err.Error(),
-		})
-		return
+// Helper function untuk cek apakah user ada
+func userExists(id string) bool {
+	for _, user := range users {
+		if user.ID == id {
+			return true
+		}
 	}
+	return false
+}
 
-	// Validasi
-	if newUser.Username == "" {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Username is required",
-		})
-		return
+// Helper function untuk cek apakah post ada
+func postExists(id string) bool {
+	for _, post := range posts {
+		if post.ID == id {
+			return true
+		}
 	}
+	return false
+}
 
-	if newUser.Email == "" {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Email is required",
-		})
-		return
+func userIndex(id string) int {
+	for i := range users {
+		if users[i].ID == id {
+			return i
+		}
 	}
+	return -1
+}
 
-	// Cek uniqueness
-	if !isUsernameUnique(newUser.Username, "") {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Username already exists",
-		})
-		return
+func findUser(id string) (User, bool) {
+	if i := userIndex(id); i >= 0 {
+		return users[i], true
 	}
+	return User{}, false
+}
 
-	if !isEmailUnique(newUser.Email, "") {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Email already exists",
-		})
+func findPost(id string) (Post, bool) {
+	for _, post := range posts {
+		if post.ID == id {
+			return post, true
+		}
+	}
+	return Post{}, false
+}
+
+// Aturan custom yang dipakai di tag `validate` pada struct
+func newValidator() *validation.Validator {
+	v := validation.New()
+
+	v.RegisterPattern("username", `^[A-Za-z0-9_]+$`, "%s may only contain letters, numbers and underscores")
+
+	// Cek unik memakai ID struct supaya user tidak bentrok dengan dirinya sendiri saat update
+	v.RegisterRule("unique_username", "%s already exists", func(fl validation.FieldLevel) bool {
+		return isUsernameUnique(fl.Field().String(), fl.Parent().FieldByName("ID").String())
+	})
+	v.RegisterRule("unique_email", "%s already exists", func(fl validation.FieldLevel) bool {
+		return isEmailUnique(fl.Field().String(), fl.Parent().FieldByName("ID").String())
+	})
+
+	v.RegisterRule("user_exists", "%s not found", func(fl validation.FieldLevel) bool {
+		return userExists(fl.Field().String())
+	})
+	v.RegisterRule("post_exists", "%s not found", func(fl validation.FieldLevel) bool {
+		return postExists(fl.Field().String())
+	})
+
+	// Satu user hanya boleh like satu post sekali
+	v.RegisterMessage("already_liked", "User already liked this post")
+	v.RegisterStructRule(func(sl validation.StructLevel) {
+		like := sl.Current().Interface().(Like)
+		if hasUserLikedPost(like.UserID, like.PostID) {
+			sl.ReportError(like.PostID, "post_id", "PostID", "already_liked", "")
+		}
+	}, Like{})
+
+	return v
+}
+
+// Helper function untuk response error yang konsisten
+func respondError(c *gin.Context, status int, message string, err *apierror.Error) {
+	c.JSON(status, APIResponse{
+		Message: message,
+		Data:    nil,
+		Error:   apierror.ForRequest(c, err),
+	})
+}
+
+// User handlers
+func createUser(c *gin.Context) {
+	var newUser User
+	if err := c.ShouldBindJSON(&newUser); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
 		return
 	}
 
-	newUser.ID = generateID()
-	users = append(users, newUser)
+	// Validasi, termasuk cek uniqueness, jalan di dalam commit supaya dua
+	// request tidak bisa memakai username atau email yang sama. ID dari
+	// client dibuang dulu karena cek uniqueness mengecualikan user dengan ID
+	// yang sama.
+	newUser.ID = ""
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if fields := validate.Struct(&newUser); len(fields) > 0 {
+			return nil, nil, reject(http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		}
+		newUser.ID = generateID()
+		return func() { users = append(users, newUser) }, []events.Message{{Type: EventUserCreated, Data: newUser}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("User created", "user_id", newUser.ID, "username", newUser.Username)
 
 	c.JSON(http.StatusCreated, APIResponse{
 		Message: "User created successfully",
@@ -211,11 +375,7 @@ func getUser(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "User not found",
-		Data:    nil,
-		Error:   "User with ID " + id + " not found",
-	})
+	respondError(c, http.StatusNotFound, "User not found", apierror.NotFound("User", id))
 }
 
 func updateUser(c *gin.Context) {
@@ -223,112 +383,45 @@ func updateUser(c *gin.Context) {
 
 	var updatedUser User
 	if err := c.ShouldBindJSON(&updatedUser); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Invalid request body",
-			Data:    nil,
-			Error:   err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
 		return
 	}
 
-	for i, user := range users {
-		if user.ID == id {
-			// Validasi
-			if updatedUser.Username == "" {
-				c.JSON(http.StatusBadRequest, APIResponse{
-					Message: "Validation failed",
-					Data:    nil,
-					Error:   "Username is required",
-				})
-				return
-			}
-
-			if updatedUser.Email == "" {
-				c.JSON(http.StatusBadRequest, APIResponse{
-					Message: "Validation failed",
-					Data:    nil,
-					Error:   "Email is required",
-				})
-				return
-			}
-
-			// Cek uniqueness
-			if !isUsernameUnique(updatedUser.Username, id) {
-				c.JSON(http.StatusBadRequest, APIResponse{
-					Message: "Validation failed",
-					Data:    nil,
-					Error:   "Username already exists",
-				})
-				return
-			}
-
-			if !isEmailUnique(updatedUser.Email, id) {
-				c.JSON(http.StatusBadRequest, APIResponse{
-					Message: "Validation failed",
-					Data:    nil,
-					Error:   "Email already exists",
-				})
-				return
-			}
-
-			updatedUser.ID = id
-			users[i] = updatedUser
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		i := userIndex(id)
+		if i < 0 {
+			return nil, nil, reject(http.StatusNotFound, "User not found", apierror.NotFound("User", id))
+		}
 
-			c.JSON(http.StatusOK, APIResponse{
-				Message: "User updated successfully",
-				Data:    updatedUser,
-				Error:   nil,
-			})
-			return
+		// Validasi, termasuk cek uniqueness
+		updatedUser.ID = id
+		if fields := validate.Struct(&updatedUser); len(fields) > 0 {
+			return nil, nil, reject(http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
 		}
+		return func() { users[i] = updatedUser }, []events.Message{{Type: EventUserUpdated, Data: updatedUser}}, nil
+	}) {
+		return
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "User not found",
-		Data:    nil,
-		Error:   "User with ID " + id + " not found",
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "User updated successfully",
+		Data:    updatedUser,
+		Error:   nil,
 	})
 }
 
 func deleteUser(c *gin.Context) {
 	id := c.Param("id")
 
-	for i, user := range users {
-		if user.ID == id {
-			// Hapus user
-			users = append(users[:i], users[i+1:]...)
-
-			// Hapus semua post dari user ini
-			var filteredPosts []Post
-			for _, post := range posts {
-				if post.UserID != id {
-					filteredPosts = append(filteredPosts, post)
-				}
-			}
-			posts = filteredPosts
-
-			// Hapus semua like dari user ini
-			var filteredLikes []Like
-			for _, like := range likes {
-				if like.UserID != id {
-					filteredLikes = append(filteredLikes, like)
-				}
-			}
-			likes = filteredLikes
-
-			c.JSON(http.StatusOK, APIResponse{
-				Message: "User deleted successfully",
-				Data:    nil,
-				Error:   nil,
-			})
-			return
-		}
+	if !deleteUserCascade(c, id) {
+		return
 	}
+	logging.From(c).Info("User deleted", "user_id", id)
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "User not found",
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "User deleted successfully",
 		Data:    nil,
-		Error:   "User with ID " + id + " not found",
+		Error:   nil,
 	})
 }
 
@@ -336,46 +429,23 @@ func deleteUser(c *gin.Context) {
 func createPost(c *gin.Context) {
 	var newPost Post
 	if err := c.ShouldBindJSON(&newPost); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Invalid request body",
-			Data:    nil,
-			Error:   err.Error(),
-		})
-		return
-	}
-
-	// Validasi
-	if newPost.Content == "" {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Content is required",
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
 		return
 	}
 
-	// Cek apakah user ada
-	userExists := false
-	for _, user := range users {
-		if user.ID == newPost.UserID {
-			userExists = true
-			break
+	// Validasi, termasuk cek apakah user ada, jalan di dalam commit supaya
+	// user tidak bisa terhapus di antara cek dan simpan
+	newPost.Created = time.Now().Format(time.RFC3339)
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if fields := validate.Struct(&newPost); len(fields) > 0 {
+			return nil, nil, reject(http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
 		}
-	}
-
-	if !userExists {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "User ID not found",
-		})
+		newPost.ID = generateID()
+		return func() { posts = append(posts, newPost) }, []events.Message{{Type: EventPostCreated, Data: newPost}}, nil
+	}) {
 		return
 	}
 
-	newPost.ID = generateID()
-	newPost.Created = time.Now().Format(time.RFC3339)
-	posts = append(posts, newPost)
-
 	c.JSON(http.StatusCreated, APIResponse{
 		Message: "Post created successfully",
 		Data:    newPost,
@@ -405,31 +475,15 @@ func getPost(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "Post not found",
-		Data:    nil,
-		Error:   "Post with ID " + id + " not found",
-	})
+	respondError(c, http.StatusNotFound, "Post not found", apierror.NotFound("Post", id))
 }
 
 func getUserPosts(c *gin.Context) {
 	userID := c.Param("id")
 
 	// Cek apakah user ada
-	userExists := false
-	for _, user := range users {
-		if user.ID == userID {
-			userExists = true
-			break
-		}
-	}
-
-	if !userExists {
-		c.JSON(http.StatusNotFound, APIResponse{
-			Message: "User not found",
-			Data:    nil,
-			Error:   "User with ID " + userID + " not found",
-		})
+	if !userExists(userID) {
+		respondError(c, http.StatusNotFound, "User not found", apierror.NotFound("User", userID))
 		return
 	}
 
@@ -450,33 +504,14 @@ func getUserPosts(c *gin.Context) {
 func deletePost(c *gin.Context) {
 	id := c.Param("id")
 
-	for i, post := range posts {
-		if post.ID == id {
-			// Hapus post
-			posts = append(posts[:i], posts[i+1:]...)
-
-			// Hapus semua like untuk post ini
-			var filteredLikes []Like
-			for _, like := range likes {
-				if like.PostID != id {
-					filteredLikes = append(filteredLikes, like)
-				}
-			}
-			likes = filteredLikes
-
-			c.JSON(http.StatusOK, APIResponse{
-				Message: "Post deleted successfully",
-				Data:    nil,
-				Error:   nil,
-			})
-			return
-		}
+	if !deletePostCascade(c, id) {
+		return
 	}
 
-	c.JSON(http.StatusNotFound, APIResponse{
-		Message: "Post not found",
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Post deleted successfully",
 		Data:    nil,
-		Error:   "Post with ID " + id + " not found",
+		Error:   nil,
 	})
 }
 
@@ -484,63 +519,23 @@ func deletePost(c *gin.Context) {
 func createLike(c *gin.Context) {
 	var newLike Like
 	if err := c.ShouldBindJSON(&newLike); err != nil {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Invalid request body",
-			Data:    nil,
-			Error:   err.Error(),
-		})
-		return
-	}
-
-	// Cek apakah user ada
-	userExists := false
-	for _, user := range users {
-		if user.ID == newLike.UserID {
-			userExists = true
-			break
-		}
-	}
-
-	if !userExists {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "User ID not found",
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
 		return
 	}
 
-	// Cek apakah post ada
-	postExists := false
-	for _, post := range posts {
-		if post.ID == newLike.PostID {
-			postExists = true
-			break
+	// Validasi, termasuk cek apakah user dan post ada dan like ganda, jalan
+	// di dalam commit supaya dua request tidak bisa menyimpan like yang sama
+	newLike.ID = ""
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if fields := validate.Struct(&newLike); len(fields) > 0 {
+			return nil, nil, reject(http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
 		}
-	}
-
-	if !postExists {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "Post ID not found",
-		})
-		return
-	}
-
-	// Cek apakah user sudah like post ini
-	if hasUserLikedPost(newLike.UserID, newLike.PostID) {
-		c.JSON(http.StatusBadRequest, APIResponse{
-			Message: "Validation failed",
-			Data:    nil,
-			Error:   "User already liked this post",
-		})
+		newLike.ID = generateID()
+		return func() { likes = append(likes, newLike) }, []events.Message{{Type: EventLikeCreated, Data: newLike}}, nil
+	}) {
 		return
 	}
 
-	newLike.ID = generateID()
-	likes = append(likes, newLike)
-
 	c.JSON(http.StatusCreated, APIResponse{
 		Message: "Like created successfully",
 		Data:    newLike,
@@ -552,20 +547,8 @@ func getPostLikes(c *gin.Context) {
 	postID := c.Param("id")
 
 	// Cek apakah post ada
-	postExists := false
-	for _, post := range posts {
-		if post.ID == postID {
-			postExists = true
-			break
-		}
-	}
-
-	if !postExists {
-		c.JSON(http.StatusNotFound, APIResponse{
-			Message: "Post not found",
-			Data:    nil,
-			Error:   "Post with ID " + postID + " not found",
-		})
+	if !postExists(postID) {
+		respondError(c, http.StatusNotFound, "Post not found", apierror.NotFound("Post", postID))
 		return
 	}
 
@@ -587,20 +570,8 @@ func getUserLikes(c *gin.Context) {
 	userID := c.Param("id")
 
 	// Cek apakah user ada
-	userExists := false
-	for _, user := range users {
-		if user.ID == userID {
-			userExists = true
-			break
-		}
-	}
-
-	if !userExists {
-		c.JSON(http.StatusNotFound, APIResponse{
-			Message: "User not found",
-			Data:    nil,
-			Error:   "User with ID " + userID + " not found",
-		})
+	if !userExists(userID) {
+		respondError(c, http.StatusNotFound, "User not found", apierror.NotFound("User", userID))
 		return
 	}
 
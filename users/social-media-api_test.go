@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"e-commerce/internal/config"
+	"e-commerce/internal/events"
+	"e-commerce/internal/fixture"
+	"e-commerce/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateUserIgnoresClientID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := loadFixtures(config.Fixtures{Set: fixture.SetDemo}); err != nil {
+		t.Fatal(err)
+	}
+	bus = newEventBus(events.NewMemoryStore())
+	r := setupRouter(testConfig())
+
+	for _, body := range []string{
+		`{"id":"1","username":"john_doe","email":"new@example.com"}`,
+		`{"id":"1","username":"new_user","email":"john@example.com"}`,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, w.Code)
+		}
+	}
+	if len(users) != 2 {
+		t.Errorf("users = %+v", users)
+	}
+}
+
+func TestRateLimitIgnoresForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := loadFixtures(config.Fixtures{Set: fixture.SetDemo}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig()
+	cfg.RateLimits["default"] = ratelimit.Rule{Requests: 2, Period: time.Minute}
+	r := setupRouter(cfg)
+
+	// Setiap request memakai X-Forwarded-For berbeda, tapi tidak ada proxy
+	// yang dipercaya jadi semuanya masuk bucket IP koneksi yang sama
+	var codes []int
+	for i := 1; i <= 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		codes = append(codes, w.Code)
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("status codes = %v, want 200, 200, 429", codes)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"time"
+
+	"e-commerce/internal/auth"
+	"e-commerce/internal/validation"
+	"e-commerce/internal/webhook"
+)
+
+// Validator yang dipakai semua handler
+var validate = newValidator()
+
+// Aturan custom yang dipakai di tag `validate` pada struct
+func newValidator() *validation.Validator {
+	v := validation.New()
+
+	v.RegisterRule("source_exists", "%s not found", func(fl validation.FieldLevel) bool {
+		return sourceExists(fl.Field().String())
+	})
+
+	v.RegisterRule("customer_exists", "%s not found", func(fl validation.FieldLevel) bool {
+		_, found := findCustomer(fl.Field().String())
+		return found
+	})
+
+	v.RegisterRule("warehouse_exists", "%s not found", func(fl validation.FieldLevel) bool {
+		return warehouseIndex(fl.Field().String()) >= 0
+	})
+
+	v.RegisterRule("currency", "%s has no exchange rate in effect", func(fl validation.FieldLevel) bool {
+		return supportedCurrency(fl.Field().String())
+	})
+
+	v.RegisterRule("currency_code", "%s must be a three-letter currency code other than "+baseCurrency, func(fl validation.FieldLevel) bool {
+		code := fl.Field().String()
+		if len(code) != 3 || code == baseCurrency {
+			return false
+		}
+		for _, r := range code {
+			if r < 'A' || r > 'Z' {
+				return false
+			}
+		}
+		return true
+	})
+
+	v.RegisterPattern("country_code", `^[A-Z]{2}$`, "%s must be a two-letter ISO 3166 country code")
+
+	v.RegisterRule("scope", "%s contains an unknown scope", func(fl validation.FieldLevel) bool {
+		return auth.Scope(fl.Field().String()).Valid()
+	})
+
+	v.RegisterRule("duration", "%s must be zero or a positive duration such as 1h", func(fl validation.FieldLevel) bool {
+		value := fl.Field().String()
+		if value == "" {
+			return true
+		}
+		d, err := time.ParseDuration(value)
+		return err == nil && d >= 0
+	})
+
+	v.RegisterRule("webhook_event", "%s contains an unknown event", func(fl validation.FieldLevel) bool {
+		return webhook.ValidEvent(fl.Field().String())
+	})
+
+	return v
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/config"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/shipping"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Warehouse adalah lokasi stock. Gudang pertama yang dibuat menjadi gudang
+// utama (Default) dan tidak bisa diganti. Stock gudang utama tidak disimpan
+// sendiri: nilainya Product.Stock dikurangi stock di gudang lain, jadi
+// endpoint lama yang mengubah stock produk (update, PATCH stock, bulk)
+// selalu mengubah stock gudang utama dan Product.Stock tetap jumlah stock
+// semua gudang.
+type Warehouse struct {
+	ID      string `json:"id"`
+	Name    string `json:"name" validate:"required,max=200"`
+	City    string `json:"city" validate:"required,max=100"`
+	Country string `json:"country" validate:"required,country_code"`
+	Default bool   `json:"default"`
+}
+
+// StockLevel adalah stock satu produk di satu gudang. Yang disimpan hanya
+// gudang selain gudang utama.
+type StockLevel struct {
+	WarehouseID string `json:"warehouse_id"`
+	ProductID   string `json:"product_id"`
+	Quantity    int    `json:"quantity"`
+}
+
+// StockMovement mencatat perpindahan stock. From kosong berarti stock
+// masuk (adjustment naik) dan To kosong berarti stock keluar (transaksi
+// atau adjustment turun).
+type StockMovement struct {
+	ID              string    `json:"id"`
+	ProductID       string    `json:"product_id"`
+	FromWarehouseID string    `json:"from_warehouse_id,omitempty"`
+	ToWarehouseID   string    `json:"to_warehouse_id,omitempty"`
+	Quantity        int       `json:"quantity"`
+	Reason          string    `json:"reason"`
+	TransactionID   string    `json:"transaction_id,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type StockTransferRequest struct {
+	ProductID       string `json:"product_id" validate:"required"`
+	FromWarehouseID string `json:"from_warehouse_id" validate:"required,warehouse_exists"`
+	ToWarehouseID   string `json:"to_warehouse_id" validate:"required,warehouse_exists,nefield=FromWarehouseID"`
+	Quantity        int    `json:"quantity" validate:"gt=0"`
+}
+
+type WarehouseStockRequest struct {
+	Quantity *int `json:"quantity" validate:"required,gte=0"`
+}
+
+type StockMovementQuery struct {
+	ProductID   string `form:"product_id" json:"product_id"`
+	WarehouseID string `form:"warehouse_id" json:"warehouse_id"`
+}
+
+// Domain event gudang. stock.moved hanya mengubah StockLevel; perubahan
+// Product.Stock tetap lewat product.stock_changed.
+const (
+	EventWarehouseCreated = "warehouse.created"
+	EventWarehouseUpdated = "warehouse.updated"
+	EventWarehouseDeleted = "warehouse.deleted"
+	EventStockMoved       = "stock.moved"
+)
+
+var (
+	warehouses     = []Warehouse{}
+	stockLevels    = []StockLevel{}
+	stockMovements = []StockMovement{}
+
+	allocationRule = config.AllocationMostStock
+)
+
+func initializeWarehouses(cfg *config.Config) {
+	allocationRule = cfg.Warehouses.Allocation
+}
+
+// defaultWarehouseID kosong kalau belum ada gudang
+func defaultWarehouseID() string {
+	for _, warehouse := range warehouses {
+		if warehouse.Default {
+			return warehouse.ID
+		}
+	}
+	return ""
+}
+
+// stockIn mengembalikan stock produk di gudang. ID kosong atau gudang
+// utama berarti sisa stock yang tidak ada di gudang lain.
+func stockIn(product Product, warehouseID string) int {
+	if warehouseID == "" || warehouseID == defaultWarehouseID() {
+		return product.Stock - locatedStock(product.ID)
+	}
+	if i := stockLevelIndex(warehouseID, product.ID); i >= 0 {
+		return stockLevels[i].Quantity
+	}
+	return 0
+}
+
+// locatedStock adalah stock produk di semua gudang selain gudang utama.
+// Product.Stock tidak boleh lebih kecil dari ini.
+func locatedStock(productID string) int {
+	total := 0
+	for _, level := range stockLevels {
+		if level.ProductID == productID {
+			total += level.Quantity
+		}
+	}
+	return total
+}
+
+// checkStockFloor dipakai endpoint yang mengubah Product.Stock langsung.
+// Perubahan itu hanya mengenai gudang utama, jadi stock baru tidak boleh
+// lebih kecil dari stock di gudang lain.
+func checkStockFloor(productID string, stock int) *apierror.Error {
+	if located := locatedStock(productID); stock < located {
+		return apierror.Newf(apierror.CodeConflict, "Product %s has %d units in other warehouses; move or adjust them before lowering stock to %d", productID, located, stock)
+	}
+	return nil
+}
+
+// allocationCandidates mengurutkan gudang yang dicoba untuk transaksi.
+// Gudang yang dipilih client dicoba sendirian; selain itu semua gudang
+// diurutkan menurut allocationRule. Tanpa gudang hasilnya [""] dan stock
+// diambil dari Product.Stock seperti biasa.
+func allocationCandidates(product Product, requested string, address *shipping.Address) []string {
+	if len(warehouses) == 0 {
+		return []string{""}
+	}
+	if requested != "" {
+		return []string{requested}
+	}
+
+	ranked := append([]Warehouse{}, warehouses...)
+	stock := map[string]int{}
+	for _, warehouse := range ranked {
+		stock[warehouse.ID] = stockIn(product, warehouse.ID)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if allocationRule == config.AllocationNearest && address != nil {
+			if di, dj := distance(ranked[i], *address), distance(ranked[j], *address); di != dj {
+				return di < dj
+			}
+		}
+		return stock[ranked[i].ID] > stock[ranked[j].ID]
+	})
+
+	candidates := make([]string, len(ranked))
+	for i, warehouse := range ranked {
+		candidates[i] = warehouse.ID
+	}
+	return candidates
+}
+
+// distance adalah perkiraan jarak gudang ke alamat: 0 kota yang sama,
+// 1 negara yang sama, 2 negara lain
+func distance(warehouse Warehouse, address shipping.Address) int {
+	switch {
+	case warehouse.Country != address.Country:
+		return 2
+	case !strings.EqualFold(strings.TrimSpace(warehouse.City), strings.TrimSpace(address.City)):
+		return 1
+	}
+	return 0
+}
+
+// applyMovement mengubah StockLevel sesuai perpindahan. Juga dipakai
+// replay journal.
+func applyMovement(movement StockMovement) {
+	defaultID := defaultWarehouseID()
+	if movement.FromWarehouseID != "" && movement.FromWarehouseID != defaultID {
+		addStockLevel(movement.FromWarehouseID, movement.ProductID, -movement.Quantity)
+	}
+	if movement.ToWarehouseID != "" && movement.ToWarehouseID != defaultID {
+		addStockLevel(movement.ToWarehouseID, movement.ProductID, movement.Quantity)
+	}
+	stockMovements = append(stockMovements, movement)
+}
+
+func addStockLevel(warehouseID, productID string, quantity int) {
+	if i := stockLevelIndex(warehouseID, productID); i >= 0 {
+		stockLevels[i].Quantity += quantity
+		return
+	}
+	stockLevels = append(stockLevels, StockLevel{WarehouseID: warehouseID, ProductID: productID, Quantity: quantity})
+}
+
+// removeWarehouse juga dipakai replay journal. Gudang yang dihapus tidak
+// punya stock lagi selain milik produk yang sudah dihapus, jadi
+// StockLevel-nya ikut dibuang.
+func removeWarehouse(id string) {
+	if i := warehouseIndex(id); i >= 0 {
+		warehouses = append(warehouses[:i], warehouses[i+1:]...)
+	}
+	kept := stockLevels[:0]
+	for _, level := range stockLevels {
+		if level.WarehouseID != id {
+			kept = append(kept, level)
+		}
+	}
+	stockLevels = kept
+}
+
+// removeProductStock membuang StockLevel produk yang dihapus. Juga dipakai
+// replay journal.
+func removeProductStock(productID string) {
+	kept := stockLevels[:0]
+	for _, level := range stockLevels {
+		if level.ProductID != productID {
+			kept = append(kept, level)
+		}
+	}
+	stockLevels = kept
+}
+
+// Warehouse handlers
+func getWarehouses(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Warehouses retrieved successfully",
+		Data:    warehouses,
+		Error:   nil,
+	})
+}
+
+func getWarehouse(c *gin.Context) {
+	id := c.Param("id")
+	i := warehouseIndex(id)
+	if i < 0 {
+		respondError(c, http.StatusNotFound, "Warehouse not found", apierror.NotFound("Warehouse", id))
+		return
+	}
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Warehouse retrieved successfully",
+		Data:    warehouses[i],
+		Error:   nil,
+	})
+}
+
+// createWarehouse membuat gudang baru. Gudang pertama menjadi gudang utama
+// dan menampung semua stock produk yang sudah ada.
+func createWarehouse(c *gin.Context) {
+	var newWarehouse Warehouse
+	if !bindWarehouse(c, &newWarehouse) {
+		return
+	}
+
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		newWarehouse.ID = generateID()
+		newWarehouse.Default = len(warehouses) == 0
+		return func() { warehouses = append(warehouses, newWarehouse) }, []events.Message{{Type: EventWarehouseCreated, Data: newWarehouse}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Warehouse created", "warehouse_id", newWarehouse.ID, "default", newWarehouse.Default)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Warehouse created successfully",
+		Data:    newWarehouse,
+		Error:   nil,
+	})
+}
+
+func updateWarehouse(c *gin.Context) {
+	id := c.Param("id")
+	if warehouseIndex(id) < 0 {
+		respondError(c, http.StatusNotFound, "Warehouse not found", apierror.NotFound("Warehouse", id))
+		return
+	}
+
+	var updated Warehouse
+	if !bindWarehouse(c, &updated) {
+		return
+	}
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		i := warehouseIndex(id)
+		if i < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Warehouse not found", apierror.NotFound("Warehouse", id))
+		}
+		updated.ID, updated.Default = id, warehouses[i].Default
+		return func() { warehouses[i] = updated }, []events.Message{{Type: EventWarehouseUpdated, Data: updated}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Warehouse updated", "warehouse_id", id)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Warehouse updated successfully",
+		Data:    updated,
+		Error:   nil,
+	})
+}
+
+// deleteWarehouse hanya untuk gudang tanpa stock. Gudang utama hanya bisa
+// dihapus kalau tidak ada gudang lain; stock-nya tetap di Product.Stock.
+func deleteWarehouse(c *gin.Context) {
+	id := c.Param("id")
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		i := warehouseIndex(id)
+		if i < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Warehouse not found", apierror.NotFound("Warehouse", id))
+		}
+		warehouse := warehouses[i]
+
+		if warehouse.Default && len(warehouses) > 1 {
+			return nil, nil, reject(http.StatusConflict, "Warehouse in use", apierror.New(apierror.CodeConflict, "The default warehouse can only be deleted when it is the last warehouse"))
+		}
+		for _, level := range stockLevels {
+			if _, found := findProduct(level.ProductID); found && level.WarehouseID == id && level.Quantity > 0 {
+				return nil, nil, reject(http.StatusConflict, "Warehouse in use", apierror.Newf(apierror.CodeConflict, "Warehouse %s still holds %d units of product %s; transfer them first", id, level.Quantity, level.ProductID))
+			}
+		}
+		return func() { removeWarehouse(id) }, []events.Message{{Type: EventWarehouseDeleted, Data: warehouse}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Warehouse deleted", "warehouse_id", id)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Warehouse deleted successfully",
+		Data:    nil,
+		Error:   nil,
+	})
+}
+
+// getWarehouseStock menampilkan stock semua produk di gudang
+func getWarehouseStock(c *gin.Context) {
+	id := c.Param("id")
+	if warehouseIndex(id) < 0 {
+		respondError(c, http.StatusNotFound, "Warehouse not found", apierror.NotFound("Warehouse", id))
+		return
+	}
+
+	levels := []StockLevel{}
+	for _, product := range products {
+		levels = append(levels, StockLevel{WarehouseID: id, ProductID: product.ID, Quantity: stockIn(product, id)})
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Warehouse stock retrieved successfully",
+		Data:    levels,
+		Error:   nil,
+	})
+}
+
+// getProductStockLevels menampilkan stock produk per gudang. Jumlahnya
+// sama dengan Product.Stock.
+func getProductStockLevels(c *gin.Context) {
+	id := c.Param("id")
+	product, found := findProduct(id)
+	if !found {
+		respondError(c, http.StatusNotFound, "Product not found", apierror.NotFound("Product", id))
+		return
+	}
+
+	levels := []StockLevel{}
+	for _, warehouse := range warehouses {
+		levels = append(levels, StockLevel{WarehouseID: warehouse.ID, ProductID: id, Quantity: stockIn(product, warehouse.ID)})
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Product stock levels retrieved successfully",
+		Data:    levels,
+		Error:   nil,
+	})
+}
+
+// setWarehouseStock mengatur stock produk di satu gudang (stock opname).
+// Product.Stock ikut berubah sebesar selisihnya.
+func setWarehouseStock(c *gin.Context) {
+	id, productID := c.Param("id"), c.Param("product_id")
+
+	var req WarehouseStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+	var delta int
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		if warehouseIndex(id) < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Warehouse not found", apierror.NotFound("Warehouse", id))
+		}
+		index, err := productIndex(productID)
+		if err != nil {
+			return nil, nil, reject(http.StatusNotFound, "Product not found", apierror.NotFound("Product", productID))
+		}
+		product := products[index]
+
+		delta = *req.Quantity - stockIn(product, id)
+		if delta == 0 {
+			return nil, nil, nil
+		}
+		movement := StockMovement{ProductID: productID, Quantity: delta, Reason: StockReasonAdjustment, CreatedAt: time.Now().UTC()}
+		if delta > 0 {
+			movement.ToWarehouseID = id
+		} else {
+			movement.FromWarehouseID, movement.Quantity = id, -delta
+		}
+		movement.ID = generateID()
+		updatedProduct := product
+		updatedProduct.Stock += delta
+
+		return func() {
+			products[index] = updatedProduct
+			applyMovement(movement)
+		}, append(stockChanged(product, updatedProduct, StockReasonAdjustment), events.Message{Type: EventStockMoved, Data: movement}), nil
+	}) {
+		return
+	}
+	if delta != 0 {
+		logging.From(c).Info("Warehouse stock adjusted", "warehouse_id", id, "product_id", productID, "delta", delta)
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Warehouse stock updated successfully",
+		Data:    StockLevel{WarehouseID: id, ProductID: productID, Quantity: *req.Quantity},
+		Error:   nil,
+	})
+}
+
+// transferStock memindahkan stock antar gudang. Product.Stock tidak
+// berubah. Stock yang sedang ditahan untuk pembayaran tidak bisa dipindah.
+func transferStock(c *gin.Context) {
+	var req StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+	product, found := findProduct(req.ProductID)
+	if !found {
+		respondError(c, http.StatusNotFound, "Product not found", apierror.NotFound("Product", req.ProductID))
+		return
+	}
+
+	// Hold dipakai supaya transaksi yang sedang dibayar tidak kehilangan stock
+	from, available, ok := holdStock(product, []string{req.FromWarehouseID}, req.Quantity)
+	if !ok {
+		respondError(c, http.StatusBadRequest, "Insufficient stock", apierror.Newf(apierror.CodeInsufficientStock, "Available stock in warehouse %s: %d, requested: %d", req.FromWarehouseID, available, req.Quantity))
+		return
+	}
+	defer releaseStock(product.ID, from, req.Quantity)
+
+	movement := StockMovement{
+		ProductID:       req.ProductID,
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Quantity:        req.Quantity,
+		Reason:          StockReasonTransfer,
+		CreatedAt:       time.Now().UTC(),
+	}
+	// Produk, stock dan gudang tujuan bisa berubah sejak dicek, jadi dibaca
+	// ulang di bawah lock yang sama dengan perpindahannya
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		index, err := productIndex(req.ProductID)
+		if err != nil {
+			return nil, nil, reject(http.StatusNotFound, "Product not found", apierror.NotFound("Product", req.ProductID))
+		}
+		if available := stockIn(products[index], req.FromWarehouseID); available < req.Quantity {
+			return nil, nil, reject(http.StatusBadRequest, "Insufficient stock", apierror.Newf(apierror.CodeInsufficientStock, "Available stock in warehouse %s: %d, requested: %d", req.FromWarehouseID, available, req.Quantity))
+		}
+		if warehouseIndex(req.ToWarehouseID) < 0 {
+			return nil, nil, reject(http.StatusNotFound, "Warehouse not found", apierror.NotFound("Warehouse", req.ToWarehouseID))
+		}
+		movement.ID = generateID()
+		return func() { applyMovement(movement) }, []events.Message{{Type: EventStockMoved, Data: movement}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Stock transferred", "product_id", movement.ProductID, "from", movement.FromWarehouseID, "to", movement.ToWarehouseID, "quantity", movement.Quantity)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Stock transferred successfully",
+		Data:    movement,
+		Error:   nil,
+	})
+}
+
+func getStockMovements(c *gin.Context) {
+	var query StockMovementQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid query", apierror.InvalidBody(err))
+		return
+	}
+
+	filtered := []StockMovement{}
+	for _, movement := range stockMovements {
+		if query.ProductID != "" && movement.ProductID != query.ProductID {
+			continue
+		}
+		if query.WarehouseID != "" && movement.FromWarehouseID != query.WarehouseID && movement.ToWarehouseID != query.WarehouseID {
+			continue
+		}
+		filtered = append(filtered, movement)
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Stock movements retrieved successfully",
+		Data:    filtered,
+		Error:   nil,
+	})
+}
+
+func bindWarehouse(c *gin.Context, warehouse *Warehouse) bool {
+	if err := c.ShouldBindJSON(warehouse); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return false
+	}
+	warehouse.Country = strings.ToUpper(strings.TrimSpace(warehouse.Country))
+	if fields := validate.Struct(warehouse); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return false
+	}
+	return true
+}
+
+func warehouseIndex(id string) int {
+	for i := range warehouses {
+		if warehouses[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func stockLevelIndex(warehouseID, productID string) int {
+	for i := range stockLevels {
+		if stockLevels[i].WarehouseID == warehouseID && stockLevels[i].ProductID == productID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"e-commerce/internal/auth"
+	"e-commerce/internal/config"
+)
+
+func TestWarehouseStockSumsToProductStock(t *testing.T) {
+	api := newTestAPI(t,
+		auth.User{ID: "u1", Username: "admin", Role: auth.RoleAdmin},
+		auth.User{ID: "u2", Username: "staff", Role: auth.RoleStaff},
+		auth.User{ID: "u3", Username: "customer", Role: auth.RoleCustomer},
+	)
+	do := api.do
+	levels := func() map[string]int {
+		t.Helper()
+		var list []StockLevel
+		if code := do("staff", http.MethodGet, "/products/2/stock-levels", "", &list); code != http.StatusOK {
+			t.Fatalf("stock levels status %d", code)
+		}
+		byWarehouse := map[string]int{}
+		for _, level := range list {
+			byWarehouse[level.WarehouseID] = level.Quantity
+		}
+		return byWarehouse
+	}
+	stock := func() int {
+		product, _ := findProduct("2")
+		return product.Stock
+	}
+
+	// Mouse demo: 50 unit, 20 di Surabaya dan sisanya di gudang utama Jakarta
+	if got := levels(); got["1"] != 30 || got["2"] != 20 {
+		t.Fatalf("demo stock levels %v", got)
+	}
+
+	// Transfer tidak mengubah stock produk
+	if code := do("staff", http.MethodPost, "/stock-transfers", `{"product_id":"2","from_warehouse_id":"2","to_warehouse_id":"1","quantity":25}`, nil); code != http.StatusBadRequest {
+		t.Errorf("transfer over stock status %d", code)
+	}
+	if code := do("staff", http.MethodPost, "/stock-transfers", `{"product_id":"2","from_warehouse_id":"1","to_warehouse_id":"1","quantity":1}`, nil); code != http.StatusBadRequest {
+		t.Errorf("transfer to same warehouse status %d", code)
+	}
+	if code := do("staff", http.MethodPost, "/stock-transfers", `{"product_id":"2","from_warehouse_id":"1","to_warehouse_id":"2","quantity":10}`, nil); code != http.StatusCreated {
+		t.Fatalf("transfer status %d", code)
+	}
+	if got := levels(); got["1"] != 20 || got["2"] != 30 || stock() != 50 {
+		t.Errorf("after transfer levels %v, stock %d", got, stock())
+	}
+
+	// Update produk hanya mengubah gudang utama
+	if code := do("staff", http.MethodPatch, "/products/2/stock", `{"stock":25}`, nil); code != http.StatusConflict {
+		t.Errorf("stock below other warehouses status %d", code)
+	}
+
+	// Gudang yang dipilih harus punya stock cukup; tanpa pilihan, gudang
+	// dengan stock terbanyak
+	if code := do("customer", http.MethodPost, "/transactions", `{"product_id":"2","quantity":25,"warehouse_id":"1"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("transaction over warehouse stock status %d", code)
+	}
+	var transaction Transaction
+	if code := do("customer", http.MethodPost, "/transactions", `{"product_id":"2","quantity":5}`, &transaction); code != http.StatusCreated || transaction.WarehouseID != "2" {
+		t.Fatalf("transaction status %d: %+v", code, transaction)
+	}
+	if got := levels(); got["1"] != 20 || got["2"] != 25 || stock() != 45 {
+		t.Errorf("after transaction levels %v, stock %d", got, stock())
+	}
+
+	// Aturan nearest memilih gudang di kota tujuan
+	allocationRule = config.AllocationNearest
+	t.Cleanup(func() { allocationRule = config.AllocationMostStock })
+	address := `{"name":"Budi","line1":"Jl. Sudirman 1","city":"Jakarta","postal_code":"10220","country":"ID"}`
+	if code := do("customer", http.MethodPost, "/transactions", `{"product_id":"2","quantity":1,"shipping_address":`+address+`}`, &transaction); code != http.StatusCreated || transaction.WarehouseID != "1" {
+		t.Errorf("nearest transaction status %d: %+v", code, transaction)
+	}
+
+	var movements []StockMovement
+	if do("staff", http.MethodGet, "/stock-movements?warehouse_id=2", "", &movements); len(movements) != 2 || movements[1].Reason != StockReasonTransaction {
+		t.Errorf("movements %+v", movements)
+	}
+
+	// Gudang hanya bisa dihapus kalau kosong; stock opname ke 0 ikut
+	// mengurangi stock produk
+	if code := do("admin", http.MethodDelete, "/warehouses/2", "", nil); code != http.StatusConflict {
+		t.Errorf("delete warehouse with stock status %d", code)
+	}
+	if code := do("staff", http.MethodPut, "/warehouses/2/stock/2", `{"quantity":0}`, nil); code != http.StatusOK || stock() != 19 {
+		t.Fatalf("stock count status %d, stock %d", code, stock())
+	}
+	if code := do("admin", http.MethodDelete, "/warehouses/1", "", nil); code != http.StatusConflict {
+		t.Errorf("delete default warehouse status %d", code)
+	}
+	if code := do("admin", http.MethodDelete, "/warehouses/2", "", nil); code != http.StatusOK {
+		t.Errorf("delete empty warehouse status %d", code)
+	}
+	if got := levels(); len(got) != 1 || got["1"] != 19 {
+		t.Errorf("after delete levels %v", got)
+	}
+}
+
+func TestDeleteProductDropsStockLevels(t *testing.T) {
+	api := newTestAPI(t, auth.User{ID: "u1", Username: "admin", Role: auth.RoleAdmin})
+
+	// Stock Mouse di Surabaya ikut hilang, jadi gudangnya bisa dihapus
+	if code := api.do("admin", http.MethodDelete, "/products/2", "", nil); code != http.StatusOK {
+		t.Fatalf("delete product status %d", code)
+	}
+	for _, level := range stockLevels {
+		if level.ProductID == "2" {
+			t.Errorf("stock level left after delete: %+v", level)
+		}
+	}
+	if code := api.do("admin", http.MethodDelete, "/warehouses/2", "", nil); code != http.StatusOK {
+		t.Errorf("delete warehouse status %d", code)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"e-commerce/internal/apierror"
+	"e-commerce/internal/events"
+	"e-commerce/internal/logging"
+	"e-commerce/internal/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookRequest struct {
+	URL         string   `json:"url" validate:"required,http_url,max=2000"`
+	Events      []string `json:"events" validate:"required,min=1,dive,webhook_event"`
+	Description string   `json:"description" validate:"max=200"`
+	Active      *bool    `json:"active" doc:"Default true"`
+}
+
+type DeliveryQuery struct {
+	Status string `form:"status" validate:"omitempty,oneof=pending retrying succeeded failed"`
+}
+
+// Domain event webhook. Data webhook.created dan webhook.updated berisi
+// secret supaya bisa di-replay dari journal, jadi tidak diteruskan ke
+// webhook.
+const (
+	EventWebhookCreated = "webhook.created"
+	EventWebhookUpdated = "webhook.updated"
+	EventWebhookDeleted = "webhook.deleted"
+)
+
+var webhooks = webhook.New(webhook.Options{})
+
+// Webhook handlers
+func createWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	// Validasi
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	active := req.Active == nil || *req.Active
+	var sub webhook.Subscription
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		var err error
+		if sub, err = webhooks.NewSubscription(req.URL, req.Events, req.Description, active); err != nil {
+			return nil, nil, reject(http.StatusInternalServerError, "Could not create webhook", apierror.New(apierror.CodeInternal, err.Error()))
+		}
+		return func() { webhooks.Put(sub) }, []events.Message{{Type: EventWebhookCreated, Data: sub}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Webhook created", "webhook_id", sub.ID, "events", sub.Events)
+
+	c.JSON(http.StatusCreated, APIResponse{
+		Message: "Webhook created successfully",
+		Data:    sub,
+		Error:   nil,
+	})
+}
+
+func getWebhooks(c *gin.Context) {
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Webhooks retrieved successfully",
+		Data:    webhooks.List(),
+		Error:   nil,
+	})
+}
+
+func getWebhook(c *gin.Context) {
+	id := c.Param("id")
+
+	sub, err := webhooks.Get(id)
+	if err != nil {
+		respondWebhookError(c, id, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Webhook retrieved successfully",
+		Data:    sub,
+		Error:   nil,
+	})
+}
+
+func updateWebhook(c *gin.Context) {
+	id := c.Param("id")
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request body", apierror.InvalidBody(err))
+		return
+	}
+
+	// Validasi
+	if fields := validate.Struct(&req); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	active := req.Active == nil || *req.Active
+	var sub webhook.Subscription
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		var err error
+		if sub, err = webhooks.Updated(id, req.URL, req.Events, req.Description, active); err != nil {
+			return nil, nil, rejectWebhook(id, err)
+		}
+		return func() { webhooks.Put(sub) }, []events.Message{{Type: EventWebhookUpdated, Data: sub}}, nil
+	}) {
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Webhook updated successfully",
+		Data:    sub.Redacted(),
+		Error:   nil,
+	})
+}
+
+func deleteWebhook(c *gin.Context) {
+	id := c.Param("id")
+
+	if !commitFunc(c, func() (func(), []events.Message, error) {
+		sub, err := webhooks.Get(id)
+		if err != nil {
+			return nil, nil, rejectWebhook(id, err)
+		}
+		return func() { webhooks.Delete(id) }, []events.Message{{Type: EventWebhookDeleted, Data: sub}}, nil
+	}) {
+		return
+	}
+	logging.From(c).Info("Webhook deleted", "webhook_id", id)
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Webhook deleted successfully",
+		Data:    nil,
+		Error:   nil,
+	})
+}
+
+func getWebhookDeliveries(c *gin.Context) {
+	id := c.Param("id")
+
+	var query DeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid query", apierror.InvalidBody(err))
+		return
+	}
+	if fields := validate.Struct(&query); len(fields) > 0 {
+		respondError(c, http.StatusBadRequest, "Validation failed", apierror.Validation(fields...))
+		return
+	}
+
+	deliveries, err := webhooks.Deliveries(id, query.Status)
+	if err != nil {
+		respondWebhookError(c, id, "", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Webhook deliveries retrieved successfully",
+		Data:    deliveries,
+		Error:   nil,
+	})
+}
+
+func getWebhookDelivery(c *gin.Context) {
+	id := c.Param("id")
+	deliveryID := c.Param("delivery_id")
+
+	delivery, err := webhooks.Delivery(id, deliveryID)
+	if err != nil {
+		respondWebhookError(c, id, deliveryID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, APIResponse{
+		Message: "Webhook delivery retrieved successfully",
+		Data:    delivery,
+		Error:   nil,
+	})
+}
+
+func redeliverWebhook(c *gin.Context) {
+	id := c.Param("id")
+	deliveryID := c.Param("delivery_id")
+
+	delivery, err := webhooks.Redeliver(id, deliveryID)
+	if err != nil {
+		respondWebhookError(c, id, deliveryID, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, APIResponse{
+		Message: "Webhook delivery scheduled",
+		Data:    delivery,
+		Error:   nil,
+	})
+}
+
+func respondWebhookError(c *gin.Context, id, deliveryID string, err error) {
+	switch {
+	case errors.Is(err, webhook.ErrSubscriptionNotFound):
+		respondError(c, http.StatusNotFound, "Webhook not found", apierror.NotFound("Webhook", id))
+	case errors.Is(err, webhook.ErrDeliveryNotFound):
+		respondError(c, http.StatusNotFound, "Delivery not found", apierror.NotFound("Delivery", deliveryID))
+	case errors.Is(err, webhook.ErrClosed):
+		respondError(c, http.StatusServiceUnavailable, "Server is shutting down", apierror.New(apierror.CodeUnavailable, err.Error()))
+	default:
+		respondError(c, http.StatusInternalServerError, "Webhook error", apierror.New(apierror.CodeInternal, err.Error()))
+	}
+}
+
+// rejectWebhook seperti respondWebhookError untuk dipakai di dalam prepare
+func rejectWebhook(id string, err error) error {
+	if errors.Is(err, webhook.ErrSubscriptionNotFound) {
+		return reject(http.StatusNotFound, "Webhook not found", apierror.NotFound("Webhook", id))
+	}
+	return reject(http.StatusInternalServerError, "Webhook error", apierror.New(apierror.CodeInternal, err.Error()))
+}